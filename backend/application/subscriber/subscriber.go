@@ -0,0 +1,259 @@
+// Package subscriber turns outbox events into queued emails and security
+// audit rows. Handlers run in the transaction that records their delivery
+// (see outbox.Dispatcher), so each row is written exactly once.
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/outbox"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+)
+
+// ============================================================================
+// PORTS
+// ============================================================================
+
+// Email is a message for the email_queue worker
+type Email struct {
+	UserID   int
+	To       string
+	Subject  string
+	Body     string
+	Template string
+	Data     map[string]any
+	Priority int // 1 (highest) to 10 (lowest)
+}
+
+// EmailQueue queues emails for sending
+type EmailQueue interface {
+	Enqueue(ctx context.Context, email *Email) error
+}
+
+// SecurityLog records security audit events
+type SecurityLog interface {
+	Record(ctx context.Context, event *user.SecurityEvent) error
+}
+
+// PasswordResets looks up issued password reset tokens
+type PasswordResets interface {
+	// GetPasswordReset returns user.ErrResetTokenNotFound for unknown IDs
+	GetPasswordReset(ctx context.Context, id int) (*user.PasswordResetToken, error)
+}
+
+// Security event types written by the subscribers
+const (
+	SecurityEventAccountLockout      = "account_lockout"
+	SecurityEventAccountStatusChange = "account_status_change"
+	SecurityEventPasswordReset       = "password_reset"
+	SecurityEventAccountDeleted      = "account_deleted"
+	SecurityEventAccountRestored     = "account_restored"
+)
+
+// ============================================================================
+// REGISTRATION
+// ============================================================================
+
+// Register subscribes the email and security audit handlers to d.
+// Subscriber names are stored with each delivery; do not rename them.
+func Register(d *outbox.Dispatcher, emails EmailQueue, audit SecurityLog, resets PasswordResets) {
+	d.Subscribe("email.welcome", user.EventUserRegistered, sendEmail(emails, welcomeEmail))
+	d.Subscribe("email.password_reset", user.EventPasswordResetRequested, sendPasswordResetEmail(emails, resets))
+	d.Subscribe("email.account_locked", user.EventAccountLocked, sendEmail(emails, accountLockedEmail))
+	d.Subscribe("email.account_status", user.EventAccountStatusChanged, sendEmail(emails, accountStatusEmail))
+
+	d.Subscribe("audit.account_locked", user.EventAccountLocked, recordSecurity(audit, accountLockedAudit))
+	d.Subscribe("audit.account_status", user.EventAccountStatusChanged, recordSecurity(audit, accountStatusAudit))
+	d.Subscribe("audit.password_reset", user.EventPasswordResetRequested, recordSecurity(audit, passwordResetAudit))
+	d.Subscribe("audit.user_deleted", user.EventUserDeleted, recordSecurity(audit, userDeletedAudit))
+	d.Subscribe("audit.user_restored", user.EventUserRestored, recordSecurity(audit, userRestoredAudit))
+}
+
+// sendEmail adapts a payload-to-email function to an outbox.Handler.
+// A nil email means the event needs no message.
+func sendEmail[T any](emails EmailQueue, build func(payload *T) *Email) outbox.Handler {
+	return func(ctx context.Context, event *outbox.Event) error {
+		var payload T
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		email := build(&payload)
+		if email == nil {
+			return nil
+		}
+		return emails.Enqueue(ctx, email)
+	}
+}
+
+// sendPasswordResetEmail emails the reset token of the event, read from
+// its row because payloads never carry the token. Used and deleted tokens
+// need no message.
+func sendPasswordResetEmail(emails EmailQueue, resets PasswordResets) outbox.Handler {
+	return func(ctx context.Context, event *outbox.Event) error {
+		var payload user.PasswordResetRequestedEvent
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		token, err := resets.GetPasswordReset(ctx, payload.TokenID)
+		if errors.Is(err, user.ErrResetTokenNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if token.UsedAt != nil {
+			return nil
+		}
+		return emails.Enqueue(ctx, passwordResetEmail(&payload, token))
+	}
+}
+
+// recordSecurity adapts a payload-to-security-event function to an outbox.Handler
+func recordSecurity[T any](audit SecurityLog, build func(payload *T) *user.SecurityEvent) outbox.Handler {
+	return func(ctx context.Context, event *outbox.Event) error {
+		var payload T
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return audit.Record(ctx, build(&payload))
+	}
+}
+
+// ============================================================================
+// EMAILS
+// ============================================================================
+
+func welcomeEmail(e *user.UserRegisteredEvent) *Email {
+	return &Email{
+		UserID:   e.UserID,
+		To:       e.Email,
+		Subject:  "Welcome to SurvivalPro",
+		Body:     fmt.Sprintf("Hi %s,\n\nYour SurvivalPro account has been created.", e.Name),
+		Template: "welcome",
+		Data:     map[string]any{"name": e.Name},
+		Priority: 5,
+	}
+}
+
+func passwordResetEmail(e *user.PasswordResetRequestedEvent, token *user.PasswordResetToken) *Email {
+	return &Email{
+		UserID:  e.UserID,
+		To:      e.Email,
+		Subject: "Reset your SurvivalPro password",
+		Body: fmt.Sprintf("Use this code to reset your password: %s\n\nIt expires at %s. If you did not ask for a reset, ignore this email.",
+			token.Token, token.ExpiresAt.UTC().Format(time.RFC1123)),
+		Template: "password_reset",
+		Data:     map[string]any{"token": token.Token, "expires_at": token.ExpiresAt},
+		Priority: 1,
+	}
+}
+
+func accountLockedEmail(e *user.AccountLockedEvent) *Email {
+	return &Email{
+		UserID:  e.UserID,
+		To:      e.Email,
+		Subject: "Your SurvivalPro account was locked",
+		Body: fmt.Sprintf("Your account was locked after %d failed sign-in attempts and unlocks at %s. If this was not you, reset your password.",
+			e.FailedAttempts, e.LockedUntil.UTC().Format(time.RFC1123)),
+		Template: "account_locked",
+		Data:     map[string]any{"failed_attempts": e.FailedAttempts, "locked_until": e.LockedUntil, "ip_address": e.IPAddress},
+		Priority: 2,
+	}
+}
+
+func accountStatusEmail(e *user.AccountStatusChangedEvent) *Email {
+	var body string
+	switch e.To {
+	case user.AccountSuspended:
+		body = "Your SurvivalPro account has been suspended."
+	case user.AccountBanned:
+		body = "Your SurvivalPro account has been banned."
+	case user.AccountActive:
+		body = "Your SurvivalPro account is active again."
+	default:
+		return nil // Pending and closed accounts are not notified
+	}
+	if e.Reason != "" {
+		body += "\n\nReason: " + e.Reason
+	}
+
+	return &Email{
+		UserID:   e.UserID,
+		To:       e.Email,
+		Subject:  "Your SurvivalPro account status changed",
+		Body:     body,
+		Template: "account_status",
+		Data:     map[string]any{"status": e.To, "reason": e.Reason},
+		Priority: 3,
+	}
+}
+
+// ============================================================================
+// SECURITY AUDIT
+// ============================================================================
+
+func accountLockedAudit(e *user.AccountLockedEvent) *user.SecurityEvent {
+	return &user.SecurityEvent{
+		UserID:      &e.UserID,
+		EventType:   SecurityEventAccountLockout,
+		Severity:    "medium",
+		Description: fmt.Sprintf("Locked after %d failed login attempts until %s", e.FailedAttempts, e.LockedUntil.UTC().Format(time.RFC3339)),
+		IPAddress:   e.IPAddress,
+	}
+}
+
+func accountStatusAudit(e *user.AccountStatusChangedEvent) *user.SecurityEvent {
+	severity := "low"
+	if e.To == user.AccountSuspended || e.To == user.AccountBanned {
+		severity = "high"
+	}
+
+	description := fmt.Sprintf("Account status changed from %s to %s by user %d", e.From, e.To, e.ChangedBy)
+	if e.Reason != "" {
+		description += ": " + e.Reason
+	}
+	return &user.SecurityEvent{
+		UserID:      &e.UserID,
+		EventType:   SecurityEventAccountStatusChange,
+		Severity:    severity,
+		Description: description,
+		IPAddress:   e.IPAddress,
+	}
+}
+
+func passwordResetAudit(e *user.PasswordResetRequestedEvent) *user.SecurityEvent {
+	return &user.SecurityEvent{
+		UserID:      &e.UserID,
+		EventType:   SecurityEventPasswordReset,
+		Severity:    "low",
+		Description: "Password reset requested",
+		IPAddress:   e.IPAddress,
+	}
+}
+
+func userDeletedAudit(e *user.UserDeletedEvent) *user.SecurityEvent {
+	reason := e.Reason
+	if reason == "" {
+		reason = "Not specified"
+	}
+	return &user.SecurityEvent{
+		UserID:      &e.UserID,
+		EventType:   SecurityEventAccountDeleted,
+		Severity:    "high",
+		Description: fmt.Sprintf("User account was soft deleted by user %d. Reason: %s", e.DeletedBy, reason),
+		IPAddress:   e.IPAddress,
+	}
+}
+
+func userRestoredAudit(e *user.UserRestoredEvent) *user.SecurityEvent {
+	return &user.SecurityEvent{
+		UserID:      &e.UserID,
+		EventType:   SecurityEventAccountRestored,
+		Severity:    "medium",
+		Description: fmt.Sprintf("User account was restored by user %d", e.RestoredBy),
+		IPAddress:   e.IPAddress,
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/application/validation"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/outbox"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
 )
@@ -20,7 +23,7 @@ import (
 var (
 	ErrInvalidCredentials = errors.New("invalid_credentials")
 	ErrAccountLocked      = errors.New("account_locked")
-	ErrUserNotFound       = errors.New("user_not_found")
+	ErrUserNotFound       = user.ErrUserNotFound
 	ErrEmailNotVerified   = errors.New("email_not_verified")
 	ErrInvalidToken       = errors.New("invalid_token")
 	ErrTokenExpired       = errors.New("token_expired")
@@ -40,17 +43,24 @@ type LoginUseCase struct {
 	securityRepo   SecurityRepository
 	sessionRepo    SessionRepository
 	activityRepo   ActivityRepository
+	txManager      TxManager
+	events         EventOutbox
 	rateLimiter    ratelimit.Limiter
+	clock          clock.Clock
 }
 
-// NewLoginUseCase creates a new login use case
+// NewLoginUseCase creates a new login use case.
+// A nil txManager runs every write on its own; a nil events drops events.
 func NewLoginUseCase(
 	userRepo UserRepository,
 	credentialRepo CredentialRepository,
 	securityRepo SecurityRepository,
 	sessionRepo SessionRepository,
 	activityRepo ActivityRepository,
+	txManager TxManager,
+	events EventOutbox,
 	rateLimiter ratelimit.Limiter,
+	clk clock.Clock,
 ) *LoginUseCase {
 	return &LoginUseCase{
 		userRepo:       userRepo,
@@ -58,7 +68,10 @@ func NewLoginUseCase(
 		securityRepo:   securityRepo,
 		sessionRepo:    sessionRepo,
 		activityRepo:   activityRepo,
+		txManager:      orNoTx(txManager),
+		events:         orNoEvents(events),
 		rateLimiter:    rateLimiter,
+		clock:          clock.OrSystem(clk),
 	}
 }
 
@@ -89,12 +102,12 @@ func (uc *LoginUseCase) Execute(ctx context.Context, req *user.LoginRequest, ipA
 		return nil, fmt.Errorf("rate limit check failed: %w", err)
 	}
 
-	if !ipStatus.IsAllowed() {
+	if !ipStatus.IsAllowedAt(uc.clock.Now()) {
 		uc.logLoginActivity(ctx, 0, req.Email, false, "Rate limited by IP", ipAddress)
 		return nil, &RateLimitError{
 			Action:     ratelimit.ActionLogin,
 			Status:     ipStatus,
-			RetryAfter: ipStatus.TimeUntilReset(),
+			RetryAfter: ipStatus.TimeUntilResetAt(uc.clock.Now()),
 		}
 	}
 
@@ -109,19 +122,20 @@ func (uc *LoginUseCase) Execute(ctx context.Context, req *user.LoginRequest, ipA
 		return nil, fmt.Errorf("rate limit check failed: %w", err)
 	}
 
-	if !emailStatus.IsAllowed() {
+	if !emailStatus.IsAllowedAt(uc.clock.Now()) {
 		uc.logLoginActivity(ctx, 0, req.Email, false, "Rate limited by email", ipAddress)
 		return nil, &RateLimitError{
 			Action:     ratelimit.ActionLogin,
 			Status:     emailStatus,
-			RetryAfter: emailStatus.TimeUntilReset(),
+			RetryAfter: emailStatus.TimeUntilResetAt(uc.clock.Now()),
 		}
 	}
 
 	// ========================================================================
 	// STEP 3: Find User
 	// ========================================================================
-	foundUser, err := uc.userRepo.FindByEmail(ctx, req.Email)
+	// Deleted users are found so the login policy reports them as deleted
+	foundUser, err := uc.userRepo.FindByEmail(ctx, req.Email, true)
 	if err != nil {
 		// Don't reveal if user exists (security best practice)
 		uc.logLoginActivity(ctx, 0, req.Email, false, "User not found", ipAddress)
@@ -145,7 +159,7 @@ func (uc *LoginUseCase) Execute(ctx context.Context, req *user.LoginRequest, ipA
 	// ========================================================================
 	// STEP 5: Check Account Locks (Domain Policy)
 	// ========================================================================
-	if securityInfo.IsLocked() {
+	if securityInfo.IsLockedAt(uc.clock.Now()) {
 		uc.logLoginActivity(ctx, foundUser.ID, req.Email, false, "Account locked", ipAddress)
 		return nil, ErrAccountLocked
 	}
@@ -165,13 +179,33 @@ func (uc *LoginUseCase) Execute(ctx context.Context, req *user.LoginRequest, ipA
 		return nil, ErrInvalidCredentials
 	}
 
-	// Verify password (use bcrypt or similar)
+	// Verify password (see HashPassword)
 	if !verifyPassword(credential.PasswordHash, req.Password) {
-		// Increment failed login attempts
-		securityInfo.IncrementFailedAttempts()
-		_ = uc.securityRepo.Update(ctx, securityInfo)
-
-		uc.logLoginActivity(ctx, foundUser.ID, req.Email, false, "Invalid password", ipAddress)
+		// Increment failed login attempts and log the attempt together,
+		// with the lockout event when this attempt locked the account
+		securityInfo.IncrementFailedAttemptsAt(uc.clock.Now())
+		locked := securityInfo.IsLockedAt(uc.clock.Now())
+		_ = uc.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			if err := uc.securityRepo.Update(ctx, securityInfo); err != nil {
+				return err
+			}
+			if locked {
+				err := recordUserEvent(ctx, uc.events, user.EventAccountLocked, foundUser.ID,
+					user.EventKey(user.EventAccountLocked, int64(foundUser.ID), securityInfo.LockedUntil.Unix()),
+					user.AccountLockedEvent{
+						UserID:         foundUser.ID,
+						Email:          foundUser.Email,
+						IPAddress:      ipAddress,
+						FailedAttempts: securityInfo.FailedLoginAttempts,
+						LockedUntil:    *securityInfo.LockedUntil,
+					})
+				if err != nil {
+					return err
+				}
+			}
+			uc.logLoginActivity(ctx, foundUser.ID, req.Email, false, "Invalid password", ipAddress)
+			return nil
+		})
 		return nil, ErrInvalidCredentials
 	}
 
@@ -197,23 +231,49 @@ func (uc *LoginUseCase) Execute(ctx context.Context, req *user.LoginRequest, ipA
 	_ = uc.rateLimiter.Reset(ctx, ipIdentifier, ratelimit.ActionLogin)
 	_ = uc.rateLimiter.Reset(ctx, emailIdentifier, ratelimit.ActionLogin)
 
-	// Reset failed login attempts
+	// Reset failed login attempts, create the session, emit the login event
+	// and log the login in one transaction so a crash cannot leave half of
+	// them behind
 	securityInfo.ResetFailedAttempts()
-	securityInfo.UpdateLastLogin()
-	_ = uc.securityRepo.Update(ctx, securityInfo)
+	securityInfo.UpdateLastLoginAt(uc.clock.Now())
 
-	// Create session
-	session := &user.UserSession{
-		UserID:     foundUser.ID,
-		DeviceID:   req.DeviceID,
-		DeviceName: req.DeviceName,
-		Platform:   req.Platform,
-		IPAddress:  &ipAddress,
-		ExpiresAt:  time.Now().Add(7 * 24 * time.Hour),
-	}
+	var session *user.UserSession
+	err = uc.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.securityRepo.Update(ctx, securityInfo); err != nil {
+			return fmt.Errorf("failed to update security info: %w", err)
+		}
 
-	if err := uc.sessionRepo.Create(ctx, session); err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+		session = &user.UserSession{
+			UserID:     foundUser.ID,
+			DeviceID:   req.DeviceID,
+			DeviceName: req.DeviceName,
+			Platform:   req.Platform,
+			IPAddress:  &ipAddress,
+			ExpiresAt:  uc.clock.Now().Add(7 * 24 * time.Hour),
+		}
+		if err := uc.sessionRepo.Create(ctx, session); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+
+		err := recordUserEvent(ctx, uc.events, user.EventUserLoggedIn, foundUser.ID,
+			user.EventKey(user.EventUserLoggedIn, int64(session.ID)),
+			user.UserLoggedInEvent{
+				UserID:     foundUser.ID,
+				Email:      foundUser.Email,
+				SessionID:  session.ID,
+				IPAddress:  ipAddress,
+				DeviceName: req.DeviceName,
+				Platform:   req.Platform,
+			})
+		if err != nil {
+			return err
+		}
+
+		uc.logLoginActivity(ctx, foundUser.ID, req.Email, true, "", ipAddress)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate tokens
@@ -222,16 +282,13 @@ func (uc *LoginUseCase) Execute(ctx context.Context, req *user.LoginRequest, ipA
 		return nil, fmt.Errorf("failed to generate tokens: %w", err)
 	}
 
-	// Log successful login
-	uc.logLoginActivity(ctx, foundUser.ID, req.Email, true, "", ipAddress)
-
 	// Return response
 	return &user.LoginResponse{
 		User:         foundUser.ToResponse(securityInfo, credential),
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		SessionID:    session.ID,
-		ExpiresAt:    time.Now().Add(15 * time.Minute),
+		ExpiresAt:    session.ExpiresAt,
 	}, nil
 }
 
@@ -242,18 +299,27 @@ func (uc *LoginUseCase) Execute(ctx context.Context, req *user.LoginRequest, ipA
 type PasswordResetUseCase struct {
 	userRepo    UserRepository
 	tokenRepo   TokenRepository
+	txManager   TxManager
+	events      EventOutbox
 	rateLimiter ratelimit.Limiter
+	clock       clock.Clock
 }
 
 func NewPasswordResetUseCase(
 	userRepo UserRepository,
 	tokenRepo TokenRepository,
+	txManager TxManager,
+	events EventOutbox,
 	rateLimiter ratelimit.Limiter,
+	clk clock.Clock,
 ) *PasswordResetUseCase {
 	return &PasswordResetUseCase{
 		userRepo:    userRepo,
 		tokenRepo:   tokenRepo,
+		txManager:   orNoTx(txManager),
+		events:      orNoEvents(events),
 		rateLimiter: rateLimiter,
+		clock:       clock.OrSystem(clk),
 	}
 }
 
@@ -272,11 +338,11 @@ func (uc *PasswordResetUseCase) RequestReset(ctx context.Context, email string,
 		return err
 	}
 
-	if !ipStatus.IsAllowed() {
+	if !ipStatus.IsAllowedAt(uc.clock.Now()) {
 		return &RateLimitError{
 			Action:     ratelimit.ActionPasswordReset,
 			Status:     ipStatus,
-			RetryAfter: ipStatus.TimeUntilReset(),
+			RetryAfter: ipStatus.TimeUntilResetAt(uc.clock.Now()),
 		}
 	}
 
@@ -289,38 +355,46 @@ func (uc *PasswordResetUseCase) RequestReset(ctx context.Context, email string,
 		return err
 	}
 
-	if !emailStatus.IsAllowed() {
+	if !emailStatus.IsAllowedAt(uc.clock.Now()) {
 		return &RateLimitError{
 			Action:     ratelimit.ActionPasswordReset,
 			Status:     emailStatus,
-			RetryAfter: emailStatus.TimeUntilReset(),
+			RetryAfter: emailStatus.TimeUntilResetAt(uc.clock.Now()),
 		}
 	}
 
 	// STEP 3: Find user
-	foundUser, err := uc.userRepo.FindByEmail(ctx, email)
+	foundUser, err := uc.userRepo.FindByEmail(ctx, email, false)
 	if err != nil {
 		// Don't reveal if user exists, but still rate limit
 		// This is a security best practice
 		return nil
 	}
 
-	// STEP 4: Generate and send reset token
-	token := generateSecureToken()
+	// STEP 4: Store the reset token; the email is sent by the outbox
+	// subscriber of the event committed with it
 	resetToken := &user.PasswordResetToken{
 		UserID:    foundUser.ID,
-		Token:     token,
-		ExpiresAt: time.Now().Add(1 * time.Hour),
-	}
-
-	if err := uc.tokenRepo.CreatePasswordReset(ctx, resetToken); err != nil {
-		return fmt.Errorf("failed to create reset token: %w", err)
+		Token:     generateSecureToken(),
+		ExpiresAt: uc.clock.Now().Add(1 * time.Hour),
+		IPAddress: &ipAddress,
 	}
 
-	// Send email (async)
-	go sendPasswordResetEmail(email, token)
+	return uc.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.tokenRepo.CreatePasswordReset(ctx, resetToken); err != nil {
+			return fmt.Errorf("failed to create reset token: %w", err)
+		}
 
-	return nil
+		return recordUserEvent(ctx, uc.events, user.EventPasswordResetRequested, foundUser.ID,
+			user.EventKey(user.EventPasswordResetRequested, int64(resetToken.ID)),
+			user.PasswordResetRequestedEvent{
+				UserID:    foundUser.ID,
+				Email:     foundUser.Email,
+				TokenID:   resetToken.ID,
+				ExpiresAt: resetToken.ExpiresAt,
+				IPAddress: ipAddress,
+			})
+	})
 }
 
 // ============================================================================
@@ -328,17 +402,29 @@ func (uc *PasswordResetUseCase) RequestReset(ctx context.Context, email string,
 // ============================================================================
 
 type RegistrationUseCase struct {
-	userRepo    UserRepository
-	rateLimiter ratelimit.Limiter
+	userRepo       UserRepository
+	credentialRepo CredentialRepository
+	txManager      TxManager
+	events         EventOutbox
+	rateLimiter    ratelimit.Limiter
+	clock          clock.Clock
 }
 
 func NewRegistrationUseCase(
 	userRepo UserRepository,
+	credentialRepo CredentialRepository,
+	txManager TxManager,
+	events EventOutbox,
 	rateLimiter ratelimit.Limiter,
+	clk clock.Clock,
 ) *RegistrationUseCase {
 	return &RegistrationUseCase{
-		userRepo:    userRepo,
-		rateLimiter: rateLimiter,
+		userRepo:       userRepo,
+		credentialRepo: credentialRepo,
+		txManager:      orNoTx(txManager),
+		events:         orNoEvents(events),
+		rateLimiter:    rateLimiter,
+		clock:          clock.OrSystem(clk),
 	}
 }
 
@@ -355,24 +441,54 @@ func (uc *RegistrationUseCase) Register(ctx context.Context, req *user.UserCreat
 		return err
 	}
 
-	if !status.IsAllowed() {
+	if !status.IsAllowedAt(uc.clock.Now()) {
 		return &RateLimitError{
 			Action:     ratelimit.ActionRegistration,
 			Status:     status,
-			RetryAfter: status.TimeUntilReset(),
+			RetryAfter: status.TimeUntilResetAt(uc.clock.Now()),
 		}
 	}
 
-	// STEP 3: Check if email already exists
-	existing, err := uc.userRepo.FindByEmail(ctx, req.Email)
+	// STEP 3: Check if email already exists (deleted accounts keep their
+	// email until they are purged)
+	existing, err := uc.userRepo.FindByEmail(ctx, req.Email, true)
 	if err == nil && existing != nil {
-		return errors.New("email_already_exists")
+		return user.ErrEmailAlreadyExists
+	}
+
+	// STEP 4: Hash the password before opening the transaction
+	passwordHash, err := HashPassword(req.Password)
+	if err != nil {
+		return err
 	}
 
-	// STEP 4: Create user
-	// ... implementation
+	// STEP 5: Create the user, its credentials and its registration event
+	// together; the welcome / verification email is sent by the outbox
+	// subscriber
+	newUser := &user.User{
+		Email: req.Email,
+		Name:  req.Name,
+		Role:  user.UserRoleUser,
+		Phone: req.Phone,
+	}
 
-	return nil
+	return uc.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.userRepo.Create(ctx, newUser); err != nil {
+			return err
+		}
+		if err := uc.credentialRepo.SetPassword(ctx, newUser.ID, passwordHash); err != nil {
+			return err
+		}
+
+		return recordUserEvent(ctx, uc.events, user.EventUserRegistered, newUser.ID,
+			user.EventKey(user.EventUserRegistered, int64(newUser.ID)),
+			user.UserRegisteredEvent{
+				UserID:    newUser.ID,
+				Email:     newUser.Email,
+				Name:      newUser.Name,
+				IPAddress: ipAddress,
+			})
+	})
 }
 
 // ============================================================================
@@ -393,30 +509,33 @@ func generateSecureToken() string {
 	return "secure_token"
 }
 
-// verifyPassword verifies password using bcrypt
-func verifyPassword(hash *string, password string) bool {
-	// Implementation using bcrypt.CompareHashAndPassword
-	return true
-}
-
 // verify2FACode verifies 2FA TOTP code
 func verify2FACode(secret *string, code string) bool {
 	// Implementation using TOTP library
 	return true
 }
 
-// sendPasswordResetEmail sends password reset email
-func sendPasswordResetEmail(email, token string) {
-	// Implementation using email service
+// generateTokens returns the access and refresh tokens of a session. The
+// access token is the opaque session token checked by
+// middleware.SessionAuth.
+func (uc *LoginUseCase) generateTokens(user *user.User, session *user.UserSession) (string, string, error) {
+	return session.SessionToken, session.RefreshToken, nil
 }
 
-// generateTokens generates access and refresh tokens
-func (uc *LoginUseCase) generateTokens(user *user.User, session *user.UserSession) (string, string, error) {
-	// Implementation using JWT
-	return "access_token", "refresh_token", nil
+// recordUserEvent adds a user event to the outbox, in the transaction of ctx
+func recordUserEvent(ctx context.Context, events EventOutbox, eventType string, userID int, key string, payload any) error {
+	event, err := outbox.NewEvent(eventType, user.AggregateUser, strconv.Itoa(userID), key, payload)
+	if err != nil {
+		return err
+	}
+	if err := events.Add(ctx, event); err != nil {
+		return fmt.Errorf("failed to record %s event: %w", eventType, err)
+	}
+	return nil
 }
 
-// logLoginActivity logs login attempt
+// logLoginActivity logs login attempt. Inside a transaction the insert runs
+// in a savepoint, so a failed log does not abort the surrounding writes.
 func (uc *LoginUseCase) logLoginActivity(ctx context.Context, userID int, email string, success bool, reason string, ipAddress string) {
 	activity := &user.LoginActivity{
 		UserID:    &userID,
@@ -425,7 +544,9 @@ func (uc *LoginUseCase) logLoginActivity(ctx context.Context, userID int, email
 		Reason:    &reason,
 		IPAddress: ipAddress,
 	}
-	_ = uc.activityRepo.CreateLoginActivity(ctx, activity)
+	_ = uc.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		return uc.activityRepo.CreateLoginActivity(ctx, activity)
+	})
 }
 
 // ============================================================================
@@ -459,12 +580,19 @@ func IsRateLimitError(err error) bool {
 // ============================================================================
 
 type UserRepository interface {
-	FindByEmail(ctx context.Context, email string) (*user.User, error)
+	FindByEmail(ctx context.Context, email string, includeDeleted bool) (*user.User, error)
+	FindByID(ctx context.Context, id int, includeDeleted bool) (*user.User, error)
 	Create(ctx context.Context, user *user.User) error
+	SoftDelete(ctx context.Context, id, deletedBy int, reason *string) (user.AccountStatus, int, error)
+	Restore(ctx context.Context, id, restoredBy int) (int, error)
+	LogStatusChange(ctx context.Context, change *user.AccountStatusChange) error
+	FindPurgeable(ctx context.Context, deletedBefore time.Time, limit int) ([]int, error)
+	Purge(ctx context.Context, id int) error
 }
 
 type CredentialRepository interface {
 	GetByUserID(ctx context.Context, userID int) (*user.UserCredential, error)
+	SetPassword(ctx context.Context, userID int, passwordHash string) error
 }
 
 type SecurityRepository interface {
@@ -475,6 +603,15 @@ type SecurityRepository interface {
 
 type SessionRepository interface {
 	Create(ctx context.Context, session *user.UserSession) error
+	RevokeAllForUser(ctx context.Context, userID, revokedBy int, reason string) (int64, error)
+}
+
+type PushTokenRepository interface {
+	DeactivateAllForUser(ctx context.Context, userID int, reason string) (int64, error)
+}
+
+type APIKeyRepository interface {
+	RevokeAllForUser(ctx context.Context, userID, revokedBy int, reason string) (int64, error)
 }
 
 type TokenRepository interface {
@@ -484,3 +621,46 @@ type TokenRepository interface {
 type ActivityRepository interface {
 	CreateLoginActivity(ctx context.Context, activity *user.LoginActivity) error
 }
+
+// TxManager runs fn in one database transaction. Repositories called with
+// the ctx passed to fn take part in it; nested calls use savepoints.
+// fn may be rerun after a serialization failure.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// noTx runs fn directly, without a transaction
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// orNoTx returns m, or noTx if m is nil
+func orNoTx(m TxManager) TxManager {
+	if m == nil {
+		return noTx{}
+	}
+	return m
+}
+
+// EventOutbox stores domain events. Events added with a transaction in ctx
+// commit or roll back with it (see outbox.Store).
+type EventOutbox interface {
+	Add(ctx context.Context, events ...*outbox.Event) error
+}
+
+// noEvents drops events
+type noEvents struct{}
+
+func (noEvents) Add(ctx context.Context, events ...*outbox.Event) error {
+	return nil
+}
+
+// orNoEvents returns o, or noEvents if o is nil
+func orNoEvents(o EventOutbox) EventOutbox {
+	if o == nil {
+		return noEvents{}
+	}
+	return o
+}
@@ -0,0 +1,167 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/application/usecase"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/persistence/memory"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+)
+
+func TestLoginWithMemoryRepositories(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	users := memory.NewUserRepository(clk)
+	credentials := memory.NewCredentialRepository(clk)
+	security := memory.NewSecurityRepository(clk)
+	sessions := memory.NewSessionRepository(clk)
+	activity := memory.NewActivityRepository(clk)
+	tx := memory.NewTxManager()
+	events := memory.NewOutboxStore(clk)
+
+	login := usecase.NewLoginUseCase(
+		users, credentials, security, sessions, activity, tx, events,
+		ratelimit.NewMemoryLimiter(ratelimit.DefaultRules(), clk), clk,
+	)
+
+	alice := &user.User{Email: "alice@example.com", Name: "Alice", Role: user.UserRoleUser, AccountStatus: user.AccountActive, EmailVerified: true}
+	if err := users.Create(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Create(ctx, &user.User{Email: "alice@example.com"}); !errors.Is(err, user.ErrEmailAlreadyExists) {
+		t.Errorf("duplicate Create = %v, want ErrEmailAlreadyExists", err)
+	}
+	hash, err := usecase.HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials.Put(&user.UserCredential{UserID: alice.ID, PasswordHash: &hash})
+
+	// Unknown emails are logged without a user
+	_, err = login.Execute(ctx, &user.LoginRequest{Email: "bob@example.com", Password: "secret"}, "10.0.0.1")
+	if !errors.Is(err, usecase.ErrInvalidCredentials) {
+		t.Fatalf("unknown email = %v, want ErrInvalidCredentials", err)
+	}
+
+	resp, err := login.Execute(ctx, &user.LoginRequest{Email: alice.Email, Password: "secret"}, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.User.ID != alice.ID || resp.SessionID == 0 || resp.AccessToken == "" {
+		t.Errorf("response = user %d session %d access token %q", resp.User.ID, resp.SessionID, resp.AccessToken)
+	}
+	if got := sessions.Sessions(alice.ID); len(got) != 1 || !got[0].ExpiresAt.Equal(clk.Now().Add(7*24*time.Hour)) {
+		t.Errorf("sessions = %+v", got)
+	}
+	info, err := security.GetByUserID(ctx, alice.ID)
+	if err != nil || info.LastLoginAt == nil || !info.LastLoginAt.Equal(clk.Now()) {
+		t.Errorf("security info = %+v, %v", info, err)
+	}
+
+	// The failed attempt logs in its own transaction, the login nests its log
+	if commits, rollbacks := tx.Counts(); commits != 3 || rollbacks != 0 {
+		t.Errorf("transactions = %d commits, %d rollbacks, want 3, 0", commits, rollbacks)
+	}
+
+	// The login event is committed with the session
+	records := events.Records()
+	if len(records) != 1 || records[0].Event.Type != user.EventUserLoggedIn ||
+		records[0].Event.IdempotencyKey != user.EventKey(user.EventUserLoggedIn, int64(resp.SessionID)) {
+		t.Errorf("outbox = %+v", records)
+	}
+
+	logins := activity.LoginActivities()
+	if len(logins) != 2 || logins[0].UserID != nil || !logins[1].Success {
+		t.Errorf("login activity = %+v", logins)
+	}
+
+	// A lock stored in the security info blocks the next login
+	until := clk.Now().Add(time.Hour)
+	info.LockedUntil = &until
+	_ = security.Update(ctx, info)
+	if _, err := login.Execute(ctx, &user.LoginRequest{Email: alice.Email, Password: "secret"}, "10.0.0.1"); !errors.Is(err, usecase.ErrAccountLocked) {
+		t.Errorf("locked login = %v, want ErrAccountLocked", err)
+	}
+}
+
+func TestRegisterRecordsEvent(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	users := memory.NewUserRepository(clk)
+	credentials := memory.NewCredentialRepository(clk)
+	events := memory.NewOutboxStore(clk)
+
+	register := usecase.NewRegistrationUseCase(
+		users, credentials, memory.NewTxManager(), events,
+		ratelimit.NewMemoryLimiter(ratelimit.DefaultRules(), clk), clk,
+	)
+	req := &user.UserCreateRequest{Email: "an@example.com", Password: "Str0ng!Passw0rd", Name: "Nguyễn Văn An"}
+	if err := register.Register(ctx, req, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := users.FindByEmail(ctx, req.Email, false)
+	if err != nil || created.Role != user.UserRoleUser {
+		t.Fatalf("created user = %+v, %v", created, err)
+	}
+
+	// The password is stored hashed, never as given
+	credential, err := credentials.GetByUserID(ctx, created.ID)
+	if err != nil || credential.PasswordHash == nil || *credential.PasswordHash == req.Password {
+		t.Fatalf("credential = %+v, %v", credential, err)
+	}
+	if !strings.HasPrefix(*credential.PasswordHash, "pbkdf2-sha256$") {
+		t.Errorf("password hash = %q", *credential.PasswordHash)
+	}
+
+	records := events.Records()
+	if len(records) != 1 || records[0].Event.AggregateID != strconv.Itoa(created.ID) {
+		t.Fatalf("outbox = %+v", records)
+	}
+	var payload user.UserRegisteredEvent
+	if err := records[0].Event.Decode(&payload); err != nil || payload.Email != req.Email || payload.IPAddress != "10.0.0.1" {
+		t.Errorf("payload = %+v, %v", payload, err)
+	}
+}
+
+func TestRequestResetKeepsTokenOutOfOutbox(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	users := memory.NewUserRepository(clk)
+	tokens := memory.NewTokenRepository(clk)
+	events := memory.NewOutboxStore(clk)
+
+	alice := &user.User{Email: "alice@example.com", Name: "Alice", Role: user.UserRoleUser}
+	if err := users.Create(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+
+	reset := usecase.NewPasswordResetUseCase(
+		users, tokens, memory.NewTxManager(), events,
+		ratelimit.NewMemoryLimiter(ratelimit.DefaultRules(), clk), clk,
+	)
+	if err := reset.RequestReset(ctx, alice.Email, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	issued := tokens.PasswordResets(alice.ID)
+	records := events.Records()
+	if len(issued) != 1 || len(records) != 1 {
+		t.Fatalf("tokens = %+v, outbox = %+v", issued, records)
+	}
+	if strings.Contains(string(records[0].Event.Payload), issued[0].Token) {
+		t.Errorf("payload %s contains the reset token", records[0].Event.Payload)
+	}
+	var payload user.PasswordResetRequestedEvent
+	if err := records[0].Event.Decode(&payload); err != nil || payload.TokenID != issued[0].ID {
+		t.Errorf("payload = %+v, %v", payload, err)
+	}
+}
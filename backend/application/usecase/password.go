@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// PASSWORD HASHING
+// ============================================================================
+// Passwords are stored as "pbkdf2-sha256$<iterations>$<salt>$<key>" with
+// the salt and key in unpadded base64. The iteration count travels with
+// the hash, so it can be raised without invalidating existing passwords.
+
+const (
+	passwordHashScheme = "pbkdf2-sha256"
+	passwordIterations = 600_000 // OWASP 2023 recommendation for PBKDF2-HMAC-SHA256
+	passwordSaltLength = 16
+	passwordKeyLength  = 32
+)
+
+// HashPassword derives the stored hash of a password with a random salt
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate password salt: %w", err)
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeyLength)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return strings.Join([]string{
+		passwordHashScheme,
+		strconv.Itoa(passwordIterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+// verifyPassword checks password against a hash made by HashPassword.
+// Missing and malformed hashes never match.
+func verifyPassword(hash *string, password string) bool {
+	if hash == nil {
+		return false
+	}
+
+	parts := strings.Split(*hash, "$")
+	if len(parts) != 4 || parts[0] != passwordHashScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(want) == 0 {
+		return false
+	}
+
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
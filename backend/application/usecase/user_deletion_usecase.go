@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+)
+
+// ============================================================================
+// USER DELETION USE CASE
+// ============================================================================
+// Deleting a user closes the account, revokes its sessions, push tokens and
+// API keys, logs the status change and records a user.deleted event, all in one
+// transaction. The row is kept so an admin can restore it; after the
+// retention period a purge erases the personal data and frees the email.
+
+// revokeReasonDeleted is stored on the sessions, push tokens and API keys
+// of a deleted user
+const revokeReasonDeleted = "User account deleted"
+
+// purgeBatchSize is how many users one purge round handles
+const purgeBatchSize = 100
+
+// UserCache drops cached data built from a user
+type UserCache interface {
+	InvalidateUser(userID int) error
+	InvalidateUserSessions(userID int) error
+}
+
+// noCache has nothing to invalidate
+type noCache struct{}
+
+func (noCache) InvalidateUser(userID int) error         { return nil }
+func (noCache) InvalidateUserSessions(userID int) error { return nil }
+
+// UserDeletionUseCase soft deletes, restores and purges users
+type UserDeletionUseCase struct {
+	userRepo      UserRepository
+	sessionRepo   SessionRepository
+	pushTokenRepo PushTokenRepository
+	apiKeyRepo    APIKeyRepository
+	txManager     TxManager
+	events        EventOutbox
+	cache         UserCache
+	clock         clock.Clock
+}
+
+// NewUserDeletionUseCase creates a new user deletion use case.
+// A nil txManager runs every write on its own, a nil events drops events
+// and a nil cache skips invalidation.
+func NewUserDeletionUseCase(
+	userRepo UserRepository,
+	sessionRepo SessionRepository,
+	pushTokenRepo PushTokenRepository,
+	apiKeyRepo APIKeyRepository,
+	txManager TxManager,
+	events EventOutbox,
+	cache UserCache,
+	clk clock.Clock,
+) *UserDeletionUseCase {
+	if cache == nil {
+		cache = noCache{}
+	}
+	return &UserDeletionUseCase{
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		pushTokenRepo: pushTokenRepo,
+		apiKeyRepo:    apiKeyRepo,
+		txManager:     orNoTx(txManager),
+		events:        orNoEvents(events),
+		cache:         cache,
+		clock:         clock.OrSystem(clk),
+	}
+}
+
+// Delete soft deletes the target user on behalf of actor (see
+// user.CanDeleteUser). It returns user.ErrUserNotFound for unknown users
+// and user.ErrUserDeleted for users already deleted.
+func (uc *UserDeletionUseCase) Delete(ctx context.Context, actor *user.User, targetID int, reason *string, ipAddress string) error {
+	err := uc.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		target, err := uc.userRepo.FindByID(ctx, targetID, true)
+		if err != nil {
+			return err
+		}
+		if err := user.CanDeleteUser(actor, target); err != nil {
+			return err
+		}
+
+		// A concurrent delete makes this report the user as not found
+		previous, version, err := uc.userRepo.SoftDelete(ctx, target.ID, actor.ID, reason)
+		if err != nil {
+			return err
+		}
+		err = uc.userRepo.LogStatusChange(ctx, &user.AccountStatusChange{
+			UserID:    target.ID,
+			OldStatus: previous,
+			NewStatus: user.AccountClosed,
+			Reason:    reason,
+			ChangedBy: actor.ID,
+			IPAddress: &ipAddress,
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err := uc.sessionRepo.RevokeAllForUser(ctx, target.ID, actor.ID, revokeReasonDeleted); err != nil {
+			return err
+		}
+		if _, err := uc.pushTokenRepo.DeactivateAllForUser(ctx, target.ID, revokeReasonDeleted); err != nil {
+			return err
+		}
+		if _, err := uc.apiKeyRepo.RevokeAllForUser(ctx, target.ID, actor.ID, revokeReasonDeleted); err != nil {
+			return err
+		}
+
+		deleted := &user.UserDeletedEvent{
+			UserID:    target.ID,
+			Email:     target.Email,
+			From:      previous,
+			DeletedBy: actor.ID,
+			IPAddress: ipAddress,
+		}
+		if reason != nil {
+			deleted.Reason = *reason
+		}
+		return recordUserEvent(ctx, uc.events, user.EventUserDeleted, target.ID,
+			user.EventKey(user.EventUserDeleted, int64(target.ID), int64(version)), deleted)
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.invalidate(targetID, true)
+	return nil
+}
+
+// Restore reactivates a soft-deleted user on behalf of actor (see
+// user.CanRestoreUser). Purged users cannot be restored.
+func (uc *UserDeletionUseCase) Restore(ctx context.Context, actor *user.User, targetID int, ipAddress string) error {
+	err := uc.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		target, err := uc.userRepo.FindByID(ctx, targetID, true)
+		if err != nil {
+			return err
+		}
+		if err := user.CanRestoreUser(actor, target); err != nil {
+			return err
+		}
+
+		version, err := uc.userRepo.Restore(ctx, target.ID, actor.ID)
+		if err != nil {
+			return err
+		}
+		err = uc.userRepo.LogStatusChange(ctx, &user.AccountStatusChange{
+			UserID:    target.ID,
+			OldStatus: target.AccountStatus,
+			NewStatus: user.AccountActive,
+			ChangedBy: actor.ID,
+			IPAddress: &ipAddress,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordUserEvent(ctx, uc.events, user.EventUserRestored, target.ID,
+			user.EventKey(user.EventUserRestored, int64(target.ID), int64(version)),
+			&user.UserRestoredEvent{UserID: target.ID, Email: target.Email, RestoredBy: actor.ID, IPAddress: ipAddress})
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.invalidate(targetID, false)
+	return nil
+}
+
+// PurgeDeleted erases the personal data of up to limit users deleted more
+// than retention ago and returns how many were purged. A user restored
+// meanwhile is skipped.
+func (uc *UserDeletionUseCase) PurgeDeleted(ctx context.Context, retention time.Duration, limit int) (int, error) {
+	ids, err := uc.userRepo.FindPurgeable(ctx, uc.clock.Now().Add(-retention), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, id := range ids {
+		err := uc.txManager.WithinTx(ctx, func(ctx context.Context) error {
+			return uc.userRepo.Purge(ctx, id)
+		})
+		if errors.Is(err, user.ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return purged, fmt.Errorf("user %d: %w", id, err)
+		}
+		purged++
+		uc.invalidate(id, false)
+	}
+	return purged, nil
+}
+
+// StartPurge purges users deleted more than retention ago every interval
+// until ctx is done
+func (uc *UserDeletionUseCase) StartPurge(ctx context.Context, retention, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				uc.purgeAll(ctx, retention)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// purgeAll runs purge rounds until no due user is left
+func (uc *UserDeletionUseCase) purgeAll(ctx context.Context, retention time.Duration) {
+	total := 0
+	for ctx.Err() == nil {
+		n, err := uc.PurgeDeleted(ctx, retention, purgeBatchSize)
+		total += n
+		if err != nil {
+			log.Printf("⚠️  User purge failed: %v", err)
+			break
+		}
+		if n < purgeBatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("🗂️  Purged the personal data of %d deleted user(s)", total)
+	}
+}
+
+// invalidate drops the cached data of a changed user. The change is
+// already committed, so failures are only logged.
+func (uc *UserDeletionUseCase) invalidate(userID int, sessions bool) {
+	if err := uc.cache.InvalidateUser(userID); err != nil {
+		log.Printf("⚠️  Failed to invalidate cache for user %d: %v", userID, err)
+	}
+	if !sessions {
+		return
+	}
+	if err := uc.cache.InvalidateUserSessions(userID); err != nil {
+		log.Printf("⚠️  Failed to invalidate sessions cache for user %d: %v", userID, err)
+	}
+}
@@ -0,0 +1,137 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/application/usecase"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/persistence/memory"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+)
+
+// cacheSpy records invalidated user IDs
+type cacheSpy struct{ users, sessions []int }
+
+func (c *cacheSpy) InvalidateUser(userID int) error {
+	c.users = append(c.users, userID)
+	return nil
+}
+
+func (c *cacheSpy) InvalidateUserSessions(userID int) error {
+	c.sessions = append(c.sessions, userID)
+	return nil
+}
+
+func TestDeleteRestoreAndPurgeUser(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	users := memory.NewUserRepository(clk)
+	sessions := memory.NewSessionRepository(clk)
+	pushTokens := memory.NewPushTokenRepository(clk)
+	apiKeys := memory.NewAPIKeyRepository(clk)
+	events := memory.NewOutboxStore(clk)
+	cache := &cacheSpy{}
+	deletion := usecase.NewUserDeletionUseCase(users, sessions, pushTokens, apiKeys, memory.NewTxManager(), events, cache, clk)
+
+	admin := &user.User{Email: "admin@example.com", Role: user.UserRoleAdmin, AccountStatus: user.AccountActive, EmailVerified: true}
+	leader := &user.User{Email: "leader@example.com", Role: user.UserRoleLeader, AccountStatus: user.AccountActive, EmailVerified: true}
+	target := &user.User{Email: "an@example.com", Name: "An", Role: user.UserRoleUser, AccountStatus: user.AccountSuspended}
+	for _, u := range []*user.User{admin, leader, target} {
+		if err := users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = sessions.Create(ctx, &user.UserSession{UserID: target.ID, ExpiresAt: clk.Now().Add(time.Hour)})
+	pushTokens.Put(&user.UserPushToken{UserID: target.ID, Token: "t", DeviceID: "d", Platform: "ios"})
+	apiKeys.Put(&user.APIKey{UserID: target.ID, KeyHash: "h", KeyPrefix: "sp_", Name: "ci"})
+	apiKeys.Put(&user.APIKey{UserID: leader.ID, KeyHash: "h2", KeyPrefix: "sp_", Name: "ci"})
+
+	if err := deletion.Delete(ctx, leader, admin.ID, nil, "10.0.0.1"); !errors.Is(err, user.ErrCannotModifyAdmin) {
+		t.Errorf("leader deleting admin = %v, want ErrCannotModifyAdmin", err)
+	}
+	if err := deletion.Delete(ctx, admin, admin.ID, nil, "10.0.0.1"); !errors.Is(err, user.ErrCannotModifySelf) {
+		t.Errorf("deleting self = %v, want ErrCannotModifySelf", err)
+	}
+
+	reason := "spam"
+	if err := deletion.Delete(ctx, leader, target.ID, &reason, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := deletion.Delete(ctx, leader, target.ID, &reason, "10.0.0.1"); !errors.Is(err, user.ErrUserDeleted) {
+		t.Errorf("second delete = %v, want ErrUserDeleted", err)
+	}
+
+	// Deleted users are hidden unless asked for
+	if _, err := users.FindByEmail(ctx, target.Email, false); !errors.Is(err, user.ErrUserNotFound) {
+		t.Errorf("FindByEmail of deleted user = %v, want ErrUserNotFound", err)
+	}
+	deleted, err := users.FindByID(ctx, target.ID, true)
+	if err != nil || deleted.AccountStatus != user.AccountClosed || *deleted.DeletedBy != leader.ID {
+		t.Fatalf("deleted user = %+v, %v", deleted, err)
+	}
+	if s := sessions.Sessions(target.ID); len(s) != 1 || !s[0].IsRevoked() {
+		t.Errorf("sessions = %+v", s)
+	}
+	if tokens := pushTokens.Tokens(target.ID); len(tokens) != 1 || tokens[0].IsActive {
+		t.Errorf("push tokens = %+v", tokens)
+	}
+	if keys := apiKeys.Keys(target.ID); len(keys) != 1 || !keys[0].IsRevoked() || *keys[0].RevokedBy != leader.ID {
+		t.Errorf("API keys = %+v", keys)
+	}
+	if keys := apiKeys.Keys(leader.ID); len(keys) != 1 || keys[0].IsRevoked() {
+		t.Errorf("API keys of the leader = %+v", keys)
+	}
+	changes := users.StatusChanges()
+	if len(changes) != 1 || changes[0].OldStatus != user.AccountSuspended || changes[0].NewStatus != user.AccountClosed {
+		t.Errorf("status changes = %+v", changes)
+	}
+	if records := events.Records(); len(records) != 1 || records[0].Event.Type != user.EventUserDeleted {
+		t.Errorf("outbox = %+v", records)
+	}
+	if len(cache.users) != 1 || len(cache.sessions) != 1 {
+		t.Errorf("invalidated = %+v", cache)
+	}
+
+	// Only admins restore
+	if err := deletion.Restore(ctx, leader, target.ID, "10.0.0.1"); !errors.Is(err, user.ErrPermissionDenied) {
+		t.Errorf("leader restore = %v, want ErrPermissionDenied", err)
+	}
+	if err := deletion.Restore(ctx, admin, target.ID, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	restored, _ := users.FindByID(ctx, target.ID, false)
+	if restored == nil || restored.AccountStatus != user.AccountActive {
+		t.Fatalf("restored user = %+v", restored)
+	}
+	if err := deletion.Restore(ctx, admin, target.ID, "10.0.0.1"); !errors.Is(err, user.ErrUserNotDeleted) {
+		t.Errorf("restore of live user = %v, want ErrUserNotDeleted", err)
+	}
+
+	// Purge only touches users deleted longer than the retention
+	if err := deletion.Delete(ctx, admin, target.ID, nil, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	retention := 30 * 24 * time.Hour
+	if n, err := deletion.PurgeDeleted(ctx, retention, 10); n != 0 || err != nil {
+		t.Errorf("early purge = %d, %v", n, err)
+	}
+	clk.Advance(retention + time.Second)
+	if n, err := deletion.PurgeDeleted(ctx, retention, 10); n != 1 || err != nil {
+		t.Fatalf("purge = %d, %v", n, err)
+	}
+
+	purged, _ := users.FindByID(ctx, target.ID, true)
+	if !purged.IsPurged() || purged.Email == target.Email || purged.Name != "Deleted user" {
+		t.Errorf("purged user = %+v", purged)
+	}
+	if _, err := users.FindByEmail(ctx, target.Email, true); !errors.Is(err, user.ErrUserNotFound) {
+		t.Errorf("purged email still taken: %v", err)
+	}
+	if err := deletion.Restore(ctx, admin, target.ID, "10.0.0.1"); !errors.Is(err, user.ErrUserPurged) {
+		t.Errorf("restore of purged user = %v, want ErrUserPurged", err)
+	}
+}
@@ -3,12 +3,21 @@ package main
 import (
 	"context"
 	"log"
+	"os"
+	"time"
 
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/application/subscriber"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/application/usecase"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/outbox"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/persistence/postgres"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/shutdown"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/config"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/db"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/handlers"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/middleware"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/redis"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/router"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"    // ✅
@@ -28,6 +37,14 @@ func main() {
 	log.Println("🚀 Starting application...")
 	config.MustLoad()
 
+	// `server migrate ...` manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatalf("❌ Migration failed: %v", err)
+		}
+		return
+	}
+
 	// =========================================================================
 	// INITIALIZE DATABASE
 	// =========================================================================
@@ -36,6 +53,38 @@ func main() {
 	}
 	defer db.CloseDB()
 
+	// Keep monthly partitions ahead of inserts and archive old ones,
+	// and take failing read replicas out of rotation
+	dbCtx, stopDBMaintenance := context.WithCancel(context.Background())
+	defer stopDBMaintenance()
+	db.StartPartitionMaintenance(dbCtx)
+	db.StartReplicaHealthChecks(dbCtx)
+
+	// Domain events committed to the outbox are delivered to the email
+	// queue and the security log
+	dbCfg := config.Cfg.Database
+	events := outbox.NewDispatcher(postgres.NewOutboxRepository(db.DB), postgres.NewTxManager(db.DB))
+	events.BatchSize = dbCfg.OutboxBatchSize
+	events.MaxAttempts = dbCfg.OutboxMaxAttempts
+	events.Retention = dbCfg.OutboxRetention
+	subscriber.Register(events,
+		postgres.NewEmailQueueRepository(db.DB),
+		postgres.NewSecurityEventRepository(db.DB),
+		postgres.NewTokenRepository(db.DB),
+	)
+	eventsCtx, stopEvents := context.WithCancel(context.Background())
+	defer stopEvents()
+	events.Start(eventsCtx, dbCfg.OutboxPollInterval)
+
+	// Erase the personal data of users deleted longer than the retention
+	if retention := config.Cfg.Security.DeletedUserRetention; retention > 0 {
+		deletion := usecase.NewUserDeletionUseCase(
+			postgres.NewUserRepository(db.DB), postgres.NewSessionRepository(db.DB), postgres.NewPushTokenRepository(db.DB),
+			postgres.NewAPIKeyRepository(db.DB), postgres.NewTxManager(db.DB), postgres.NewOutboxRepository(db.DB), redis.UserCache{}, nil,
+		)
+		deletion.StartPurge(dbCtx, retention, config.Cfg.Security.UserPurgeInterval)
+	}
+
 	// =========================================================================
 	// INITIALIZE REDIS & RATE LIMITER
 	// =========================================================================
@@ -49,6 +98,39 @@ func main() {
 	}
 	log.Println("✅ Redis rate limiter initialized successfully!")
 
+	// Denylisted repeat offenders are recorded as security events
+	limiter.SetSecurityEventSink(ratelimit.NewPostgresSecurityEventSink(db.DB))
+
+	// Would-be blocks of shadow rules are logged to rate_limit_log
+	limiter.SetShadowSink(ratelimit.NewPostgresRateLimitLog(db.DB))
+
+	// Blocks and admin interventions are audited to rate_limit_audit
+	audit := ratelimit.NewAuditLog(ratelimit.NewPostgresAuditStore(db.DB), ratelimit.DefaultAuditBuffer)
+	limiter.SetAuditSink(audit)
+
+	// In-flight caps (concurrent uploads, data exports) share the Redis connection
+	concurrency := ratelimit.InitializeConcurrencyLimiter(limiter)
+
+	// Load per-user / per-key quota overrides and keep them in sync
+	overrides := ratelimit.NewPostgresOverrideStore(db.DB)
+	syncCtx, stopSync := context.WithCancel(ctx)
+	defer stopSync()
+	ratelimit.StartOverrideSync(syncCtx, overrides, limiter, 1*time.Minute)
+
+	// Admin-managed CIDR allow/deny lists, cached in memory
+	ipList := ratelimit.NewIPList(ratelimit.NewPostgresIPListStore(db.DB), clock.System)
+	ratelimit.StartIPListSync(syncCtx, ipList, 1*time.Minute)
+
+	// Real client IP behind the load balancer (IPv6 bucketed by prefix)
+	ipResolver, err := ratelimit.NewClientIPResolver(
+		config.Cfg.RateLimit.TrustedProxies,
+		config.Cfg.RateLimit.ProxyHeader,
+		config.Cfg.RateLimit.IPv6PrefixLength,
+	)
+	if err != nil {
+		log.Fatalf("❌ Invalid proxy configuration: %v", err)
+	}
+
 	// =========================================================================
 	// SETUP FIBER APP
 	// =========================================================================
@@ -63,15 +145,30 @@ func main() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: config.Cfg.App.FrontendURL,
 		AllowMethods: "GET,POST,PUT,DELETE,PATCH,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
+		AllowHeaders: "Origin,Content-Type,Accept,Authorization," + middleware.APIKeyHeader,
 	}))
 
+	// Client IP must be resolved before IP lists and rate limits
+	app.Use(middleware.ResolveClientIP(ipResolver))
+	app.Use(middleware.IPAccessControl(ipList))
+
+	// Reads after a write in the same request go to the primary
+	app.Use(middleware.ReadYourWrites())
+
 	// Setup routes with rate limiting
-	router.Setup(app, limiter)
+	router.Setup(app, limiter, concurrency, overrides, ipList, audit,
+		postgres.NewAPIKeyRepository(db.DB),
+		postgres.NewSessionRepository(db.DB),
+	)
 
 	go shutdown.Graceful(shutdown.Resources{
 		App: app,
 		CloseDB: func() error {
+			// Flush queued audit events and finish the delivery in flight
+			// while the database is still open
+			audit.Close()
+			stopEvents()
+			events.Wait()
 			return db.CloseDB()
 		},
 		CloseLimiter: func() error {
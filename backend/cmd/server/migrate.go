@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/db"
+)
+
+const migrateUsage = `Usage: server migrate [--dry-run] <command>
+
+Commands:
+  up        Apply all pending migrations
+  down [N]  Revert the N most recent migrations (default 1)
+  status    List migrations and whether they are applied
+  redo      Revert and reapply the most recent migration
+`
+
+// runMigrate implements the migrate subcommand
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "log what would run without changing the database")
+	flags.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("missing migrate command")
+	}
+
+	if err := db.Connect(); err != nil {
+		return err
+	}
+	defer db.CloseDB()
+
+	migrator, err := db.NewMigrator(db.DB, db.Migrations())
+	if err != nil {
+		return err
+	}
+	migrator.DryRun = *dryRun
+
+	ctx := context.Background()
+	switch command := flags.Arg(0); command {
+	case "up":
+		count, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ %d migration(s) applied", count)
+
+	case "down":
+		n := 1
+		if flags.NArg() > 1 {
+			n, err = strconv.Atoi(flags.Arg(1))
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid migration count %q", flags.Arg(1))
+			}
+		}
+		count, err := migrator.Down(ctx, n)
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ %d migration(s) reverted", count)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(statuses)
+
+	case "redo":
+		return migrator.Redo(ctx)
+
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+
+	return nil
+}
+
+// printMigrationStatus writes the status table to stdout
+func printMigrationStatus(statuses []db.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "-"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, status.State, appliedAt)
+	}
+	w.Flush()
+}
@@ -0,0 +1,220 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// ============================================================================
+// DISPATCHER
+// ============================================================================
+// The dispatcher claims due events and hands each to every matching
+// subscriber that has not handled it yet. A subscriber's handler and the
+// record of its delivery run in one transaction: database side effects
+// happen exactly once, anything else (push, SMTP) at least once and must
+// drop duplicates by Event.IdempotencyKey. Events with failed subscribers
+// are retried with exponential backoff, then parked after MaxAttempts.
+
+// Dispatcher defaults
+const (
+	DefaultBatchSize   = 100
+	DefaultLease       = time.Minute
+	DefaultMaxAttempts = 10
+	DefaultBaseBackoff = 5 * time.Second
+	DefaultMaxBackoff  = time.Hour
+
+	// pruneInterval is how often published events past Retention are deleted
+	pruneInterval = time.Hour
+)
+
+// Handler handles one event
+type Handler func(ctx context.Context, event *Event) error
+
+// subscription is a named handler of one event type ("*" = all)
+type subscription struct {
+	name      string
+	eventType string
+	handle    Handler
+}
+
+// Dispatcher delivers stored events to in-process subscribers
+type Dispatcher struct {
+	store Store
+	tx    TxManager
+	subs  []subscription
+
+	BatchSize   int           // Events claimed per round
+	Lease       time.Duration // How long a claimed event is reserved
+	MaxAttempts int           // Attempts before an event is parked
+	BaseBackoff time.Duration // Delay after the first failed attempt, doubled after each
+	MaxBackoff  time.Duration
+	Retention   time.Duration // Published events are deleted after this (0 = keep)
+
+	done chan struct{}
+}
+
+// NewDispatcher creates a dispatcher with the default settings.
+// A nil txManager records deliveries after their handler, outside a
+// transaction.
+func NewDispatcher(store Store, txManager TxManager) *Dispatcher {
+	if txManager == nil {
+		txManager = noTx{}
+	}
+	return &Dispatcher{
+		store:       store,
+		tx:          txManager,
+		BatchSize:   DefaultBatchSize,
+		Lease:       DefaultLease,
+		MaxAttempts: DefaultMaxAttempts,
+		BaseBackoff: DefaultBaseBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+	}
+}
+
+// Subscribe registers handle for events of eventType ("*" for all). The
+// name is stored with each delivery, so it must be unique and stable
+// across deploys. Subscribe before Start.
+func (d *Dispatcher) Subscribe(name, eventType string, handle Handler) {
+	for _, s := range d.subs {
+		if s.name == name {
+			panic("outbox: duplicate subscriber " + name)
+		}
+	}
+	d.subs = append(d.subs, subscription{name: name, eventType: eventType, handle: handle})
+}
+
+// Start dispatches due events every interval until ctx is done
+func (d *Dispatcher) Start(ctx context.Context, interval time.Duration) {
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var lastPrune time.Time
+
+		for {
+			select {
+			case <-ticker.C:
+				d.drain(ctx)
+				if d.Retention > 0 && time.Since(lastPrune) >= pruneInterval {
+					lastPrune = time.Now()
+					d.prune(ctx)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Wait blocks until the loop started by Start has stopped
+func (d *Dispatcher) Wait() {
+	if d.done != nil {
+		<-d.done
+	}
+}
+
+// drain dispatches rounds until the backlog is empty
+func (d *Dispatcher) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := d.DispatchOnce(ctx)
+		if err != nil {
+			log.Printf("⚠️  Outbox dispatch failed: %v", err)
+			return
+		}
+		if n < d.BatchSize {
+			return
+		}
+	}
+}
+
+// prune deletes published events past the retention
+func (d *Dispatcher) prune(ctx context.Context) {
+	n, err := d.store.Prune(ctx, d.Retention)
+	if err != nil {
+		log.Printf("⚠️  Outbox prune failed: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("📦 Pruned %d published outbox event(s)", n)
+	}
+}
+
+// DispatchOnce claims one batch of due events and delivers them.
+// It returns the number of events claimed.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	events, err := d.store.Claim(ctx, d.BatchSize, d.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim events: %w", err)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	for _, event := range events {
+		if err := d.deliver(ctx, event); err != nil {
+			return len(events), fmt.Errorf("event %d: %w", event.ID, err)
+		}
+	}
+	return len(events), nil
+}
+
+// deliver runs the pending subscribers of event and records the outcome
+func (d *Dispatcher) deliver(ctx context.Context, event *Event) error {
+	delivered := make(map[string]bool, len(event.Delivered))
+	for _, name := range event.Delivered {
+		delivered[name] = true
+	}
+
+	var errs []error
+	for _, s := range d.subs {
+		if delivered[s.name] || (s.eventType != "*" && s.eventType != event.Type) {
+			continue
+		}
+
+		err := d.tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := handle(ctx, s.handle, event); err != nil {
+				return err
+			}
+			return d.store.MarkDelivered(ctx, event.ID, s.name)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+
+	if len(errs) == 0 {
+		return d.store.Publish(ctx, event.ID)
+	}
+
+	reason := errors.Join(errs...).Error()
+	if event.Attempts >= d.MaxAttempts {
+		log.Printf("⚠️  Outbox event %d (%s) parked after %d attempts: %s", event.ID, event.Type, event.Attempts, reason)
+		return d.store.Fail(ctx, event.ID, reason)
+	}
+	return d.store.Retry(ctx, event.ID, d.backoff(event.Attempts), reason)
+}
+
+// backoff returns the delay after the given failed attempt
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.BaseBackoff
+	for i := 1; i < attempt && delay < d.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.MaxBackoff)
+}
+
+// handle runs a handler, turning a panic into an error so one bad
+// subscriber cannot stop the dispatcher
+func handle(ctx context.Context, h Handler, event *Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, event)
+}
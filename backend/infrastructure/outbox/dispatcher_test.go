@@ -0,0 +1,106 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/outbox"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/persistence/memory"
+)
+
+func addEvent(t *testing.T, store outbox.Store, key string) {
+	t.Helper()
+	event, err := outbox.NewEvent("user.registered", "user", "1", key, map[string]int{"user_id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDispatcherRetriesOnlyFailedSubscribers(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := memory.NewOutboxStore(clk)
+
+	addEvent(t, store, "user.registered:1")
+	addEvent(t, store, "user.registered:1") // Duplicate is skipped
+	if n := len(store.Records()); n != 1 {
+		t.Fatalf("records = %d, want 1", n)
+	}
+
+	d := outbox.NewDispatcher(store, memory.NewTxManager())
+	calls := map[string]int{}
+	d.Subscribe("email", "user.registered", func(ctx context.Context, e *outbox.Event) error {
+		calls["email"]++
+		return nil
+	})
+	d.Subscribe("audit", "*", func(ctx context.Context, e *outbox.Event) error {
+		calls["audit"]++
+		if calls["audit"] == 1 {
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+	d.Subscribe("other", "user.logged_in", func(ctx context.Context, e *outbox.Event) error {
+		calls["other"]++
+		return nil
+	})
+
+	if n, err := d.DispatchOnce(ctx); n != 1 || err != nil {
+		t.Fatalf("first round = %d, %v", n, err)
+	}
+	record := store.Records()[0]
+	if record.PublishedAt != nil || record.LastError == "" || !record.AvailableAt.Equal(clk.Now().Add(outbox.DefaultBaseBackoff)) {
+		t.Fatalf("after failure = %+v", record)
+	}
+
+	// Not due before the backoff has passed
+	if n, _ := d.DispatchOnce(ctx); n != 0 {
+		t.Fatalf("claimed %d events during backoff", n)
+	}
+
+	clk.Advance(outbox.DefaultBaseBackoff)
+	if n, err := d.DispatchOnce(ctx); n != 1 || err != nil {
+		t.Fatalf("second round = %d, %v", n, err)
+	}
+	record = store.Records()[0]
+	if record.PublishedAt == nil || record.Event.Attempts != 2 {
+		t.Errorf("after retry = %+v", record)
+	}
+	if calls["email"] != 1 || calls["audit"] != 2 || calls["other"] != 0 {
+		t.Errorf("calls = %v", calls)
+	}
+}
+
+func TestDispatcherParksAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := memory.NewOutboxStore(clk)
+	addEvent(t, store, "user.registered:1")
+
+	d := outbox.NewDispatcher(store, nil)
+	d.MaxAttempts = 3
+	d.Subscribe("broken", "*", func(ctx context.Context, e *outbox.Event) error {
+		panic("nil map")
+	})
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if n, err := d.DispatchOnce(ctx); n != 1 || err != nil {
+			t.Fatalf("attempt %d = %d, %v", attempt, n, err)
+		}
+		clk.Advance(outbox.DefaultMaxBackoff)
+	}
+
+	record := store.Records()[0]
+	if record.FailedAt == nil || record.LastError != "broken: panic: nil map" {
+		t.Errorf("parked record = %+v", record)
+	}
+	if n, _ := d.DispatchOnce(ctx); n != 0 {
+		t.Errorf("claimed %d parked events", n)
+	}
+}
@@ -0,0 +1,100 @@
+// Package outbox implements the transactional outbox. Domain events are
+// stored in the transaction of the state change that produced them, so a
+// crash can lose neither; a Dispatcher then delivers them to in-process
+// subscribers, at least once per subscriber, retrying failures with backoff.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ============================================================================
+// EVENTS
+// ============================================================================
+
+// Event is a stored domain event
+type Event struct {
+	ID             int64
+	Type           string // e.g. "user.registered"
+	AggregateType  string // e.g. "user"
+	AggregateID    string
+	IdempotencyKey string // Identifies the occurrence; subscribers use it to drop redeliveries
+	Payload        json.RawMessage
+	Attempts       int // Delivery attempts so far, including the current one
+	CreatedAt      time.Time
+
+	// Delivered lists the subscribers that already handled the event
+	Delivered []string
+}
+
+// NewEvent creates an event with a JSON payload. The idempotency key names
+// the occurrence (e.g. "user.registered:42"): adding an event whose key is
+// already stored is a no-op, so a retried transaction cannot emit twice.
+func NewEvent(eventType, aggregateType, aggregateID, idempotencyKey string, payload any) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+
+	return &Event{
+		Type:           eventType,
+		AggregateType:  aggregateType,
+		AggregateID:    aggregateID,
+		IdempotencyKey: idempotencyKey,
+		Payload:        data,
+	}, nil
+}
+
+// Decode unmarshals the payload into v
+func (e *Event) Decode(v any) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", e.Type, err)
+	}
+	return nil
+}
+
+// ============================================================================
+// STORAGE
+// ============================================================================
+
+// Store persists events and their delivery state. Stores keep their own
+// time, so delays are relative.
+type Store interface {
+	// Add stores events, skipping those whose idempotency key is already
+	// stored. With a transaction in ctx they are written in it.
+	Add(ctx context.Context, events ...*Event) error
+
+	// Claim leases up to limit due events for lease and counts an attempt
+	// on each. Leased events are not claimed again until the lease ends.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*Event, error)
+
+	// MarkDelivered records that subscriber handled the event
+	MarkDelivered(ctx context.Context, eventID int64, subscriber string) error
+
+	// Publish marks the event handled by every subscriber
+	Publish(ctx context.Context, eventID int64) error
+
+	// Retry releases the event until delay has passed, recording why it failed
+	Retry(ctx context.Context, eventID int64, delay time.Duration, reason string) error
+
+	// Fail parks the event for good after its last attempt
+	Fail(ctx context.Context, eventID int64, reason string) error
+
+	// Prune deletes events published more than olderThan ago
+	Prune(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+// TxManager runs fn in one database transaction (see postgres.TxManager)
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// noTx runs fn directly, without a transaction
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
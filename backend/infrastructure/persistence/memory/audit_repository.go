@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+)
+
+// ============================================================================
+// ACTIVITY REPOSITORY
+// ============================================================================
+
+// ActivityRepository is an in-memory usecase.ActivityRepository
+type ActivityRepository struct {
+	mu     sync.RWMutex
+	logins []*user.LoginActivity
+	clock  clock.Clock
+}
+
+// NewActivityRepository creates an empty in-memory activity repository
+func NewActivityRepository(clk clock.Clock) *ActivityRepository {
+	return &ActivityRepository{clock: clock.OrSystem(clk)}
+}
+
+// CreateLoginActivity logs a login attempt. User ID 0 is stored as nil,
+// matching the NULL the Postgres repository writes.
+func (r *ActivityRepository) CreateLoginActivity(ctx context.Context, a *user.LoginActivity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a.ID = len(r.logins) + 1
+	a.CreatedAt = r.clock.Now()
+
+	stored := *a
+	if stored.UserID != nil && *stored.UserID == 0 {
+		stored.UserID = nil
+	}
+	r.logins = append(r.logins, &stored)
+	return nil
+}
+
+// LoginActivities returns copies of the logged login attempts, oldest first
+func (r *ActivityRepository) LoginActivities() []user.LoginActivity {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	activities := make([]user.LoginActivity, 0, len(r.logins))
+	for _, a := range r.logins {
+		activities = append(activities, *a)
+	}
+	return activities
+}
@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+)
+
+// ============================================================================
+// CREDENTIAL REPOSITORY
+// ============================================================================
+
+// CredentialRepository is an in-memory usecase.CredentialRepository
+type CredentialRepository struct {
+	mu          sync.RWMutex
+	credentials map[int]*user.UserCredential // by user ID
+	nextID      int
+	clock       clock.Clock
+}
+
+// NewCredentialRepository creates an empty in-memory credential repository
+func NewCredentialRepository(clk clock.Clock) *CredentialRepository {
+	return &CredentialRepository{
+		credentials: make(map[int]*user.UserCredential),
+		clock:       clock.OrSystem(clk),
+	}
+}
+
+// GetByUserID gets the active credentials of a user
+func (r *CredentialRepository) GetByUserID(ctx context.Context, userID int) (*user.UserCredential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.credentials[userID]
+	if !ok || c.IsDeleted() {
+		return nil, user.ErrCredentialNotFound
+	}
+	found := *c
+	return &found, nil
+}
+
+// SetPassword stores the password hash of a user. Credentials are created
+// when missing, like the Postgres trigger does with the user.
+func (r *CredentialRepository) SetPassword(ctx context.Context, userID int, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	c, ok := r.credentials[userID]
+	if !ok {
+		r.nextID++
+		c = &user.UserCredential{ID: r.nextID, UserID: userID}
+		c.CreatedAt = now
+		r.credentials[userID] = c
+	}
+	hash := passwordHash
+	c.PasswordHash, c.UpdatedAt = &hash, now
+	return nil
+}
+
+// Put stores the credentials of a user, replacing existing ones.
+// In Postgres the row is created by a trigger, so tests seed it here.
+func (r *CredentialRepository) Put(c *user.UserCredential) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	if existing, ok := r.credentials[c.UserID]; ok {
+		c.ID, c.CreatedAt = existing.ID, existing.CreatedAt
+	} else {
+		r.nextID++
+		c.ID, c.CreatedAt = r.nextID, now
+	}
+	c.UpdatedAt = now
+
+	stored := *c
+	r.credentials[c.UserID] = &stored
+}
+
+// ============================================================================
+// TOKEN REPOSITORY
+// ============================================================================
+
+// TokenRepository is an in-memory usecase.TokenRepository
+type TokenRepository struct {
+	mu     sync.RWMutex
+	resets []*user.PasswordResetToken
+	clock  clock.Clock
+}
+
+// NewTokenRepository creates an empty in-memory token repository
+func NewTokenRepository(clk clock.Clock) *TokenRepository {
+	return &TokenRepository{clock: clock.OrSystem(clk)}
+}
+
+// CreatePasswordReset stores a password reset token and fills in its ID
+func (r *TokenRepository) CreatePasswordReset(ctx context.Context, t *user.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t.ID = len(r.resets) + 1
+	t.CreatedAt = r.clock.Now()
+
+	stored := *t
+	r.resets = append(r.resets, &stored)
+	return nil
+}
+
+// PasswordResets returns copies of the stored reset tokens of a user
+func (r *TokenRepository) PasswordResets(userID int) []user.PasswordResetToken {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tokens []user.PasswordResetToken
+	for _, t := range r.resets {
+		if t.UserID == userID {
+			tokens = append(tokens, *t)
+		}
+	}
+	return tokens
+}
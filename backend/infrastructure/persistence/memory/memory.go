@@ -0,0 +1,25 @@
+// Package memory provides in-memory fakes of the application repositories
+// for tests. Entities are copied on the way in and out, so callers cannot
+// change stored state without going through the repository, like with the
+// Postgres implementations.
+package memory
+
+import (
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/application/usecase"
+)
+
+// ============================================================================
+// INTERFACE CHECKS
+// ============================================================================
+
+var (
+	_ usecase.UserRepository       = (*UserRepository)(nil)
+	_ usecase.CredentialRepository = (*CredentialRepository)(nil)
+	_ usecase.SecurityRepository   = (*SecurityRepository)(nil)
+	_ usecase.SessionRepository    = (*SessionRepository)(nil)
+	_ usecase.PushTokenRepository  = (*PushTokenRepository)(nil)
+	_ usecase.APIKeyRepository     = (*APIKeyRepository)(nil)
+	_ usecase.TokenRepository      = (*TokenRepository)(nil)
+	_ usecase.ActivityRepository   = (*ActivityRepository)(nil)
+	_ usecase.EventOutbox          = (*OutboxStore)(nil)
+)
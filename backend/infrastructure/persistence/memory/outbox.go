@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/outbox"
+)
+
+var _ outbox.Store = (*OutboxStore)(nil)
+
+// ============================================================================
+// OUTBOX
+// ============================================================================
+
+// OutboxRecord is a stored event with its delivery state
+type OutboxRecord struct {
+	Event       outbox.Event
+	AvailableAt time.Time
+	LockedUntil time.Time
+	PublishedAt *time.Time
+	FailedAt    *time.Time
+	LastError   string
+}
+
+// OutboxStore is an in-memory outbox.Store
+type OutboxStore struct {
+	mu      sync.Mutex
+	records []*OutboxRecord
+	nextID  int64
+	clock   clock.Clock
+}
+
+// NewOutboxStore creates an empty in-memory outbox
+func NewOutboxStore(clk clock.Clock) *OutboxStore {
+	return &OutboxStore{clock: clock.OrSystem(clk)}
+}
+
+// Add stores events, skipping already stored idempotency keys
+func (s *OutboxStore) Add(ctx context.Context, events ...*outbox.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	for _, e := range events {
+		if s.findKey(e.IdempotencyKey) != nil {
+			continue
+		}
+		s.nextID++
+		e.ID = s.nextID
+		e.CreatedAt = now
+
+		stored := *e
+		stored.Delivered = nil
+		s.records = append(s.records, &OutboxRecord{Event: stored, AvailableAt: now})
+	}
+	return nil
+}
+
+// Claim leases up to limit due events, oldest first
+func (s *OutboxStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]*outbox.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	var claimed []*outbox.Event
+	for _, r := range s.records {
+		if len(claimed) == limit {
+			break
+		}
+		if r.PublishedAt != nil || r.FailedAt != nil || r.AvailableAt.After(now) || r.LockedUntil.After(now) {
+			continue
+		}
+		r.Event.Attempts++
+		r.LockedUntil = now.Add(lease)
+
+		event := r.Event
+		event.Delivered = slices.Clone(r.Event.Delivered)
+		claimed = append(claimed, &event)
+	}
+	return claimed, nil
+}
+
+// MarkDelivered records that subscriber handled the event
+func (s *OutboxStore) MarkDelivered(ctx context.Context, eventID int64, subscriber string) error {
+	return s.update(eventID, func(r *OutboxRecord) {
+		if !slices.Contains(r.Event.Delivered, subscriber) {
+			r.Event.Delivered = append(r.Event.Delivered, subscriber)
+		}
+	})
+}
+
+// Publish marks the event handled by every subscriber
+func (s *OutboxStore) Publish(ctx context.Context, eventID int64) error {
+	now := s.clock.Now()
+	return s.update(eventID, func(r *OutboxRecord) {
+		r.PublishedAt, r.LockedUntil, r.LastError = &now, time.Time{}, ""
+	})
+}
+
+// Retry releases the event until delay has passed
+func (s *OutboxStore) Retry(ctx context.Context, eventID int64, delay time.Duration, reason string) error {
+	now := s.clock.Now()
+	return s.update(eventID, func(r *OutboxRecord) {
+		r.AvailableAt, r.LockedUntil, r.LastError = now.Add(delay), time.Time{}, reason
+	})
+}
+
+// Fail parks the event
+func (s *OutboxStore) Fail(ctx context.Context, eventID int64, reason string) error {
+	now := s.clock.Now()
+	return s.update(eventID, func(r *OutboxRecord) {
+		r.FailedAt, r.LockedUntil, r.LastError = &now, time.Time{}, reason
+	})
+}
+
+// Prune deletes events published more than olderThan ago
+func (s *OutboxStore) Prune(ctx context.Context, olderThan time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.clock.Now().Add(-olderThan)
+	before := len(s.records)
+	s.records = slices.DeleteFunc(s.records, func(r *OutboxRecord) bool {
+		return r.PublishedAt != nil && r.PublishedAt.Before(cutoff)
+	})
+	return int64(before - len(s.records)), nil
+}
+
+// Records returns copies of the stored events, oldest first
+func (s *OutboxStore) Records() []OutboxRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]OutboxRecord, 0, len(s.records))
+	for _, r := range s.records {
+		record := *r
+		record.Event.Delivered = slices.Clone(r.Event.Delivered)
+		records = append(records, record)
+	}
+	return records
+}
+
+// update applies fn to the record of eventID, if it still exists
+func (s *OutboxStore) update(eventID int64, fn func(r *OutboxRecord)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.records {
+		if r.Event.ID == eventID {
+			fn(r)
+			return nil
+		}
+	}
+	return nil
+}
+
+// findKey returns the record with the idempotency key, or nil
+func (s *OutboxStore) findKey(key string) *OutboxRecord {
+	for _, r := range s.records {
+		if r.Event.IdempotencyKey == key {
+			return r
+		}
+	}
+	return nil
+}
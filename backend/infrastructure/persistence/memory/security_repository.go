@@ -0,0 +1,284 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+)
+
+// ============================================================================
+// SECURITY REPOSITORY
+// ============================================================================
+
+// SecurityRepository is an in-memory usecase.SecurityRepository
+type SecurityRepository struct {
+	mu     sync.RWMutex
+	infos  map[int]*user.UserSecurityInfo // by user ID
+	nextID int
+	clock  clock.Clock
+}
+
+// NewSecurityRepository creates an empty in-memory security info repository
+func NewSecurityRepository(clk clock.Clock) *SecurityRepository {
+	return &SecurityRepository{
+		infos: make(map[int]*user.UserSecurityInfo),
+		clock: clock.OrSystem(clk),
+	}
+}
+
+// GetByUserID gets the security info of a user
+func (r *SecurityRepository) GetByUserID(ctx context.Context, userID int) (*user.UserSecurityInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.infos[userID]
+	if !ok {
+		return nil, user.ErrSecurityInfoNotFound
+	}
+	found := *s
+	return &found, nil
+}
+
+// Create stores the security info of a user, overwriting an existing row
+// like the Postgres upsert does
+func (r *SecurityRepository) Create(ctx context.Context, s *user.UserSecurityInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	if existing, ok := r.infos[s.UserID]; ok {
+		s.ID, s.CreatedAt = existing.ID, existing.CreatedAt
+	} else {
+		r.nextID++
+		s.ID, s.CreatedAt = r.nextID, now
+	}
+	s.UpdatedAt = now
+
+	stored := *s
+	r.infos[s.UserID] = &stored
+	return nil
+}
+
+// Update saves the login counters and timestamps of a user
+func (r *SecurityRepository) Update(ctx context.Context, s *user.UserSecurityInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.infos[s.UserID]
+	if !ok {
+		return user.ErrSecurityInfoNotFound
+	}
+	s.ID, s.CreatedAt = existing.ID, existing.CreatedAt
+	s.UpdatedAt = r.clock.Now()
+
+	stored := *s
+	r.infos[s.UserID] = &stored
+	return nil
+}
+
+// ============================================================================
+// SESSION REPOSITORY
+// ============================================================================
+
+// SessionRepository is an in-memory usecase.SessionRepository
+type SessionRepository struct {
+	mu       sync.RWMutex
+	sessions []*user.UserSession
+	clock    clock.Clock
+}
+
+// NewSessionRepository creates an empty in-memory session repository
+func NewSessionRepository(clk clock.Clock) *SessionRepository {
+	return &SessionRepository{clock: clock.OrSystem(clk)}
+}
+
+// Create stores a session and fills in its ID, timestamps and session token
+func (r *SessionRepository) Create(ctx context.Context, s *user.UserSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	s.ID = len(r.sessions) + 1
+	s.SessionToken = fmt.Sprintf("session-%d", s.ID)
+	s.CreatedAt, s.LastUsedAt = now, now
+
+	stored := *s
+	r.sessions = append(r.sessions, &stored)
+	return nil
+}
+
+// Sessions returns copies of the stored sessions of a user
+func (r *SessionRepository) Sessions(userID int) []user.UserSession {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []user.UserSession
+	for _, s := range r.sessions {
+		if s.UserID == userID {
+			sessions = append(sessions, *s)
+		}
+	}
+	return sessions
+}
+
+// RevokeAllForUser revokes the active sessions of a user and returns how
+// many were revoked
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID, revokedBy int, reason string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	var revoked int64
+	for _, s := range r.sessions {
+		if s.UserID == userID && !s.IsRevoked() {
+			by := revokedBy
+			s.RevokedAt, s.RevokedBy = &now, &by
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// ============================================================================
+// PUSH TOKEN REPOSITORY
+// ============================================================================
+
+// PushTokenRepository is an in-memory usecase.PushTokenRepository
+type PushTokenRepository struct {
+	mu     sync.RWMutex
+	tokens []*user.UserPushToken
+	clock  clock.Clock
+}
+
+// NewPushTokenRepository creates an empty in-memory push token repository
+func NewPushTokenRepository(clk clock.Clock) *PushTokenRepository {
+	return &PushTokenRepository{clock: clock.OrSystem(clk)}
+}
+
+// Put stores an active push token. Tokens are registered by the devices,
+// outside the use cases, so tests seed them here.
+func (r *PushTokenRepository) Put(t *user.UserPushToken) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	t.ID = len(r.tokens) + 1
+	t.IsActive = true
+	t.LastUsedAt, t.CreatedAt, t.UpdatedAt = now, now, now
+
+	stored := *t
+	r.tokens = append(r.tokens, &stored)
+}
+
+// DeactivateAllForUser deactivates the active push tokens of a user and
+// returns how many were deactivated
+func (r *PushTokenRepository) DeactivateAllForUser(ctx context.Context, userID int, reason string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	var deactivated int64
+	for _, t := range r.tokens {
+		if t.UserID == userID && t.IsActive {
+			t.IsActive, t.DeactivatedAt, t.UpdatedAt = false, &now, now
+			deactivated++
+		}
+	}
+	return deactivated, nil
+}
+
+// Tokens returns copies of the stored push tokens of a user
+func (r *PushTokenRepository) Tokens(userID int) []user.UserPushToken {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tokens []user.UserPushToken
+	for _, t := range r.tokens {
+		if t.UserID == userID {
+			tokens = append(tokens, *t)
+		}
+	}
+	return tokens
+}
+
+// ============================================================================
+// API KEY REPOSITORY
+// ============================================================================
+
+// APIKeyRepository is an in-memory usecase.APIKeyRepository
+type APIKeyRepository struct {
+	mu    sync.RWMutex
+	keys  []*user.APIKey
+	clock clock.Clock
+}
+
+// NewAPIKeyRepository creates an empty in-memory API key repository
+func NewAPIKeyRepository(clk clock.Clock) *APIKeyRepository {
+	return &APIKeyRepository{clock: clock.OrSystem(clk)}
+}
+
+// Put stores an unrevoked API key. Keys are issued outside the use cases,
+// so tests seed them here.
+func (r *APIKeyRepository) Put(k *user.APIKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k.ID = len(r.keys) + 1
+	k.RevokedAt, k.RevokedBy, k.RevokeReason = nil, nil, nil
+	k.CreatedAt = r.clock.Now()
+
+	stored := *k
+	r.keys = append(r.keys, &stored)
+}
+
+// RevokeAllForUser revokes the unrevoked API keys of a user and returns
+// how many were revoked
+func (r *APIKeyRepository) RevokeAllForUser(ctx context.Context, userID, revokedBy int, reason string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	var revoked int64
+	for _, k := range r.keys {
+		if k.UserID == userID && !k.IsRevoked() {
+			by, why := revokedBy, reason
+			k.RevokedAt, k.RevokedBy, k.RevokeReason = &now, &by, &why
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// FindActiveByHash finds an unrevoked, unexpired API key by the hash of
+// the raw key (see user.HashAPIKey)
+func (r *APIKeyRepository) FindActiveByHash(ctx context.Context, keyHash string) (*user.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.clock.Now()
+	for _, k := range r.keys {
+		if k.KeyHash != keyHash || k.IsRevoked() || (k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)) {
+			continue
+		}
+		found := *k
+		return &found, nil
+	}
+	return nil, user.ErrAPIKeyNotFound
+}
+
+// Keys returns copies of the stored API keys of a user
+func (r *APIKeyRepository) Keys(userID int) []user.APIKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var keys []user.APIKey
+	for _, k := range r.keys {
+		if k.UserID == userID {
+			keys = append(keys, *k)
+		}
+	}
+	return keys
+}
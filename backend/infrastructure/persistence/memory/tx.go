@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/application/usecase"
+)
+
+var _ usecase.TxManager = (*TxManager)(nil)
+
+// ============================================================================
+// TRANSACTION MANAGER
+// ============================================================================
+
+// TxManager is a usecase.TxManager for the in-memory repositories. They have
+// no rollback, so it runs fn directly and only counts the outcomes.
+type TxManager struct {
+	mu        sync.Mutex
+	commits   int
+	rollbacks int
+}
+
+// NewTxManager creates an in-memory transaction manager
+func NewTxManager() *TxManager {
+	return &TxManager{}
+}
+
+// WithinTx runs fn and records whether it would have committed
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.rollbacks++
+	} else {
+		m.commits++
+	}
+	return err
+}
+
+// Counts returns how many transactions committed and rolled back
+func (m *TxManager) Counts() (commits, rollbacks int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.commits, m.rollbacks
+}
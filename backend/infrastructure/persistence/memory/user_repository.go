@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+)
+
+// ============================================================================
+// USER REPOSITORY
+// ============================================================================
+
+// UserRepository is an in-memory usecase.UserRepository
+type UserRepository struct {
+	mu      sync.RWMutex
+	users   map[int]*user.User
+	changes []user.AccountStatusChange
+	nextID  int
+	clock   clock.Clock
+}
+
+// NewUserRepository creates an empty in-memory user repository
+func NewUserRepository(clk clock.Clock) *UserRepository {
+	return &UserRepository{
+		users: make(map[int]*user.User),
+		clock: clock.OrSystem(clk),
+	}
+}
+
+// FindByEmail finds a user by email. Soft-deleted users are only returned
+// with includeDeleted.
+func (r *UserRepository) FindByEmail(ctx context.Context, email string, includeDeleted bool) (*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.Email == email && (includeDeleted || !u.IsDeleted()) {
+			found := *u
+			return &found, nil
+		}
+	}
+	return nil, user.ErrUserNotFound
+}
+
+// FindByID finds a user by ID. Soft-deleted users are only returned with
+// includeDeleted.
+func (r *UserRepository) FindByID(ctx context.Context, id int, includeDeleted bool) (*user.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok || (u.IsDeleted() && !includeDeleted) {
+		return nil, user.ErrUserNotFound
+	}
+	found := *u
+	return &found, nil
+}
+
+// Create stores a user and fills in its ID and timestamps
+func (r *UserRepository) Create(ctx context.Context, u *user.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == u.Email {
+			return user.ErrEmailAlreadyExists
+		}
+	}
+
+	if u.AccountStatus == "" {
+		u.AccountStatus = user.AccountPending
+	}
+	r.nextID++
+	now := r.clock.Now()
+	u.ID = r.nextID
+	u.CreatedAt, u.UpdatedAt = now, now
+	u.UpdatedBy = u.CreatedBy
+	u.Version = 1
+
+	stored := *u
+	r.users[u.ID] = &stored
+	return nil
+}
+
+// SoftDelete marks a live user deleted and closes the account. It returns
+// the status the account had and the new row version.
+func (r *UserRepository) SoftDelete(ctx context.Context, id, deletedBy int, reason *string) (user.AccountStatus, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok || u.IsDeleted() {
+		return "", 0, user.ErrUserNotFound
+	}
+
+	previous := u.AccountStatus
+	now := r.clock.Now()
+	u.DeletedAt, u.DeletedBy = &now, &deletedBy
+	u.AccountStatus, u.StatusChangedAt, u.StatusChangedBy, u.StatusReason = user.AccountClosed, &now, &deletedBy, reason
+	r.touch(u, deletedBy)
+	return previous, u.Version, nil
+}
+
+// Restore reactivates a soft-deleted user whose data has not been purged.
+// It returns the new row version.
+func (r *UserRepository) Restore(ctx context.Context, id, restoredBy int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok || !u.IsDeleted() || u.IsPurged() {
+		return 0, user.ErrUserNotFound
+	}
+
+	now := r.clock.Now()
+	u.DeletedAt, u.DeletedBy = nil, nil
+	u.AccountStatus, u.StatusChangedAt, u.StatusChangedBy, u.StatusReason = user.AccountActive, &now, &restoredBy, nil
+	r.touch(u, restoredBy)
+	return u.Version, nil
+}
+
+// LogStatusChange records a status change
+func (r *UserRepository) LogStatusChange(ctx context.Context, c *user.AccountStatusChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c.ID = len(r.changes) + 1
+	c.CreatedAt = r.clock.Now()
+	r.changes = append(r.changes, *c)
+	return nil
+}
+
+// FindPurgeable returns up to limit IDs of users deleted before
+// deletedBefore whose data has not been purged yet, oldest first
+func (r *UserRepository) FindPurgeable(ctx context.Context, deletedBefore time.Time, limit int) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var due []*user.User
+	for _, u := range r.users {
+		if u.IsDeleted() && !u.IsPurged() && u.DeletedAt.Before(deletedBefore) {
+			due = append(due, u)
+		}
+	}
+	slices.SortFunc(due, func(a, b *user.User) int { return a.DeletedAt.Compare(*b.DeletedAt) })
+
+	ids := make([]int, 0, min(limit, len(due)))
+	for _, u := range due[:min(limit, len(due))] {
+		ids = append(ids, u.ID)
+	}
+	return ids, nil
+}
+
+// Purge erases the personal data of a soft-deleted user
+func (r *UserRepository) Purge(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok || !u.IsDeleted() || u.IsPurged() {
+		return user.ErrUserNotFound
+	}
+
+	now := r.clock.Now()
+	u.Email = fmt.Sprintf("deleted-%d@deleted.invalid", u.ID)
+	u.Name = "Deleted user"
+	u.Phone, u.AvatarURL = nil, nil
+	u.BloodType, u.Allergies, u.EmergencyContactName, u.EmergencyContactPhone = nil, nil, nil, nil
+	u.StatusReason = nil
+	u.PurgedAt = &now
+	u.UpdatedAt = now
+	u.Version++
+	return nil
+}
+
+// StatusChanges returns the logged status changes, oldest first
+func (r *UserRepository) StatusChanges() []user.AccountStatusChange {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.changes)
+}
+
+// touch records an update by updatedBy, like the version trigger and audit
+// columns do in Postgres
+func (r *UserRepository) touch(u *user.User, updatedBy int) {
+	u.UpdatedAt, u.UpdatedBy = r.clock.Now(), &updatedBy
+	u.Version++
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+)
+
+// ============================================================================
+// ACTIVITY REPOSITORY
+// ============================================================================
+
+// ActivityRepository implements usecase.ActivityRepository using PostgreSQL
+type ActivityRepository struct {
+	db *sql.DB
+}
+
+// NewActivityRepository creates a new Postgres-backed activity repository
+func NewActivityRepository(db *sql.DB) *ActivityRepository {
+	return &ActivityRepository{db: db}
+}
+
+// CreateLoginActivity logs a login attempt. Attempts for unknown emails
+// carry user ID 0, which is stored as NULL.
+func (r *ActivityRepository) CreateLoginActivity(ctx context.Context, a *user.LoginActivity) error {
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO login_activity
+			(user_id, email, success, ip_address, user_agent, location, reason, session_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`,
+		nullInt(a.UserID), a.Email, a.Success, a.IPAddress,
+		nullString(&a.UserAgent), nullString(a.Location), nullString(a.Reason), nullInt(a.SessionID),
+	).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create login activity: %w", err)
+	}
+
+	return nil
+}
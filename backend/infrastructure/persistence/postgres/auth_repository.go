@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+)
+
+// ============================================================================
+// CREDENTIAL REPOSITORY
+// ============================================================================
+
+// CredentialRepository implements usecase.CredentialRepository using PostgreSQL
+type CredentialRepository struct {
+	db *sql.DB
+}
+
+// NewCredentialRepository creates a new Postgres-backed credential repository
+func NewCredentialRepository(db *sql.DB) *CredentialRepository {
+	return &CredentialRepository{db: db}
+}
+
+// GetByUserID gets the active credentials of a user that is not deleted
+func (r *CredentialRepository) GetByUserID(ctx context.Context, userID int) (*user.UserCredential, error) {
+	c := &user.UserCredential{}
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		SELECT c.id, c.user_id, c.password_hash,
+			COALESCE(c.two_factor_enabled, FALSE), c.two_factor_secret,
+			c.created_at, c.updated_at, c.created_by, c.updated_by, c.deleted_at, c.deleted_by
+		FROM user_credentials c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.user_id = $1 AND c.deleted_at IS NULL AND u.deleted_at IS NULL
+	`, userID).Scan(
+		&c.ID, &c.UserID, &c.PasswordHash,
+		&c.TwoFactorEnabled, &c.TwoFactorSecret,
+		&c.CreatedAt, &c.UpdatedAt, &c.CreatedBy, &c.UpdatedBy, &c.DeletedAt, &c.DeletedBy,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrCredentialNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user credential: %w", err)
+	}
+
+	return c, nil
+}
+
+// SetPassword stores the password hash of a user. The credentials row is
+// created with the user by the initialize_user_defaults trigger.
+func (r *CredentialRepository) SetPassword(ctx context.Context, userID int, passwordHash string) error {
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, `
+		UPDATE user_credentials
+		SET password_hash = $2
+		WHERE user_id = $1 AND deleted_at IS NULL
+	`, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return user.ErrCredentialNotFound
+	}
+	return nil
+}
+
+// ============================================================================
+// TOKEN REPOSITORY
+// ============================================================================
+
+// TokenRepository implements usecase.TokenRepository using PostgreSQL
+type TokenRepository struct {
+	db *sql.DB
+}
+
+// NewTokenRepository creates a new Postgres-backed token repository
+func NewTokenRepository(db *sql.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+// CreatePasswordReset stores a password reset token and fills in its ID
+func (r *TokenRepository) CreatePasswordReset(ctx context.Context, t *user.PasswordResetToken) error {
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO password_reset_tokens (user_id, token, expires_at, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, t.UserID, t.Token, t.ExpiresAt, nullString(t.IPAddress), nullString(t.UserAgent),
+	).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	return nil
+}
+
+// GetPasswordReset gets a password reset token by ID
+func (r *TokenRepository) GetPasswordReset(ctx context.Context, id int) (*user.PasswordResetToken, error) {
+	t := &user.PasswordResetToken{}
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, user_id, token, expires_at, used_at, created_at
+		FROM password_reset_tokens
+		WHERE id = $1
+	`, id).Scan(&t.ID, &t.UserID, &t.Token, &t.ExpiresAt, &t.UsedAt, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrResetTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/application/subscriber"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+)
+
+var (
+	_ subscriber.EmailQueue     = (*EmailQueueRepository)(nil)
+	_ subscriber.SecurityLog    = (*SecurityEventRepository)(nil)
+	_ subscriber.PasswordResets = (*TokenRepository)(nil)
+)
+
+// ============================================================================
+// EMAIL QUEUE REPOSITORY
+// ============================================================================
+
+// EmailQueueRepository implements subscriber.EmailQueue on the email_queue table
+type EmailQueueRepository struct {
+	db *sql.DB
+}
+
+// NewEmailQueueRepository creates a new Postgres-backed email queue
+func NewEmailQueueRepository(db *sql.DB) *EmailQueueRepository {
+	return &EmailQueueRepository{db: db}
+}
+
+// Enqueue inserts a pending email
+func (r *EmailQueueRepository) Enqueue(ctx context.Context, e *subscriber.Email) error {
+	var data []byte
+	if e.Data != nil {
+		var err error
+		if data, err = json.Marshal(e.Data); err != nil {
+			return fmt.Errorf("failed to encode email template data: %w", err)
+		}
+	}
+
+	priority := e.Priority
+	if priority == 0 {
+		priority = 5
+	}
+
+	_, err := querierFrom(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO email_queue
+			(user_id, to_email, subject, body, template_name, template_data, priority)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, nullInt(&e.UserID), e.To, e.Subject, e.Body, nullString(&e.Template), data, priority)
+	if err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// SECURITY EVENT REPOSITORY
+// ============================================================================
+
+// SecurityEventRepository implements subscriber.SecurityLog on the
+// security_events table
+type SecurityEventRepository struct {
+	db *sql.DB
+}
+
+// NewSecurityEventRepository creates a new Postgres-backed security event log
+func NewSecurityEventRepository(db *sql.DB) *SecurityEventRepository {
+	return &SecurityEventRepository{db: db}
+}
+
+// Record inserts a security event
+func (r *SecurityEventRepository) Record(ctx context.Context, e *user.SecurityEvent) error {
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO security_events
+			(user_id, event_type, severity, description, ip_address, user_agent, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`,
+		nullInt(e.UserID), e.EventType, e.Severity, e.Description, e.IPAddress,
+		nullString(e.UserAgent), nullString(e.Metadata),
+	).Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record security event: %w", err)
+	}
+	return nil
+}
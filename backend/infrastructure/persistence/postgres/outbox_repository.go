@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/outbox"
+	"github.com/lib/pq"
+)
+
+var _ outbox.Store = (*OutboxRepository)(nil)
+
+// ============================================================================
+// OUTBOX REPOSITORY
+// ============================================================================
+
+// OutboxRepository implements outbox.Store on the outbox and
+// outbox_deliveries tables. Every method joins the transaction in ctx, so
+// events are added atomically with the change they describe.
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+// NewOutboxRepository creates a new Postgres-backed outbox
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Add inserts events, skipping those whose idempotency key is already stored
+func (r *OutboxRepository) Add(ctx context.Context, events ...*outbox.Event) error {
+	q := querierFrom(ctx, r.db)
+	for _, e := range events {
+		err := q.QueryRowContext(ctx, `
+			INSERT INTO outbox (event_type, aggregate_type, aggregate_id, idempotency_key, payload)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (idempotency_key) DO NOTHING
+			RETURNING id, created_at
+		`, e.Type, e.AggregateType, e.AggregateID, e.IdempotencyKey, []byte(e.Payload)).Scan(&e.ID, &e.CreatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue // Already stored
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add %s event: %w", e.Type, err)
+		}
+	}
+	return nil
+}
+
+// Claim leases up to limit due events. SKIP LOCKED lets several
+// dispatchers (one per instance) share the table without blocking.
+func (r *OutboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]*outbox.Event, error) {
+	rows, err := querierFrom(ctx, r.db).QueryContext(ctx, `
+		UPDATE outbox o
+		SET attempts = o.attempts + 1,
+			locked_until = NOW() + make_interval(secs => $2)
+		WHERE o.id IN (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND failed_at IS NULL
+			AND available_at <= NOW()
+			AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING o.id, o.event_type, o.aggregate_type, o.aggregate_id, o.idempotency_key,
+			o.payload, o.attempts, o.created_at,
+			ARRAY(SELECT d.subscriber FROM outbox_deliveries d WHERE d.event_id = o.id)
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*outbox.Event
+	for rows.Next() {
+		e := &outbox.Event{}
+		var payload []byte
+		if err := rows.Scan(
+			&e.ID, &e.Type, &e.AggregateType, &e.AggregateID, &e.IdempotencyKey,
+			&payload, &e.Attempts, &e.CreatedAt,
+			pq.Array(&e.Delivered),
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// MarkDelivered records that subscriber handled the event
+func (r *OutboxRepository) MarkDelivered(ctx context.Context, eventID int64, subscriber string) error {
+	_, err := querierFrom(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO outbox_deliveries (event_id, subscriber)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, eventID, subscriber)
+	if err != nil {
+		return fmt.Errorf("failed to record delivery: %w", err)
+	}
+	return nil
+}
+
+// Publish marks the event handled by every subscriber
+func (r *OutboxRepository) Publish(ctx context.Context, eventID int64) error {
+	return r.exec(ctx, `
+		UPDATE outbox SET published_at = NOW(), locked_until = NULL, last_error = NULL
+		WHERE id = $1
+	`, eventID)
+}
+
+// Retry releases the event until delay has passed
+func (r *OutboxRepository) Retry(ctx context.Context, eventID int64, delay time.Duration, reason string) error {
+	return r.exec(ctx, `
+		UPDATE outbox
+		SET available_at = NOW() + make_interval(secs => $2), locked_until = NULL, last_error = $3
+		WHERE id = $1
+	`, eventID, delay.Seconds(), reason)
+}
+
+// Fail parks the event
+func (r *OutboxRepository) Fail(ctx context.Context, eventID int64, reason string) error {
+	return r.exec(ctx, `
+		UPDATE outbox SET failed_at = NOW(), locked_until = NULL, last_error = $2
+		WHERE id = $1
+	`, eventID, reason)
+}
+
+// Prune deletes events published more than olderThan ago
+func (r *OutboxRepository) Prune(ctx context.Context, olderThan time.Duration) (int64, error) {
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM outbox WHERE published_at < NOW() - make_interval(secs => $1)
+	`, olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune outbox: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// exec runs a delivery state update
+func (r *OutboxRepository) exec(ctx context.Context, query string, args ...any) error {
+	if _, err := querierFrom(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update outbox event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Package postgres implements the application repositories against the
+// PostgreSQL schema in internal/db/migrations.
+package postgres
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/application/usecase"
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// INTERFACE CHECKS
+// ============================================================================
+
+var (
+	_ usecase.UserRepository       = (*UserRepository)(nil)
+	_ usecase.CredentialRepository = (*CredentialRepository)(nil)
+	_ usecase.SecurityRepository   = (*SecurityRepository)(nil)
+	_ usecase.SessionRepository    = (*SessionRepository)(nil)
+	_ usecase.PushTokenRepository  = (*PushTokenRepository)(nil)
+	_ usecase.APIKeyRepository     = (*APIKeyRepository)(nil)
+	_ usecase.TokenRepository      = (*TokenRepository)(nil)
+	_ usecase.ActivityRepository   = (*ActivityRepository)(nil)
+	_ usecase.EventOutbox          = (*OutboxRepository)(nil)
+)
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// uniqueViolation is the SQLSTATE for unique_violation
+const uniqueViolation = "23505"
+
+// scanner is satisfied by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
+// nullInt maps the zero ID to NULL
+func nullInt(id *int) *int {
+	if id == nil || *id == 0 {
+		return nil
+	}
+	return id
+}
+
+// nullString maps the empty string to NULL
+func nullString(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
+
+// newOpaqueToken returns a random hex token for columns the domain model
+// does not carry but the schema requires (e.g. user_sessions.session_token)
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// SECURITY REPOSITORY
+// ============================================================================
+
+// SecurityRepository implements usecase.SecurityRepository using PostgreSQL
+type SecurityRepository struct {
+	db *sql.DB
+}
+
+// NewSecurityRepository creates a new Postgres-backed security info repository
+func NewSecurityRepository(db *sql.DB) *SecurityRepository {
+	return &SecurityRepository{db: db}
+}
+
+// GetByUserID gets the security info of a user
+func (r *SecurityRepository) GetByUserID(ctx context.Context, userID int) (*user.UserSecurityInfo, error) {
+	s := &user.UserSecurityInfo{}
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, user_id, COALESCE(failed_login_attempts, 0),
+			last_failed_login_at, locked_until, last_password_change, last_login_at,
+			created_at, updated_at
+		FROM user_security_info
+		WHERE user_id = $1
+	`, userID).Scan(
+		&s.ID, &s.UserID, &s.FailedLoginAttempts,
+		&s.LastFailedLoginAt, &s.LockedUntil, &s.LastPasswordChange, &s.LastLoginAt,
+		&s.CreatedAt, &s.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrSecurityInfoNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user security info: %w", err)
+	}
+
+	return s, nil
+}
+
+// Create stores the security info of a user. The row normally exists
+// already (created by the initialize_user_defaults trigger), so an existing
+// row is overwritten rather than rejected.
+func (r *SecurityRepository) Create(ctx context.Context, s *user.UserSecurityInfo) error {
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO user_security_info
+			(user_id, failed_login_attempts, last_failed_login_at,
+			 locked_until, last_password_change, last_login_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			failed_login_attempts = EXCLUDED.failed_login_attempts,
+			last_failed_login_at = EXCLUDED.last_failed_login_at,
+			locked_until = EXCLUDED.locked_until,
+			last_password_change = EXCLUDED.last_password_change,
+			last_login_at = EXCLUDED.last_login_at
+		RETURNING id, created_at, updated_at
+	`,
+		s.UserID, s.FailedLoginAttempts, s.LastFailedLoginAt,
+		s.LockedUntil, s.LastPasswordChange, s.LastLoginAt,
+	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create user security info: %w", err)
+	}
+
+	return nil
+}
+
+// Update saves the login counters and timestamps of a user
+func (r *SecurityRepository) Update(ctx context.Context, s *user.UserSecurityInfo) error {
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		UPDATE user_security_info SET
+			failed_login_attempts = $2,
+			last_failed_login_at = $3,
+			locked_until = $4,
+			last_password_change = $5,
+			last_login_at = $6
+		WHERE user_id = $1
+		RETURNING updated_at
+	`,
+		s.UserID, s.FailedLoginAttempts, s.LastFailedLoginAt,
+		s.LockedUntil, s.LastPasswordChange, s.LastLoginAt,
+	).Scan(&s.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user.ErrSecurityInfoNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update user security info: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================================================
+// SESSION REPOSITORY
+// ============================================================================
+
+// SessionRepository implements usecase.SessionRepository using PostgreSQL
+type SessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository creates a new Postgres-backed session repository
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create stores a session and fills in its ID, timestamps and raw session
+// token. Only the hash of the session token is stored (see
+// user.HashSessionToken); an empty refresh token gets a random value until
+// the caller rotates it in.
+func (r *SessionRepository) Create(ctx context.Context, s *user.UserSession) error {
+	var err error
+	if s.SessionToken, err = newOpaqueToken(); err != nil {
+		return err
+	}
+	if s.RefreshToken == "" {
+		if s.RefreshToken, err = newOpaqueToken(); err != nil {
+			return err
+		}
+	}
+
+	err = querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO user_sessions
+			(user_id, session_token, refresh_token,
+			 device_id, device_name, platform, app_version,
+			 ip_address, user_agent, location, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, last_used_at, created_at
+	`,
+		s.UserID, user.HashSessionToken(s.SessionToken), s.RefreshToken,
+		nullString(s.DeviceID), nullString(s.DeviceName), nullString(s.Platform), nullString(s.AppVersion),
+		nullString(s.IPAddress), nullString(s.UserAgent), nullString(s.Location), s.ExpiresAt,
+	).Scan(&s.ID, &s.LastUsedAt, &s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create user session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllForUser revokes the active sessions of a user and returns how
+// many were revoked
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID, revokedBy int, reason string) (int64, error) {
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, `
+		UPDATE user_sessions
+		SET revoked_at = NOW(), revoked_by = $2, revoke_reason = $3
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID, nullInt(&revokedBy), reason)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// FindUserBySessionToken gets the user of an unrevoked, unexpired session.
+// The user must not be deleted.
+func (r *SessionRepository) FindUserBySessionToken(ctx context.Context, sessionToken string) (*user.User, error) {
+	u, err := scanUser(querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE deleted_at IS NULL AND id = (
+			SELECT user_id FROM user_sessions
+			WHERE session_token = $1
+			AND revoked_at IS NULL
+			AND expires_at > NOW()
+		)
+	`, user.HashSessionToken(sessionToken)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find session user: %w", err)
+	}
+
+	return u, nil
+}
+
+// ============================================================================
+// PUSH TOKEN REPOSITORY
+// ============================================================================
+
+// PushTokenRepository implements usecase.PushTokenRepository using PostgreSQL
+type PushTokenRepository struct {
+	db *sql.DB
+}
+
+// NewPushTokenRepository creates a new Postgres-backed push token repository
+func NewPushTokenRepository(db *sql.DB) *PushTokenRepository {
+	return &PushTokenRepository{db: db}
+}
+
+// DeactivateAllForUser deactivates the active push tokens of a user and
+// returns how many were deactivated
+func (r *PushTokenRepository) DeactivateAllForUser(ctx context.Context, userID int, reason string) (int64, error) {
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, `
+		UPDATE user_push_tokens
+		SET is_active = FALSE, deactivated_at = NOW(), deactivation_reason = $2, updated_at = NOW()
+		WHERE user_id = $1 AND is_active = TRUE
+	`, userID, reason)
+	if err != nil {
+		return 0, fmt.Errorf("failed to deactivate push tokens: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ============================================================================
+// API KEY REPOSITORY
+// ============================================================================
+
+// APIKeyRepository implements usecase.APIKeyRepository using PostgreSQL
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository creates a new Postgres-backed API key repository
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// RevokeAllForUser revokes the unrevoked API keys of a user and returns
+// how many were revoked
+func (r *APIKeyRepository) RevokeAllForUser(ctx context.Context, userID, revokedBy int, reason string) (int64, error) {
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, `
+		UPDATE api_keys
+		SET revoked_at = NOW(), revoked_by = $2, revoke_reason = $3
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID, revokedBy, reason)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke API keys: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// FindActiveByHash finds an unrevoked, unexpired API key of a user that is
+// not deleted by the hash of the raw key (see user.HashAPIKey)
+func (r *APIKeyRepository) FindActiveByHash(ctx context.Context, keyHash string) (*user.APIKey, error) {
+	k := &user.APIKey{}
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		SELECT k.id, k.user_id, k.key_hash, k.key_prefix, k.name, k.description, k.scopes,
+			k.rate_limit_max_requests, k.rate_limit_window_seconds, k.rate_limit_block_seconds,
+			k.last_used_at, COALESCE(k.usage_count, 0),
+			k.expires_at, k.revoked_at, k.revoked_by, k.revoke_reason, k.created_at
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = $1
+		AND k.revoked_at IS NULL
+		AND (k.expires_at IS NULL OR k.expires_at > NOW())
+		AND u.deleted_at IS NULL
+	`, keyHash).Scan(
+		&k.ID, &k.UserID, &k.KeyHash, &k.KeyPrefix, &k.Name, &k.Description, pq.Array(&k.Scopes),
+		&k.RateLimitMaxRequests, &k.RateLimitWindowSeconds, &k.RateLimitBlockSeconds,
+		&k.LastUsedAt, &k.UsageCount,
+		&k.ExpiresAt, &k.RevokedAt, &k.RevokedBy, &k.RevokeReason, &k.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return k, nil
+}
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// TRANSACTION MANAGER
+// ============================================================================
+// WithinTx stores the transaction in the context; repositories pick it up
+// through querierFrom, so a use case only passes ctx along. Nested calls
+// run inside a savepoint of the outer transaction.
+
+// SQLSTATEs that mean the whole transaction can be retried
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// Defaults for TxManager
+const (
+	DefaultTxMaxRetries   = 3
+	DefaultTxRetryBackoff = 20 * time.Millisecond
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// txKey is the context key of the current transaction
+type txKey struct{}
+
+// txState is the transaction stored in a context
+type txState struct {
+	tx    *sql.Tx
+	depth int // savepoint nesting level, 0 for the transaction itself
+}
+
+// querierFrom returns the transaction in ctx, or db outside a transaction
+func querierFrom(ctx context.Context, db *sql.DB) querier {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db
+}
+
+// TxManager runs functions in a database transaction
+type TxManager struct {
+	db *sql.DB
+
+	// Isolation is the isolation level of new transactions
+	Isolation sql.IsolationLevel
+	// MaxRetries is how often a transaction failing with a serialization
+	// failure or deadlock is rerun (0 disables retries)
+	MaxRetries int
+	// RetryBackoff is the base delay before a retry, doubled every attempt
+	RetryBackoff time.Duration
+}
+
+// NewTxManager creates a transaction manager with default retry settings
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{
+		db:           db,
+		MaxRetries:   DefaultTxMaxRetries,
+		RetryBackoff: DefaultTxRetryBackoff,
+	}
+}
+
+// WithinTx runs fn in a transaction, committing if fn returns nil and
+// rolling back otherwise. Inside an existing transaction fn runs in a
+// savepoint, so its failure only undoes its own writes.
+//
+// A transaction failing with a serialization failure or deadlock is rerun
+// from the start, so fn must not keep side effects outside the database.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return m.withinSavepoint(ctx, state, fn)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := m.run(ctx, fn)
+		if err == nil || !isRetryable(err) || attempt >= m.MaxRetries {
+			return err
+		}
+
+		delay := m.RetryBackoff << attempt
+		delay += rand.N(delay + 1) // jitter so retries do not collide again
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+// run executes fn once in a new transaction
+func (m *TxManager) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: m.Isolation})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to roll back transaction: %w", rbErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// withinSavepoint executes fn in a savepoint of the transaction in state
+func (m *TxManager) withinSavepoint(ctx context.Context, state *txState, fn func(ctx context.Context) error) (err error) {
+	nested := &txState{tx: state.tx, depth: state.depth + 1}
+	name := fmt.Sprintf("sp_%d", nested.depth)
+
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, nested)); err != nil {
+		if _, rbErr := state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to roll back savepoint: %w", rbErr))
+		}
+		return err
+	}
+
+	if _, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
+
+// isRetryable reports whether err means the transaction can be rerun
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == serializationFailure || pqErr.Code == deadlockDetected
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// recorder is a database/sql driver that logs statements instead of
+// running them; fail decides the error of each statement
+type recorder struct {
+	mu   sync.Mutex
+	log  []string
+	fail func(query string) error
+}
+
+func (r *recorder) record(query string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = append(r.log, query)
+	if r.fail != nil {
+		return r.fail(query)
+	}
+	return nil
+}
+
+func (r *recorder) statements() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.log, "; ")
+}
+
+func (r *recorder) Connect(context.Context) (driver.Conn, error) { return recorderConn{r}, nil }
+func (r *recorder) Driver() driver.Driver                        { return nil }
+
+type recorderConn struct{ r *recorder }
+
+func (c recorderConn) Prepare(string) (driver.Stmt, error) { return nil, errors.ErrUnsupported }
+func (c recorderConn) Close() error                        { return nil }
+func (c recorderConn) Begin() (driver.Tx, error)           { return c, c.r.record("BEGIN") }
+func (c recorderConn) Commit() error                       { return c.r.record("COMMIT") }
+func (c recorderConn) Rollback() error                     { return c.r.record("ROLLBACK") }
+
+func (c recorderConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), c.r.record(query)
+}
+
+func TestTxManager(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	db := sql.OpenDB(rec)
+	defer db.Close()
+
+	m := NewTxManager(db)
+	m.RetryBackoff = 0
+	exec := func(ctx context.Context, query string) error {
+		_, err := querierFrom(ctx, db).ExecContext(ctx, query)
+		return err
+	}
+
+	// A failing nested call only rolls back its savepoint
+	err := m.WithinTx(ctx, func(ctx context.Context) error {
+		_ = exec(ctx, "INSERT a")
+		_ = m.WithinTx(ctx, func(ctx context.Context) error {
+			_ = exec(ctx, "INSERT b")
+			return errors.New("log failed")
+		})
+		return m.WithinTx(ctx, func(ctx context.Context) error { return exec(ctx, "INSERT c") })
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "BEGIN; INSERT a; SAVEPOINT sp_1; INSERT b; ROLLBACK TO SAVEPOINT sp_1; " +
+		"SAVEPOINT sp_1; INSERT c; RELEASE SAVEPOINT sp_1; COMMIT"
+	if got := rec.statements(); got != want {
+		t.Errorf("statements =\n%s\nwant\n%s", got, want)
+	}
+
+	// Serialization failures rerun the whole transaction
+	rec.log = nil
+	failures := 2
+	rec.fail = func(query string) error {
+		if query == "UPDATE" && failures > 0 {
+			failures--
+			return &pq.Error{Code: serializationFailure}
+		}
+		return nil
+	}
+	runs := 0
+	err = m.WithinTx(ctx, func(ctx context.Context) error {
+		runs++
+		return exec(ctx, "UPDATE")
+	})
+	if err != nil || runs != 3 {
+		t.Errorf("WithinTx = %v after %d runs, want nil after 3", err, runs)
+	}
+
+	// Other errors and exhausted retries are returned
+	failures = m.MaxRetries + 1
+	if err := m.WithinTx(ctx, func(ctx context.Context) error { return exec(ctx, "UPDATE") }); !isRetryable(err) {
+		t.Errorf("exhausted retries = %v, want the serialization failure", err)
+	}
+	runs = 0
+	err = m.WithinTx(ctx, func(ctx context.Context) error {
+		runs++
+		return errors.New("boom")
+	})
+	if err == nil || runs != 1 {
+		t.Errorf("plain error = %v after %d runs, want it after 1", err, runs)
+	}
+}
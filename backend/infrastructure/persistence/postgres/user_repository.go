@@ -0,0 +1,484 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/persistence/query"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// USER REPOSITORY
+// ============================================================================
+
+// userColumns lists the users columns scanned by scanUser, in order
+const userColumns = `
+	id, email, name, role, version,
+	account_status, status_changed_at, status_changed_by, status_reason,
+	phone, avatar_url,
+	blood_type, allergies, emergency_contact_name, emergency_contact_phone,
+	COALESCE(email_verified, FALSE), COALESCE(phone_verified, FALSE),
+	purged_at, created_at, updated_at, created_by, updated_by, deleted_at, deleted_by`
+
+// UserRepository implements usecase.UserRepository using PostgreSQL
+type UserRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository creates a new Postgres-backed user repository
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// FindByEmail finds a user by email. Soft-deleted users are only returned
+// with includeDeleted (e.g. so the login policy can report them as deleted).
+func (r *UserRepository) FindByEmail(ctx context.Context, email string, includeDeleted bool) (*user.User, error) {
+	q := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+	if !includeDeleted {
+		q += ` AND deleted_at IS NULL`
+	}
+
+	u, err := scanUser(querierFrom(ctx, r.db).QueryRowContext(ctx, q, email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user by email: %w", err)
+	}
+
+	return u, nil
+}
+
+// FindByID finds a user by ID. Soft-deleted users are only returned with
+// includeDeleted.
+func (r *UserRepository) FindByID(ctx context.Context, id int, includeDeleted bool) (*user.User, error) {
+	q := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	if !includeDeleted {
+		q += ` AND deleted_at IS NULL`
+	}
+
+	u, err := scanUser(querierFrom(ctx, r.db).QueryRowContext(ctx, q, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return u, nil
+}
+
+// Create inserts a user and fills in its ID and timestamps.
+// The initialize_user_defaults trigger creates the matching credential,
+// security info and preferences rows.
+func (r *UserRepository) Create(ctx context.Context, u *user.User) error {
+	if u.AccountStatus == "" {
+		u.AccountStatus = user.AccountPending
+	}
+
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO users
+			(email, name, role, account_status,
+			 phone, avatar_url,
+			 blood_type, allergies, emergency_contact_name, emergency_contact_phone,
+			 email_verified, phone_verified, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $13)
+		RETURNING id, created_at, updated_at, version
+	`,
+		u.Email, u.Name, u.Role, u.AccountStatus,
+		u.Phone, u.AvatarURL,
+		u.BloodType, u.Allergies, u.EmergencyContactName, u.EmergencyContactPhone,
+		u.EmailVerified, u.PhoneVerified, u.CreatedBy,
+	).Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt, &u.Version)
+	if isUniqueViolation(err) {
+		return user.ErrEmailAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	u.UpdatedBy = u.CreatedBy
+	return nil
+}
+
+// Update applies the non-nil profile fields of req to a live user and
+// returns its new version. With version > 0 the update only applies while
+// the user is still at that version; otherwise it returns ErrVersionConflict.
+func (r *UserRepository) Update(ctx context.Context, id, version int, req user.UserUpdateRequest, updatedBy int) (int, error) {
+	b := query.New()
+	setIfPresent(b, "name", req.Name)
+	setIfPresent(b, "phone", req.Phone)
+	setIfPresent(b, "avatar_url", req.AvatarURL)
+	setIfPresent(b, "blood_type", req.BloodType)
+	setIfPresent(b, "allergies", req.Allergies)
+	setIfPresent(b, "emergency_contact_name", req.EmergencyContactName)
+	setIfPresent(b, "emergency_contact_phone", req.EmergencyContactPhone)
+	if b.SetClause() == "" {
+		return 0, user.ErrNoFieldsToUpdate
+	}
+	b.Set("updated_at = NOW()")
+	b.Set("updated_by = ?", nullInt(&updatedBy))
+	b.Where("id = ?", id)
+	b.Where("deleted_at IS NULL")
+
+	newVersion, err := UpdateVersioned(ctx, r.db, "users", b, version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, user.ErrUserNotFound
+	}
+	return newVersion, err
+}
+
+// ChangeStatus sets the account status of a live user and logs the change
+// in account_status_changes. It returns the updated user and the status it
+// replaced. Call it within a transaction so the row lock covers both writes.
+func (r *UserRepository) ChangeStatus(ctx context.Context, id int, status user.AccountStatus, reason *string, changedBy int, ipAddress string) (*user.User, user.AccountStatus, error) {
+	q := querierFrom(ctx, r.db)
+
+	var previous user.AccountStatus
+	err := q.QueryRowContext(ctx, `
+		SELECT account_status FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	`, id).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", user.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	u, err := scanUser(q.QueryRowContext(ctx, `
+		UPDATE users
+		SET account_status = $1,
+			status_changed_at = NOW(),
+			status_changed_by = $2,
+			status_reason = $3,
+			updated_at = NOW(),
+			updated_by = $2
+		WHERE id = $4
+		RETURNING `+userColumns,
+		status, changedBy, reason, id,
+	))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to change account status: %w", err)
+	}
+
+	err = r.LogStatusChange(ctx, &user.AccountStatusChange{
+		UserID: id, OldStatus: previous, NewStatus: status,
+		Reason: reason, ChangedBy: changedBy, IPAddress: &ipAddress,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return u, previous, nil
+}
+
+// SoftDelete marks a live user deleted and closes the account. It returns
+// the status the account had and the new row version.
+func (r *UserRepository) SoftDelete(ctx context.Context, id, deletedBy int, reason *string) (user.AccountStatus, int, error) {
+	var previous user.AccountStatus
+	var version int
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		WITH target AS (
+			SELECT id, account_status FROM users
+			WHERE id = $1 AND deleted_at IS NULL
+			FOR UPDATE
+		)
+		UPDATE users u
+		SET deleted_at = NOW(),
+			deleted_by = $2,
+			account_status = 'closed',
+			status_changed_at = NOW(),
+			status_changed_by = $2,
+			status_reason = $3,
+			updated_at = NOW(),
+			updated_by = $2
+		FROM target
+		WHERE u.id = target.id
+		RETURNING target.account_status, u.version
+	`, id, deletedBy, reason).Scan(&previous, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, user.ErrUserNotFound
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return previous, version, nil
+}
+
+// Restore reactivates a soft-deleted user whose data has not been purged.
+// It returns the new row version.
+func (r *UserRepository) Restore(ctx context.Context, id, restoredBy int) (int, error) {
+	var version int
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		UPDATE users
+		SET deleted_at = NULL,
+			deleted_by = NULL,
+			account_status = 'active',
+			status_changed_at = NOW(),
+			status_changed_by = $2,
+			status_reason = NULL,
+			updated_at = NOW(),
+			updated_by = $2
+		WHERE id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL
+		RETURNING version
+	`, id, restoredBy).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, user.ErrUserNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	return version, nil
+}
+
+// LogStatusChange records a status change in account_status_changes
+func (r *UserRepository) LogStatusChange(ctx context.Context, c *user.AccountStatusChange) error {
+	err := querierFrom(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO account_status_changes
+			(user_id, old_status, new_status, reason, changed_by, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, c.UserID, c.OldStatus, c.NewStatus, c.Reason, c.ChangedBy, nullString(c.IPAddress),
+	).Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to log account status change: %w", err)
+	}
+	return nil
+}
+
+// FindPurgeable returns up to limit IDs of users deleted before
+// deletedBefore whose data has not been purged yet, oldest first
+func (r *UserRepository) FindPurgeable(ctx context.Context, deletedBefore time.Time, limit int) ([]int, error) {
+	rows, err := querierFrom(ctx, r.db).QueryContext(ctx, `
+		SELECT id FROM users
+		WHERE deleted_at < $1 AND purged_at IS NULL
+		ORDER BY deleted_at
+		LIMIT $2
+	`, deletedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find purgeable users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Purge erases the personal data of a soft-deleted user. The email is
+// replaced by a unique placeholder, which frees the address for a new
+// account; the row stays for the foreign keys of the history tables.
+func (r *UserRepository) Purge(ctx context.Context, id int) error {
+	result, err := querierFrom(ctx, r.db).ExecContext(ctx, `
+		UPDATE users
+		SET email = 'deleted-' || id || '@deleted.invalid',
+			name = 'Deleted user',
+			phone = NULL,
+			avatar_url = NULL,
+			blood_type = NULL,
+			allergies = NULL,
+			emergency_contact_name = NULL,
+			emergency_contact_phone = NULL,
+			status_reason = NULL,
+			purged_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to purge user: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return user.ErrUserNotFound
+	}
+	return nil
+}
+
+// setIfPresent adds column = *value when value is not nil
+func setIfPresent(b *query.Builder, column string, value *string) {
+	if value != nil {
+		b.Set(column+" = ?", *value)
+	}
+}
+
+// userKeyset is the sort whitelist of List
+var userKeyset = &query.Keyset[*user.User]{
+	Sorts: map[string]query.Column[*user.User]{
+		"created_at": {SQL: "created_at", Value: func(u *user.User) any { return u.CreatedAt }},
+		"updated_at": {SQL: "updated_at", Value: func(u *user.User) any { return u.UpdatedAt }},
+		"name":       {SQL: "name", Value: func(u *user.User) any { return u.Name }},
+		"email":      {SQL: "email", Value: func(u *user.User) any { return u.Email }},
+	},
+	DefaultSort:  "created_at",
+	DefaultDesc:  true,
+	Tiebreak:     query.Column[*user.User]{SQL: "id", Value: func(u *user.User) any { return u.ID }},
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+// List returns one page of the users matching f, the cursor of the next
+// page ("" on the last one) and the number of matching users.
+// Invalid sorts and cursors return query.ErrInvalidSort / ErrInvalidCursor.
+func (r *UserRepository) List(ctx context.Context, f user.UserFilter) ([]*user.User, string, int64, error) {
+	page, err := userKeyset.Page(query.PageRequest{
+		Sort:   f.SortBy,
+		Order:  f.SortOrder,
+		Cursor: f.Cursor,
+		Limit:  f.PageSize,
+	})
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	b := query.New()
+	applyUserFilter(b, f)
+
+	var total int64
+	if err := querierFrom(ctx, r.db).QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM users`+b.WhereClause(), b.Args()...,
+	).Scan(&total); err != nil {
+		return nil, "", 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	orderBy := page.Apply(b)
+	rows, err := querierFrom(ctx, r.db).QueryContext(ctx,
+		`SELECT `+userColumns+` FROM users`+b.WhereClause()+orderBy, b.Args()...,
+	)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*user.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", 0, err
+	}
+
+	users, next := page.Trim(users)
+	return users, next, total, nil
+}
+
+// applyUserFilter adds the conditions of f to b
+func applyUserFilter(b *query.Builder, f user.UserFilter) {
+	b.Eq("email", f.Email)
+	b.Eq("role", f.Role)
+	b.Eq("account_status", f.AccountStatus)
+
+	if f.EmailVerified != nil {
+		b.Where("COALESCE(email_verified, FALSE) = ?", *f.EmailVerified)
+	}
+	if f.Search != nil && *f.Search != "" {
+		pattern := "%" + query.EscapeLike(*f.Search) + "%"
+		b.Where("(name ILIKE ? OR email ILIKE ?)", pattern, pattern)
+	}
+	if f.CreatedAfter != nil {
+		b.Where("created_at >= ?", *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		b.Where("created_at < ?", *f.CreatedBefore)
+	}
+	if len(f.Tags) > 0 {
+		b.Where(`EXISTS (
+			SELECT 1 FROM user_tags ut JOIN tags t ON t.id = ut.tag_id
+			WHERE ut.user_id = users.id AND t.name = ANY(?)
+		)`, pq.Array(f.Tags))
+	}
+	if !f.IncludeDeleted {
+		b.Where("deleted_at IS NULL")
+	}
+}
+
+// Search defaults
+const (
+	defaultSearchLimit      = 20
+	maxSearchLimit          = 100
+	defaultSearchSimilarity = 0.3
+)
+
+// Search runs the search_users function: accent-insensitive full-text
+// prefix matches ranked first, then trigram matches for typos
+func (r *UserRepository) Search(ctx context.Context, q user.UserSearchQuery) ([]*user.UserSearchResult, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultSearchLimit
+	}
+	if q.Limit > maxSearchLimit {
+		q.Limit = maxSearchLimit
+	}
+	if q.MinSimilarity <= 0 || q.MinSimilarity > 1 {
+		q.MinSimilarity = defaultSearchSimilarity
+	}
+
+	rows, err := querierFrom(ctx, r.db).QueryContext(ctx, `
+		SELECT id, email, name, role, account_status, match_type, score, name_highlight, email_highlight
+		FROM search_users($1, $2, $3, $4)
+	`, q.Query, q.Limit, q.MinSimilarity, q.AccountStatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*user.UserSearchResult{}
+	for rows.Next() {
+		res := &user.UserSearchResult{}
+		if err := rows.Scan(
+			&res.ID, &res.Email, &res.Name, &res.Role, &res.AccountStatus,
+			&res.MatchType, &res.Score, &res.NameHighlight, &res.EmailHighlight,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		res.NameHighlight = escapeHighlight(res.NameHighlight)
+		res.EmailHighlight = escapeHighlight(res.EmailHighlight)
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// escapeHighlight HTML-escapes a ts_headline result and turns its \x01 and
+// \x02 match delimiters (see migration 0006) into <mark> tags, so names can
+// be rendered as HTML safely
+func escapeHighlight(s string) string {
+	s = html.EscapeString(s)
+	return strings.NewReplacer("\x01", "<mark>", "\x02", "</mark>").Replace(s)
+}
+
+// scanUser scans a row selected with userColumns
+func scanUser(row scanner) (*user.User, error) {
+	u := &user.User{}
+	err := row.Scan(
+		&u.ID, &u.Email, &u.Name, &u.Role, &u.Version,
+		&u.AccountStatus, &u.StatusChangedAt, &u.StatusChangedBy, &u.StatusReason,
+		&u.Phone, &u.AvatarURL,
+		&u.BloodType, &u.Allergies, &u.EmergencyContactName, &u.EmergencyContactPhone,
+		&u.EmailVerified, &u.PhoneVerified,
+		&u.PurgedAt, &u.CreatedAt, &u.UpdatedAt, &u.CreatedBy, &u.UpdatedBy, &u.DeletedAt, &u.DeletedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
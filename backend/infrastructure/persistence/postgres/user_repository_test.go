@@ -0,0 +1,17 @@
+package postgres
+
+import "testing"
+
+func TestEscapeHighlight(t *testing.T) {
+	cases := map[string]string{
+		"\x01Nguyễn\x02 Văn \x01An\x02":             "<mark>Nguyễn</mark> Văn <mark>An</mark>",
+		"\x01Bob\x02 <script>alert(\"x\")</script>": `<mark>Bob</mark> &lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;`,
+		"\x01Eve\x02 <mark>admin</mark>":            "<mark>Eve</mark> &lt;mark&gt;admin&lt;/mark&gt;",
+		"Tom & Jerry":                               "Tom &amp; Jerry",
+	}
+	for in, want := range cases {
+		if got := escapeHighlight(in); got != want {
+			t.Errorf("escapeHighlight(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
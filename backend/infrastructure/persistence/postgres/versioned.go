@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/persistence/query"
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// VERSIONED UPDATES (Optimistic concurrency)
+// ============================================================================
+// Tables with a version column and the bump_row_version trigger (migration
+// 0003) get a new version on every update. A writer sends the version it
+// read; the update only applies while that version is still current, so
+// two editors of the same row cannot silently overwrite each other.
+
+// ErrVersionConflict is returned when the row changed since it was read
+var ErrVersionConflict = errors.New("resource was modified by another request")
+
+// UpdateVersioned applies b's assignments to the single row of table
+// matching b's conditions, if its version is still expected (0 updates
+// unconditionally), and returns the new version. The table must have an
+// id primary key.
+//
+// Returns sql.ErrNoRows when no row matches, and ErrVersionConflict with
+// the current version when expected is stale.
+func UpdateVersioned(ctx context.Context, db *sql.DB, table string, b *query.Builder, expected int) (int, error) {
+	if b.SetClause() == "" {
+		return 0, errors.New("no columns to update")
+	}
+
+	where := b.WhereClause()
+	guard := where
+	if expected > 0 {
+		if guard == "" {
+			guard = " WHERE version = " + b.Arg(expected)
+		} else {
+			guard += " AND version = " + b.Arg(expected)
+		}
+	}
+
+	// One statement finds the row and attempts the update, so a miss is
+	// told apart from a conflict without a second round trip
+	table = pq.QuoteIdentifier(table)
+	q := querierFrom(ctx, db)
+	var id, updated sql.NullInt64
+	err := q.QueryRowContext(ctx, `
+		WITH target AS (
+			SELECT id FROM `+table+where+`
+		), updated AS (
+			UPDATE `+table+b.SetClause()+guard+` RETURNING version
+		)
+		SELECT (SELECT id FROM target), (SELECT version FROM updated)
+	`, b.Args()...).Scan(&id, &updated)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update %s: %w", table, err)
+	}
+
+	switch {
+	case updated.Valid:
+		return int(updated.Int64), nil
+	case !id.Valid:
+		return 0, sql.ErrNoRows
+	}
+
+	// The statement above sees the row as of its start; a writer that
+	// committed while it waited for the row lock has already moved the
+	// version on, so read the current one in a new statement
+	var current int
+	err = q.QueryRowContext(ctx, `SELECT version FROM `+table+` WHERE id = $1`, id.Int64).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, sql.ErrNoRows
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s version: %w", table, err)
+	}
+	return current, ErrVersionConflict
+}
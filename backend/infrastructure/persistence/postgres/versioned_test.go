@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/persistence/query"
+)
+
+// versionRow is a database/sql driver answering the update with one
+// (id, updated) row and the version re-read with latest, and remembering
+// the last update
+type versionRow struct {
+	id, updated, latest any
+	query               string
+	args                []any
+	reread              bool
+}
+
+func (v *versionRow) Connect(context.Context) (driver.Conn, error) { return versionConn{v}, nil }
+func (v *versionRow) Driver() driver.Driver                        { return nil }
+
+type versionConn struct{ v *versionRow }
+
+func (c versionConn) Prepare(string) (driver.Stmt, error) { return nil, errors.ErrUnsupported }
+func (c versionConn) Close() error                        { return nil }
+func (c versionConn) Begin() (driver.Tx, error)           { return nil, errors.ErrUnsupported }
+
+func (c versionConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.Contains(query, "UPDATE") {
+		c.v.reread = true
+		if c.v.latest == nil {
+			return &versionRows{}, nil
+		}
+		return &versionRows{values: []driver.Value{c.v.latest}}, nil
+	}
+
+	c.v.query, c.v.args, c.v.reread = query, nil, false
+	for _, arg := range args {
+		c.v.args = append(c.v.args, arg.Value)
+	}
+	return &versionRows{values: []driver.Value{c.v.id, c.v.updated}}, nil
+}
+
+type versionRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *versionRows) Columns() []string { return make([]string, len(r.values)) }
+func (r *versionRows) Close() error      { return nil }
+func (r *versionRows) Next(dest []driver.Value) error {
+	if r.done || r.values == nil {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func TestUpdateVersioned(t *testing.T) {
+	ctx := context.Background()
+	rows := &versionRow{}
+	db := sql.OpenDB(rows)
+	defer db.Close()
+
+	update := func(expected int) (int, error) {
+		b := query.New().Set("name = ?", "An").Where("id = ?", 7)
+		return UpdateVersioned(ctx, db, "users", b, expected)
+	}
+
+	rows.id, rows.updated = int64(7), int64(4)
+	if v, err := update(3); err != nil || v != 4 || rows.reread {
+		t.Errorf("update = %d, %v (reread %v); want 4, nil", v, err, rows.reread)
+	}
+	if !strings.Contains(rows.query, `UPDATE "users" SET name = $1 WHERE id = $2 AND version = $3`) {
+		t.Errorf("query = %s", rows.query)
+	}
+	if len(rows.args) != 3 || rows.args[2] != int64(3) {
+		t.Errorf("args = %v", rows.args)
+	}
+
+	// A conflict reports the version committed since, not the one the
+	// update statement saw
+	rows.updated, rows.latest = nil, int64(6)
+	if v, err := update(3); !errors.Is(err, ErrVersionConflict) || v != 6 || !rows.reread {
+		t.Errorf("stale update = %d, %v; want 6, ErrVersionConflict", v, err)
+	}
+
+	// Deleted between the update and the re-read
+	rows.latest = nil
+	if _, err := update(3); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleted row = %v, want sql.ErrNoRows", err)
+	}
+
+	rows.id = nil
+	if _, err := update(3); !errors.Is(err, sql.ErrNoRows) || rows.reread {
+		t.Errorf("missing row = %v, want sql.ErrNoRows", err)
+	}
+
+	// Without an expected version the update is unconditional
+	rows.id, rows.updated = int64(7), int64(6)
+	if _, err := update(0); err != nil || strings.Contains(rows.query, "version =") {
+		t.Errorf("unconditional update = %v with %s", err, rows.query)
+	}
+}
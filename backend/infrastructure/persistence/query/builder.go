@@ -0,0 +1,110 @@
+// Package query builds parameterized WHERE clauses, UPDATE assignments
+// and keyset (cursor) pagination for list endpoints. Values always travel
+// as positional arguments; only column names from code or from a sort
+// whitelist are written into the SQL.
+package query
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// BUILDER
+// ============================================================================
+
+// Builder accumulates AND-ed conditions, SET assignments and their
+// arguments. Both use ? for arguments; they are numbered $1, $2, ... in
+// order, so SQL passed in must not use the jsonb ? operator.
+type Builder struct {
+	conds []string
+	sets  []string
+	args  []any
+}
+
+// New creates an empty builder
+func New() *Builder {
+	return &Builder{}
+}
+
+// Where adds a condition with one argument per ?
+func (b *Builder) Where(cond string, args ...any) *Builder {
+	b.conds = append(b.conds, b.bind(cond, args))
+	return b
+}
+
+// Set adds an UPDATE assignment with one argument per ?,
+// e.g. Set("name = ?", name) or Set("updated_at = NOW()")
+func (b *Builder) Set(assignment string, args ...any) *Builder {
+	b.sets = append(b.sets, b.bind(assignment, args))
+	return b
+}
+
+// bind replaces the ? of sql with the placeholders of args
+func (b *Builder) bind(sql string, args []any) string {
+	var sb strings.Builder
+	next := 0
+	for _, r := range sql {
+		if r == '?' && next < len(args) {
+			sb.WriteString(b.Arg(args[next]))
+			next++
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	if next != len(args) {
+		panic("query: argument count does not match placeholders in " + strconv.Quote(sql))
+	}
+	return sb.String()
+}
+
+// Eq adds column = value when value is not nil
+func (b *Builder) Eq(column string, value any) *Builder {
+	if isNil(value) {
+		return b
+	}
+	return b.Where(column+" = ?", value)
+}
+
+// Arg appends an argument and returns its placeholder, for SQL outside
+// the WHERE clause (e.g. LIMIT)
+func (b *Builder) Arg(value any) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// WhereClause returns " WHERE cond AND ..." or "" without conditions
+func (b *Builder) WhereClause() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// SetClause returns " SET a = $1, ..." or "" without assignments
+func (b *Builder) SetClause() string {
+	if len(b.sets) == 0 {
+		return ""
+	}
+	return " SET " + strings.Join(b.sets, ", ")
+}
+
+// Args returns the arguments in placeholder order
+func (b *Builder) Args() []any {
+	return b.args
+}
+
+// EscapeLike escapes LIKE wildcards so s matches literally
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// isNil reports whether v is nil or a nil pointer
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
@@ -0,0 +1,190 @@
+package query
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// KEYSET PAGINATION
+// ============================================================================
+// Instead of OFFSET, a page continues after the sort value and tiebreak of
+// the last row of the previous page:
+//
+//	WHERE (created_at, id) < ($1, $2) ORDER BY created_at DESC, id DESC
+//
+// which stays fast on deep pages and does not skip or repeat rows when rows
+// are inserted meanwhile. The position travels as an opaque cursor.
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort")
+)
+
+// Column is a sortable column and how to read its value from a row.
+// Keyset comparisons need the column to be NOT NULL.
+type Column[T any] struct {
+	SQL   string
+	Value func(row T) any
+}
+
+// Keyset configures cursor pagination over rows of type T
+type Keyset[T any] struct {
+	Sorts        map[string]Column[T] // Allowed sort keys
+	DefaultSort  string
+	DefaultDesc  bool
+	Tiebreak     Column[T] // Unique column making the order total (e.g. id)
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// PageRequest is the pagination part of a list request
+type PageRequest struct {
+	Sort   string // Key of Keyset.Sorts (empty = default)
+	Order  string // "asc", "desc" or empty (default)
+	Cursor string // Empty for the first page
+	Limit  int    // 0 = default, capped at MaxLimit
+}
+
+// Page is a validated PageRequest
+type Page[T any] struct {
+	Limit int
+
+	keyset *Keyset[T]
+	sort   string
+	column Column[T]
+	desc   bool
+	after  []any // sort value and tiebreak of the previous row; nil on the first page
+}
+
+// cursor is the decoded form of an opaque cursor
+type cursor struct {
+	Sort   string `json:"s"`
+	Desc   bool   `json:"d"`
+	Values []any  `json:"v"`
+}
+
+// Page validates req against the whitelist and decodes its cursor.
+// A cursor only continues the sort and order it was created for.
+func (k *Keyset[T]) Page(req PageRequest) (*Page[T], error) {
+	p := &Page[T]{keyset: k, sort: req.Sort, desc: k.DefaultDesc, Limit: req.Limit}
+
+	if p.sort == "" {
+		p.sort = k.DefaultSort
+	}
+	column, ok := k.Sorts[p.sort]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q (allowed: %s)", ErrInvalidSort, req.Sort, strings.Join(k.sortKeys(), ", "))
+	}
+	p.column = column
+
+	switch strings.ToLower(req.Order) {
+	case "":
+	case "asc":
+		p.desc = false
+	case "desc":
+		p.desc = true
+	default:
+		return nil, fmt.Errorf("%w: order must be asc or desc", ErrInvalidSort)
+	}
+
+	if p.Limit <= 0 {
+		p.Limit = k.DefaultLimit
+	}
+	if k.MaxLimit > 0 && p.Limit > k.MaxLimit {
+		p.Limit = k.MaxLimit
+	}
+
+	if req.Cursor != "" {
+		c, err := decodeCursor(req.Cursor)
+		if err != nil || c.Sort != p.sort || c.Desc != p.desc || len(c.Values) != 2 {
+			return nil, ErrInvalidCursor
+		}
+		for _, v := range c.Values {
+			switch v.(type) {
+			case string, json.Number:
+			default:
+				return nil, ErrInvalidCursor
+			}
+		}
+		p.after = c.Values
+	}
+
+	return p, nil
+}
+
+// Apply adds the cursor condition to b and returns the ORDER BY and LIMIT
+// clause to append after the WHERE clause. One extra row is fetched to
+// tell whether another page follows.
+func (p *Page[T]) Apply(b *Builder) string {
+	direction, cmp := "ASC", ">"
+	if p.desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	if p.after != nil {
+		b.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", p.column.SQL, p.keyset.Tiebreak.SQL, cmp), p.after...)
+	}
+
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s LIMIT %s",
+		p.column.SQL, direction, p.keyset.Tiebreak.SQL, direction, b.Arg(p.Limit+1))
+}
+
+// Trim drops the extra row fetched by Apply and returns the cursor of the
+// next page, or "" on the last page
+func (p *Page[T]) Trim(rows []T) ([]T, string) {
+	if len(rows) <= p.Limit {
+		return rows, ""
+	}
+	rows = rows[:p.Limit]
+	last := rows[len(rows)-1]
+
+	next, err := encodeCursor(cursor{
+		Sort:   p.sort,
+		Desc:   p.desc,
+		Values: []any{p.column.Value(last), p.keyset.Tiebreak.Value(last)},
+	})
+	if err != nil {
+		// Sort values are plain JSON types; failing here is a programming error
+		panic(fmt.Sprintf("query: cannot encode cursor: %v", err))
+	}
+	return rows, next
+}
+
+// sortKeys lists the allowed sort keys
+func (k *Keyset[T]) sortKeys() []string {
+	keys := make([]string, 0, len(k.Sorts))
+	for key := range k.Sorts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// encodeCursor serializes c as URL-safe base64 JSON
+func encodeCursor(c cursor) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor parses an opaque cursor. Numbers stay json.Number so large
+// IDs keep their precision when sent back as arguments.
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err = dec.Decode(&c)
+	return c, err
+}
@@ -0,0 +1,105 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuilder(t *testing.T) {
+	type role string
+	var noRole *role
+	admin := role("admin")
+
+	b := New().
+		Eq("role", noRole).
+		Eq("role", &admin).
+		Where("(name ILIKE ? OR email ILIKE ?)", "%a%", "%a%").
+		Where("deleted_at IS NULL")
+	limit := b.Arg(20)
+
+	want := " WHERE role = $1 AND (name ILIKE $2 OR email ILIKE $3) AND deleted_at IS NULL"
+	if got := b.WhereClause(); got != want {
+		t.Errorf("WhereClause = %q, want %q", got, want)
+	}
+	if limit != "$4" || len(b.Args()) != 4 {
+		t.Errorf("limit placeholder = %s with %d args", limit, len(b.Args()))
+	}
+	if New().WhereClause() != "" {
+		t.Error("empty builder has a WHERE clause")
+	}
+	if got := EscapeLike(`50%_off\`); got != `50\%\_off\\` {
+		t.Errorf("EscapeLike = %s", got)
+	}
+}
+
+type row struct {
+	ID        int
+	CreatedAt time.Time
+}
+
+var rows = &Keyset[row]{
+	Sorts: map[string]Column[row]{
+		"created_at": {SQL: "created_at", Value: func(r row) any { return r.CreatedAt }},
+	},
+	DefaultSort:  "created_at",
+	DefaultDesc:  true,
+	Tiebreak:     Column[row]{SQL: "id", Value: func(r row) any { return r.ID }},
+	DefaultLimit: 2,
+	MaxLimit:     10,
+}
+
+func TestKeyset(t *testing.T) {
+	first, err := rows.Page(PageRequest{Limit: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Limit != 10 {
+		t.Errorf("limit = %d, want capped at 10", first.Limit)
+	}
+
+	b := New()
+	if got := first.Apply(b); got != " ORDER BY created_at DESC, id DESC LIMIT $1" || b.WhereClause() != "" {
+		t.Errorf("first page = %q %q", b.WhereClause(), got)
+	}
+
+	// Two rows per page; three fetched means there is a next page
+	page, _ := rows.Page(PageRequest{})
+	now := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	items, next := page.Trim([]row{{3, now}, {2, now}, {1, now}})
+	if len(items) != 2 || next == "" {
+		t.Fatalf("Trim = %d rows, cursor %q", len(items), next)
+	}
+	if _, last := page.Trim(items); last != "" {
+		t.Error("cursor on the last page")
+	}
+
+	second, err := rows.Page(PageRequest{Cursor: next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = New().Where("deleted_at IS NULL")
+	second.Apply(b)
+	if got := b.WhereClause(); got != " WHERE deleted_at IS NULL AND (created_at, id) < ($1, $2)" {
+		t.Errorf("second page = %q", got)
+	}
+	if got := b.Args(); !reflect.DeepEqual(got, []any{now.Format(time.RFC3339Nano), json.Number("2"), 3}) {
+		t.Errorf("args = %#v", got)
+	}
+
+	// Cursors only continue their own sort order, and must be well-formed
+	if _, err := rows.Page(PageRequest{Cursor: next, Order: "asc"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor with another order = %v", err)
+	}
+	tampered, _ := encodeCursor(cursor{Sort: "created_at", Desc: true, Values: []any{map[string]any{}, 1}})
+	for _, c := range []string{"not base64!", tampered} {
+		if _, err := rows.Page(PageRequest{Cursor: c}); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("cursor %q = %v, want ErrInvalidCursor", c, err)
+		}
+	}
+	if _, err := rows.Page(PageRequest{Sort: "password_hash"}); !errors.Is(err, ErrInvalidSort) {
+		t.Errorf("unknown sort = %v, want ErrInvalidSort", err)
+	}
+}
@@ -0,0 +1,170 @@
+package ratelimit
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// AUDIT LOG
+// ============================================================================
+// Redis keys expire, so blocks and admin interventions are also written to
+// rate_limit_audit to answer "who got blocked and why" after an incident.
+// Writes go through a bounded queue drained by one background writer so a
+// burst of blocks never slows requests down or floods the database.
+
+// AuditEventType is the kind of audited limiter event
+type AuditEventType string
+
+const (
+	AuditBlocked     AuditEventType = "blocked"      // RecordAttempt started a block
+	AuditManualBlock AuditEventType = "manual_block" // Admin blocked an identifier
+	AuditUnblock     AuditEventType = "unblock"      // Admin lifted a block
+	AuditReset       AuditEventType = "reset"        // Admin reset a counter
+	AuditPardon      AuditEventType = "pardon"       // Admin cleared offenses and the denylist entry
+)
+
+// DefaultAuditBuffer is the queue size of an AuditLog
+const DefaultAuditBuffer = 1024
+
+// auditDroppedCounter counts events dropped because the queue was full (exported via expvar)
+var auditDroppedCounter = expvar.NewInt("ratelimit_audit_dropped")
+
+// AuditEvent is an audited block or admin intervention
+type AuditEvent struct {
+	ID           int64          `json:"id"`
+	EventType    AuditEventType `json:"event_type"`
+	Identifier   string         `json:"identifier"`
+	Action       string         `json:"action"`
+	Count        int            `json:"count"`                   // Attempts in the window when blocked
+	MaxAttempts  int            `json:"max_attempts"`            // Limit of the rule that blocked
+	BlockedUntil *time.Time     `json:"blocked_until,omitempty"` // Blocks only
+	ActorID      *int           `json:"actor_id,omitempty"`      // Admin user (nil = limiter)
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// AuditFilter selects audit events (zero values match everything)
+type AuditFilter struct {
+	Action     string
+	Identifier string
+	Since      *time.Time
+	Until      *time.Time
+	Page       int
+	Limit      int
+}
+
+// AuditSink records audit events
+type AuditSink interface {
+	RecordAudit(ctx context.Context, event *AuditEvent) error
+}
+
+// AuditStore persists and queries audit events
+type AuditStore interface {
+	AuditSink
+
+	// ListAudit returns one page of events, newest first, and the total match count
+	ListAudit(ctx context.Context, filter AuditFilter) ([]*AuditEvent, int, error)
+}
+
+// AuditLog writes audit events to a store asynchronously
+type AuditLog struct {
+	store AuditStore
+
+	mu     sync.RWMutex
+	queue  chan *AuditEvent
+	closed bool
+	done   chan struct{}
+}
+
+// NewAuditLog creates an audit log with a queue of buffer events
+// and starts its background writer
+func NewAuditLog(store AuditStore, buffer int) *AuditLog {
+	if buffer <= 0 {
+		buffer = DefaultAuditBuffer
+	}
+
+	a := &AuditLog{
+		store: store,
+		queue: make(chan *AuditEvent, buffer),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+
+	return a
+}
+
+// RecordAudit queues event without blocking. Events are dropped (and
+// counted) when the queue is full.
+func (a *AuditLog) RecordAudit(ctx context.Context, event *AuditEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		auditDroppedCounter.Add(1)
+		return nil
+	}
+
+	select {
+	case a.queue <- event:
+	default:
+		auditDroppedCounter.Add(1)
+		log.Printf("⚠️  Rate limit audit queue full, dropped %s event for %s", event.EventType, event.Identifier)
+	}
+
+	return nil
+}
+
+// ListAudit returns one page of audit events from the store
+func (a *AuditLog) ListAudit(ctx context.Context, filter AuditFilter) ([]*AuditEvent, int, error) {
+	return a.store.ListAudit(ctx, filter)
+}
+
+// Close stops accepting events and waits until queued events are written
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	<-a.done
+	return nil
+}
+
+// run writes queued events until the queue is closed
+func (a *AuditLog) run() {
+	defer close(a.done)
+
+	for event := range a.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := a.store.RecordAudit(ctx, event); err != nil {
+			log.Printf("⚠️  Failed to record rate limit audit event: %v", err)
+		}
+		cancel()
+	}
+}
+
+// reportBlock records the block RecordAttempt started
+func reportBlock(sink AuditSink, identifier string, rule *RateLimitRule, count int, until, now time.Time) {
+	if sink == nil {
+		return
+	}
+
+	sink.RecordAudit(context.Background(), &AuditEvent{
+		EventType:    AuditBlocked,
+		Identifier:   identifier,
+		Action:       rule.Action,
+		Count:        count,
+		MaxAttempts:  rule.MaxAttempts,
+		BlockedUntil: &until,
+		CreatedAt:    now,
+	})
+}
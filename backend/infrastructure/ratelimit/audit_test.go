@@ -0,0 +1,78 @@
+package ratelimit_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
+)
+
+// auditStore keeps audit events in memory
+type auditStore struct {
+	mu     sync.Mutex
+	events []*ratelimit.AuditEvent
+}
+
+func (s *auditStore) RecordAudit(ctx context.Context, event *ratelimit.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *auditStore) ListAudit(ctx context.Context, filter ratelimit.AuditFilter) ([]*ratelimit.AuditEvent, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.events, len(s.events), nil
+}
+
+func TestAuditLogRecordsBlocks(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Unix(1_700_000_000, 0))
+	store := &auditStore{}
+	audit := ratelimit.NewAuditLog(store, 0)
+
+	limiter := ratelimit.NewMemoryLimiter([]*ratelimit.RateLimitRule{{
+		Action:        "login",
+		MaxAttempts:   2,
+		WindowSize:    time.Minute,
+		BlockDuration: 5 * time.Minute,
+		IsActive:      true,
+	}}, clk)
+	t.Cleanup(func() { limiter.Close() })
+	limiter.SetAuditSink(audit)
+
+	// Only the attempt that starts the block is audited
+	for i := 0; i < 4; i++ {
+		if _, err := limiter.RecordAttempt(ctx, "ip:1.1.1.1", "login"); err != nil {
+			t.Fatalf("RecordAttempt: %v", err)
+		}
+	}
+
+	// Close flushes the queue and drops later events
+	audit.Close()
+	audit.RecordAudit(ctx, &ratelimit.AuditEvent{EventType: ratelimit.AuditReset})
+
+	events, total, _ := audit.ListAudit(ctx, ratelimit.AuditFilter{})
+	if total != 1 {
+		t.Fatalf("audited %d events, want 1: %+v", total, events)
+	}
+
+	event := events[0]
+	wantUntil := clk.Now().Add(5 * time.Minute)
+	if event.EventType != ratelimit.AuditBlocked || event.Identifier != "ip:1.1.1.1" ||
+		event.Action != "login" || event.Count != 2 || event.MaxAttempts != 2 || event.ActorID != nil {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event.BlockedUntil == nil || !event.BlockedUntil.Equal(wantUntil) {
+		t.Errorf("BlockedUntil = %v, want %v", event.BlockedUntil, wantUntil)
+	}
+	if !event.CreatedAt.Equal(clk.Now()) {
+		t.Errorf("CreatedAt = %v, want %v", event.CreatedAt, clk.Now())
+	}
+}
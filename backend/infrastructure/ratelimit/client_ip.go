@@ -0,0 +1,216 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ============================================================================
+// CLIENT IP RESOLUTION
+// ============================================================================
+// Behind a load balancer the socket address is the proxy's, so the client IP
+// is read from the proxy header. Only hops added by trusted proxies are
+// believed: the chain is walked right to left and the first untrusted
+// address is the client.
+//
+// IPv6 clients usually control a whole /64, so they are bucketed by prefix
+// to stop them from rotating addresses to dodge limits.
+
+const (
+	ProxyHeaderXForwardedFor = "X-Forwarded-For"
+	ProxyHeaderForwarded     = "Forwarded"
+
+	DefaultIPv6PrefixLength = 64
+)
+
+// ClientIPResolver derives the real client IP from trusted proxy headers
+type ClientIPResolver struct {
+	trusted    []*net.IPNet
+	header     string
+	ipv6Prefix int
+}
+
+// NewClientIPResolver creates a resolver trusting the given proxies
+// trustedProxies accepts CIDRs ("10.0.0.0/8") and bare IPs ("10.1.2.3").
+// header is ProxyHeaderXForwardedFor or ProxyHeaderForwarded.
+func NewClientIPResolver(trustedProxies []string, header string, ipv6Prefix int) (*ClientIPResolver, error) {
+	trusted, err := ParseCIDRs(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxy: %w", err)
+	}
+
+	switch {
+	case strings.EqualFold(header, ProxyHeaderForwarded):
+		header = ProxyHeaderForwarded
+	case header == "" || strings.EqualFold(header, ProxyHeaderXForwardedFor):
+		header = ProxyHeaderXForwardedFor
+	default:
+		return nil, fmt.Errorf("unsupported proxy header: %s", header)
+	}
+
+	if ipv6Prefix <= 0 || ipv6Prefix > 128 {
+		ipv6Prefix = DefaultIPv6PrefixLength
+	}
+
+	return &ClientIPResolver{
+		trusted:    trusted,
+		header:     header,
+		ipv6Prefix: ipv6Prefix,
+	}, nil
+}
+
+// Header returns the proxy header the resolver reads
+func (r *ClientIPResolver) Header() string {
+	return r.header
+}
+
+// Resolve returns the client IP for a request from remote with the given
+// proxy header values (one entry per header line)
+func (r *ClientIPResolver) Resolve(remote net.IP, headerValues ...string) net.IP {
+	if remote == nil || !r.isTrusted(remote) {
+		return remote
+	}
+
+	var chain []net.IP
+	for _, value := range headerValues {
+		if r.header == ProxyHeaderForwarded {
+			chain = append(chain, parseForwarded(value)...)
+		} else {
+			chain = append(chain, parseXForwardedFor(value)...)
+		}
+	}
+
+	// Walk right to left: the first hop we don't trust is the client
+	client := remote
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i] == nil {
+			// Unparseable hop (e.g. "unknown"), stop at the last good address
+			break
+		}
+		client = chain[i]
+		if !r.isTrusted(client) {
+			break
+		}
+	}
+
+	return client
+}
+
+// Bucket returns the rate limit bucket of ip (IPv6 masked to the prefix)
+func (r *ClientIPResolver) Bucket(ip net.IP) string {
+	return BucketIP(ip, r.ipv6Prefix)
+}
+
+// isTrusted checks if ip belongs to a trusted proxy
+func (r *ClientIPResolver) isTrusted(ip net.IP) bool {
+	for _, network := range r.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// BucketIP returns the identifier value for ip: IPv4 addresses as-is,
+// IPv6 addresses masked to prefix bits (e.g. "2001:db8:1:2::/64")
+func BucketIP(ip net.IP, prefix int) string {
+	if ip == nil {
+		return ""
+	}
+
+	if v4 := ip.To4(); v4 != nil {
+		return v4.String()
+	}
+
+	if prefix <= 0 || prefix >= 128 {
+		return ip.String()
+	}
+
+	mask := net.CIDRMask(prefix, 128)
+	return fmt.Sprintf("%s/%d", ip.Mask(mask).String(), prefix)
+}
+
+// ParseCIDRs parses CIDRs and bare IPs (treated as /32 or /128)
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		network, err := ParseCIDR(value)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// ParseCIDR parses a CIDR or a bare IP (treated as /32 or /128)
+func ParseCIDR(value string) (*net.IPNet, error) {
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address: %s", value)
+		}
+		if v4 := ip.To4(); v4 != nil {
+			return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR: %s", value)
+	}
+	return network, nil
+}
+
+// parseXForwardedFor parses "client, proxy1, proxy2"
+func parseXForwardedFor(value string) []net.IP {
+	var ips []net.IP
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		ips = append(ips, parseHost(part))
+	}
+	return ips
+}
+
+// parseForwarded parses the for= parameters of an RFC 7239 Forwarded header
+//
+//	Forwarded: for=192.0.2.60;proto=https, for="[2001:db8::1]:4711"
+func parseForwarded(value string) []net.IP {
+	var ips []net.IP
+	for _, element := range strings.Split(value, ",") {
+		for _, pair := range strings.Split(element, ";") {
+			key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if !found || !strings.EqualFold(key, "for") {
+				continue
+			}
+			ips = append(ips, parseHost(strings.Trim(val, `"`)))
+		}
+	}
+	return ips
+}
+
+// parseHost parses an address with optional port and IPv6 brackets
+// Returns nil for obfuscated or unknown nodes
+func parseHost(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return net.ParseIP(strings.Trim(host, "[]"))
+}
@@ -0,0 +1,273 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// CONCURRENCY LIMITS (in-flight requests per identifier)
+// ============================================================================
+// Rate limits count events over time; concurrency limits cap how many
+// requests of an identifier run at the same time (e.g. 2 uploads at once).
+// Each running request holds a lease in a Redis sorted set scored by its
+// expiry, so leases of a crashed instance free themselves after LeaseTTL.
+
+const (
+	ActionConcurrentUpload = "upload_concurrent"
+	ActionDataExport       = "data_export"
+
+	// DefaultLeaseTTL is how long a lease lives without being refreshed
+	DefaultLeaseTTL = 1 * time.Minute
+)
+
+const (
+	// luaAcquireLease drops expired leases and adds a new one if a slot is free
+	// Returns {acquired (0/1), in-flight count}
+	luaAcquireLease = `
+		local key = KEYS[1]
+		local now = tonumber(ARGV[1])
+		local ttl = tonumber(ARGV[2])
+		local max = tonumber(ARGV[3])
+		local id = ARGV[4]
+
+		redis.call("ZREMRANGEBYSCORE", key, "-inf", now)
+		local count = redis.call("ZCARD", key)
+		if count >= max then
+			return {0, count}
+		end
+
+		redis.call("ZADD", key, now + ttl, id)
+		redis.call("PEXPIRE", key, ttl)
+
+		return {1, count + 1}
+	`
+
+	// luaRefreshLease extends a lease that is still held (expired leases stay expired)
+	luaRefreshLease = `
+		local key = KEYS[1]
+		local now = tonumber(ARGV[1])
+		local ttl = tonumber(ARGV[2])
+		local id = ARGV[3]
+
+		local expires = redis.call("ZSCORE", key, id)
+		if not expires or tonumber(expires) <= now then
+			return 0
+		end
+
+		redis.call("ZADD", key, "XX", now + ttl, id)
+		if redis.call("PTTL", key) < ttl then
+			redis.call("PEXPIRE", key, ttl)
+		end
+
+		return 1
+	`
+)
+
+// ConcurrencyRule caps in-flight requests of an identifier for an action
+type ConcurrencyRule struct {
+	Action        string        `json:"action"`
+	MaxConcurrent int           `json:"max_concurrent"`
+	LeaseTTL      time.Duration `json:"-"` // Leases expire after this without a refresh
+}
+
+// Lease is a held concurrency slot, release it when the request finishes
+type Lease struct {
+	ID         string
+	Identifier string
+	Action     string
+	ttl        time.Duration
+}
+
+// ConcurrencyStatus is the result of an acquire attempt
+type ConcurrencyStatus struct {
+	Identifier    string `json:"identifier"`
+	Action        string `json:"action"`
+	InFlight      int    `json:"in_flight"`
+	MaxConcurrent int    `json:"max_concurrent"` // -1 = unlimited
+	Acquired      bool   `json:"acquired"`
+}
+
+// ConcurrencyLimiter implements concurrency limits with Redis leases
+type ConcurrencyLimiter struct {
+	client redis.UniversalClient
+
+	mu    sync.RWMutex
+	rules map[string]*ConcurrencyRule
+	clock clock.Clock
+
+	scriptAcquire *redis.Script
+	scriptRefresh *redis.Script
+}
+
+// NewConcurrencyLimiter creates a new Redis-backed concurrency limiter
+// clk is the time source for lease expiry (nil = clock.System)
+func NewConcurrencyLimiter(client redis.UniversalClient, rules []*ConcurrencyRule, clk clock.Clock) *ConcurrencyLimiter {
+	limiter := &ConcurrencyLimiter{
+		client:        client,
+		rules:         make(map[string]*ConcurrencyRule),
+		clock:         clock.OrSystem(clk),
+		scriptAcquire: redis.NewScript(luaAcquireLease),
+		scriptRefresh: redis.NewScript(luaRefreshLease),
+	}
+
+	for _, rule := range rules {
+		limiter.AddRule(rule)
+	}
+
+	return limiter
+}
+
+// AddRule adds or updates a concurrency rule (thread-safe)
+func (l *ConcurrencyLimiter) AddRule(rule *ConcurrencyRule) {
+	if rule.LeaseTTL <= 0 {
+		rule.LeaseTTL = DefaultLeaseTTL
+	}
+
+	l.mu.Lock()
+	l.rules[rule.Action] = rule
+	l.mu.Unlock()
+}
+
+// GetRule gets a concurrency rule (thread-safe)
+func (l *ConcurrencyLimiter) GetRule(action string) (*ConcurrencyRule, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	rule, exists := l.rules[action]
+	return rule, exists
+}
+
+// Acquire takes a slot for identifier. The lease is nil when no slot is free
+// or the action has no rule (status.Acquired tells them apart).
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, identifier, action string) (*Lease, *ConcurrencyStatus, error) {
+	rule, exists := l.GetRule(action)
+	if !exists || rule.MaxConcurrent <= 0 {
+		return nil, &ConcurrencyStatus{
+			Identifier:    identifier,
+			Action:        action,
+			MaxConcurrent: -1,
+			Acquired:      true,
+		}, nil
+	}
+
+	id, err := newLeaseID()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := l.scriptAcquire.Run(ctx, l.client,
+		[]string{l.makeKey(identifier, action)},
+		l.clock.Now().UnixMilli(),
+		rule.LeaseTTL.Milliseconds(),
+		rule.MaxConcurrent,
+		id,
+	).Int64Slice()
+	if err != nil {
+		return nil, nil, fmt.Errorf("redis acquire script failed: %w", err)
+	}
+	if len(result) != 2 {
+		return nil, nil, fmt.Errorf("unexpected script result format")
+	}
+
+	status := &ConcurrencyStatus{
+		Identifier:    identifier,
+		Action:        action,
+		InFlight:      int(result[1]),
+		MaxConcurrent: rule.MaxConcurrent,
+		Acquired:      result[0] == 1,
+	}
+	if !status.Acquired {
+		return nil, status, nil
+	}
+
+	return &Lease{ID: id, Identifier: identifier, Action: action, ttl: rule.LeaseTTL}, status, nil
+}
+
+// Release frees the slot held by lease (nil leases are ignored)
+func (l *ConcurrencyLimiter) Release(ctx context.Context, lease *Lease) error {
+	if lease == nil {
+		return nil
+	}
+	return l.client.ZRem(ctx, l.makeKey(lease.Identifier, lease.Action), lease.ID).Err()
+}
+
+// Refresh extends lease by its TTL. Returns false if the lease already expired.
+func (l *ConcurrencyLimiter) Refresh(ctx context.Context, lease *Lease) (bool, error) {
+	if lease == nil {
+		return false, nil
+	}
+
+	refreshed, err := l.scriptRefresh.Run(ctx, l.client,
+		[]string{l.makeKey(lease.Identifier, lease.Action)},
+		l.clock.Now().UnixMilli(),
+		lease.ttl.Milliseconds(),
+		lease.ID,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("redis refresh script failed: %w", err)
+	}
+
+	return refreshed == 1, nil
+}
+
+// KeepAlive refreshes lease in the background until the returned stop func
+// is called, so requests running longer than the lease TTL keep their slot
+func (l *ConcurrencyLimiter) KeepAlive(lease *Lease) (stop func()) {
+	if lease == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(lease.ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				ok, err := l.Refresh(ctx, lease)
+				cancel()
+				if err == nil && !ok {
+					// Lease is gone (expired or cleared), nothing left to refresh
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// InFlight returns how many leases identifier currently holds for action
+func (l *ConcurrencyLimiter) InFlight(ctx context.Context, identifier, action string) (int, error) {
+	count, err := l.client.ZCount(ctx, l.makeKey(identifier, action),
+		fmt.Sprintf("(%d", l.clock.Now().UnixMilli()), "+inf",
+	).Result()
+	return int(count), err
+}
+
+// makeKey generates Redis key for the leases of identifier
+func (l *ConcurrencyLimiter) makeKey(identifier, action string) string {
+	return fmt.Sprintf("rl:sem:%s:%s", action, slotTag(identifier))
+}
+
+// newLeaseID generates a random lease ID
+func newLeaseID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lease id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,73 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestConcurrencyLimiterLeases(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	clk := clock.NewFake(time.Unix(1_700_000_000, 0))
+
+	limiter := ratelimit.NewConcurrencyLimiter(
+		redis.NewClient(&redis.Options{Addr: server.Addr()}),
+		[]*ratelimit.ConcurrencyRule{{Action: "upload", MaxConcurrent: 2, LeaseTTL: time.Minute}},
+		clk,
+	)
+
+	acquire := func() (*ratelimit.Lease, *ratelimit.ConcurrencyStatus) {
+		t.Helper()
+		lease, status, err := limiter.Acquire(ctx, "user:1", "upload")
+		if err != nil {
+			t.Fatalf("Acquire: %v", err)
+		}
+		return lease, status
+	}
+
+	first, _ := acquire()
+	second, status := acquire()
+	if first == nil || second == nil || status.InFlight != 2 {
+		t.Fatalf("want 2 leases, got %v %v (in flight %d)", first, second, status.InFlight)
+	}
+
+	if lease, status := acquire(); lease != nil || status.Acquired {
+		t.Fatalf("acquired a third lease over the cap")
+	}
+
+	// Releasing frees the slot immediately
+	if err := limiter.Release(ctx, first); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	third, _ := acquire()
+	if third == nil {
+		t.Fatal("slot not freed by Release")
+	}
+
+	// A refreshed lease survives past its original expiry, the other one lapses
+	clk.Advance(40 * time.Second)
+	if ok, err := limiter.Refresh(ctx, third); err != nil || !ok {
+		t.Fatalf("Refresh = %t, %v", ok, err)
+	}
+	clk.Advance(30 * time.Second)
+
+	inFlight, err := limiter.InFlight(ctx, "user:1", "upload")
+	if err != nil {
+		t.Fatalf("InFlight: %v", err)
+	}
+	if inFlight != 1 {
+		t.Errorf("InFlight = %d, want 1 (expired lease still counted)", inFlight)
+	}
+	if ok, _ := limiter.Refresh(ctx, second); ok {
+		t.Error("refreshed an expired lease")
+	}
+	if lease, _ := acquire(); lease == nil {
+		t.Error("expired lease still holds a slot")
+	}
+}
@@ -94,6 +94,9 @@ func (c *Config) Validate() error {
 		if rule.BlockDurationSeconds < 0 {
 			return fmt.Errorf("rule %s: block_duration cannot be negative", rule.Action)
 		}
+		if rule.Mode != "" && !rule.Mode.IsValid() {
+			return fmt.Errorf("rule %s: invalid mode %q", rule.Action, rule.Mode)
+		}
 	}
 
 	return nil
@@ -124,6 +127,7 @@ func DefaultRules() []*RateLimitRule {
 			WindowSizeSeconds:    300,  // 5 minutes
 			BlockDurationSeconds: 1800, // 30 minutes
 			IsActive:             true,
+			Escalate:             true,
 		},
 		{
 			Action:               ActionPasswordReset,
@@ -131,6 +135,7 @@ func DefaultRules() []*RateLimitRule {
 			WindowSizeSeconds:    3600, // 1 hour
 			BlockDurationSeconds: 3600, // 1 hour
 			IsActive:             true,
+			Escalate:             true,
 		},
 		{
 			Action:               ActionEmailVerify,
@@ -152,6 +157,7 @@ func DefaultRules() []*RateLimitRule {
 			WindowSizeSeconds:    3600, // 1 hour
 			BlockDurationSeconds: 7200, // 2 hours
 			IsActive:             true,
+			Escalate:             true,
 		},
 		{
 			Action:               ActionOTPRequest,
@@ -159,6 +165,7 @@ func DefaultRules() []*RateLimitRule {
 			WindowSizeSeconds:    300, // 5 minutes
 			BlockDurationSeconds: 600, // 10 minutes
 			IsActive:             true,
+			Escalate:             true,
 		},
 	}
 
@@ -354,6 +361,20 @@ func (b *RuleBuilder) Enabled() *RuleBuilder {
 	return b
 }
 
+// Shadow makes the rule count attempts and log would-be blocks without blocking
+// Use it to try out a new or tighter rule in production
+func (b *RuleBuilder) Shadow() *RuleBuilder {
+	b.rule.Mode = ModeShadow
+	return b
+}
+
+// Escalating makes repeat blocks for the same identifier grow longer
+// See EscalationPolicy
+func (b *RuleBuilder) Escalating() *RuleBuilder {
+	b.rule.Escalate = true
+	return b
+}
+
 // Build returns the constructed rule
 // It automatically calls LoadDurations() to ensure Duration fields are set
 func (b *RuleBuilder) Build() *RateLimitRule {
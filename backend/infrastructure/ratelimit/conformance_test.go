@@ -0,0 +1,37 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit/limitertest"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMemoryLimiterConformance(t *testing.T) {
+	limitertest.Run(t, func(t *testing.T, clk clock.Clock, rules []*ratelimit.RateLimitRule) limitertest.Target {
+		limiter := ratelimit.NewMemoryLimiter(rules, clk)
+		t.Cleanup(func() { limiter.Close() })
+
+		return limitertest.Target{Limiter: limiter}
+	})
+}
+
+func TestRedisLimiterConformance(t *testing.T) {
+	limitertest.Run(t, func(t *testing.T, clk clock.Clock, rules []*ratelimit.RateLimitRule) limitertest.Target {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+		limiter := ratelimit.NewRedisLimiter(client, rules, clk)
+		t.Cleanup(func() { limiter.Close() })
+
+		return limitertest.Target{
+			Limiter: limiter,
+			// Key TTLs follow the miniredis clock
+			Advance: func(d time.Duration) { server.FastForward(d) },
+		}
+	})
+}
@@ -5,9 +5,11 @@ import (
 	"crypto/sha1"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -15,17 +17,23 @@ import (
 // REDIS LUA SCRIPTS
 // ============================================================================
 // Using Lua scripts ensures atomic operations and better performance
+//
+// Cluster safety: every key of an identifier (counter, block, shadow block,
+// denylist, offenses) carries the identifier hash tag, so scripts and MGETs
+// touching several of them stay within one slot. Index keys (rl:i:*) are
+// only written through pipelines, which the cluster client splits per slot.
 
 const (
-	// luaIncrWithExpire atomically increments counter and sets expiry
-	// This prevents race condition between INCR and EXPIRE
+	// luaIncrWithExpire atomically increments counter by cost and sets expiry
+	// This prevents race condition between INCRBY and EXPIRE
 	luaIncrWithExpire = `
 		local key = KEYS[1]
 		local window = tonumber(ARGV[1])
+		local cost = tonumber(ARGV[2])
 		
-		local count = redis.call("INCR", key)
+		local count = redis.call("INCRBY", key, cost)
 		
-		if count == 1 then
+		if count == cost then
 			redis.call("EXPIRE", key, window)
 		end
 		
@@ -37,12 +45,45 @@ const (
 	luaGetMulti = `
 		local counterKey = KEYS[1]
 		local blockKey = KEYS[2]
+		local denyKey = KEYS[3]
 		
 		local count = redis.call("GET", counterKey)
 		local ttl = redis.call("TTL", counterKey)
 		local blockVal = redis.call("GET", blockKey)
+		local denyVal = redis.call("GET", denyKey)
 		
-		return {count or "0", tostring(ttl), blockVal or ""}
+		return {count or "0", tostring(ttl), blockVal or "", denyVal or ""}
+	`
+
+	// luaRecordOffense decays the offense count, adds one offense and
+	// refreshes the expiry so fully decayed records disappear
+	// Must stay in sync with decayOffenses
+	luaRecordOffense = `
+		local key = KEYS[1]
+		local now = tonumber(ARGV[1])
+		local decay = tonumber(ARGV[2])
+		local fallbackTTL = tonumber(ARGV[3])
+		
+		local count = tonumber(redis.call("HGET", key, "count") or "0")
+		local last = tonumber(redis.call("HGET", key, "last") or "0")
+		
+		if count > 0 and decay > 0 and now > last then
+			count = count - math.floor((now - last) / decay)
+			if count < 0 then
+				count = 0
+			end
+		end
+		
+		count = count + 1
+		redis.call("HSET", key, "count", count, "last", now)
+		
+		if decay > 0 then
+			redis.call("EXPIRE", key, count * decay)
+		else
+			redis.call("EXPIRE", key, fallbackTTL)
+		end
+		
+		return count
 	`
 )
 
@@ -53,24 +94,43 @@ const (
 // RedisLimiter implements Limiter using Redis
 // Thread-safe and production-ready with atomic operations
 type RedisLimiter struct {
-	client *redis.Client
+	client redis.UniversalClient
 
 	// Use sync.RWMutex for thread-safe rule access
-	mu    sync.RWMutex
-	rules map[string]*RateLimitRule
+	mu        sync.RWMutex
+	rules     map[string]*RateLimitRule
+	overrides map[string]*RateLimitRule // key: "identifier|action"
+
+	// Repeat offender tracking (nil escalation = constant block durations)
+	escalation *EscalationPolicy
+	events     SecurityEventSink
+
+	// Where would-be blocks of shadow rules are logged
+	shadowSink ShadowSink
+
+	// Where blocks are audited (nil = not audited)
+	audit AuditSink
+
+	// Time source (clock.System unless a test injects a fake)
+	clock clock.Clock
 
 	// Lua scripts (preloaded for better performance)
-	scriptIncr *redis.Script
-	scriptGet  *redis.Script
+	scriptIncr    *redis.Script
+	scriptGet     *redis.Script
+	scriptOffense *redis.Script
 }
 
 // NewRedisLimiter creates a new Redis-backed rate limiter
-func NewRedisLimiter(client *redis.Client, rules []*RateLimitRule) *RedisLimiter {
+// clk is the time source (nil = clock.System); Redis TTLs follow the server clock
+func NewRedisLimiter(client redis.UniversalClient, rules []*RateLimitRule, clk clock.Clock) *RedisLimiter {
 	limiter := &RedisLimiter{
-		client:     client,
-		rules:      make(map[string]*RateLimitRule),
-		scriptIncr: redis.NewScript(luaIncrWithExpire),
-		scriptGet:  redis.NewScript(luaGetMulti),
+		client:        client,
+		rules:         make(map[string]*RateLimitRule),
+		overrides:     make(map[string]*RateLimitRule),
+		clock:         clock.OrSystem(clk),
+		scriptIncr:    redis.NewScript(luaIncrWithExpire),
+		scriptGet:     redis.NewScript(luaGetMulti),
+		scriptOffense: redis.NewScript(luaRecordOffense),
 	}
 
 	// Load rules (thread-safe)
@@ -90,17 +150,46 @@ func (l *RedisLimiter) Check(ctx context.Context, identifier, action string) (bo
 		return false, err
 	}
 
-	return status.IsAllowed(), nil
+	return status.IsAllowedAt(l.clock.Now()), nil
 }
 
 // RecordAttempt records an attempt using Redis with atomic operations
 func (l *RedisLimiter) RecordAttempt(ctx context.Context, identifier, action string) (*RateLimitStatus, error) {
-	// Get rule (thread-safe read)
-	l.mu.RLock()
-	rule, exists := l.rules[action]
-	l.mu.RUnlock()
+	return l.RecordAttemptWithCost(ctx, identifier, action, 1)
+}
+
+// RecordAttemptWithCost records an attempt weighing cost units against the quota
+func (l *RedisLimiter) RecordAttemptWithCost(ctx context.Context, identifier, action string, cost int) (*RateLimitStatus, error) {
+	if cost < 1 {
+		cost = 1
+	}
+
+	// Get rule (per-identifier override first, thread-safe read)
+	rule, exists := l.ruleFor(identifier, action)
+
+	key := l.makeKey(identifier, action)
+	blockKey := l.makeBlockKey(identifier, action)
+	denyKey := l.makeDenyKey(identifier)
+	now := l.clock.Now()
+
+	// Shadow rules keep would-be blocks under their own key
+	shadow := exists && rule.EffectiveMode() == ModeShadow
+	if shadow {
+		blockKey = l.makeShadowBlockKey(identifier, action)
+	}
 
-	if !exists || !rule.IsActive {
+	// Check block and denylist in one round-trip (Unix timestamps, not JSON)
+	values, err := l.client.MGet(ctx, blockKey, denyKey).Result()
+	if err != nil {
+		values = []interface{}{nil, nil}
+	}
+
+	// Denylisted identifiers are blocked for every action
+	if until, ok := parseUnixValue(values[1]); ok && now.Before(until) {
+		return denylistedStatus(identifier, action, rule, until), nil
+	}
+
+	if !exists || rule.EffectiveMode() == ModeOff {
 		// No rate limiting for this action
 		return &RateLimitStatus{
 			Identifier:     identifier,
@@ -110,37 +199,34 @@ func (l *RedisLimiter) RecordAttempt(ctx context.Context, identifier, action str
 		}, nil
 	}
 
-	key := l.makeKey(identifier, action)
-	blockKey := l.makeBlockKey(identifier, action)
-	now := time.Now()
+	// Check if blocked
+	if blockedUntil, ok := parseUnixValue(values[0]); ok && now.Before(blockedUntil) {
+		// Still blocked
+		status := &RateLimitStatus{
+			Identifier:     identifier,
+			Action:         action,
+			Count:          rule.MaxAttempts,
+			MaxAttempts:    rule.MaxAttempts,
+			Window:         rule.WindowSize,
+			RemainingTries: 0,
+			WindowEnd:      blockedUntil,
+			Blocked:        true,
+			BlockedUntil:   &blockedUntil,
+		}
 
-	// Check if blocked (using timestamp instead of JSON for efficiency)
-	blockedUntilStr, err := l.client.Get(ctx, blockKey).Result()
-	if err == nil && blockedUntilStr != "" {
-		blockedUntilUnix, err := strconv.ParseInt(blockedUntilStr, 10, 64)
-		if err == nil {
-			blockedUntil := time.Unix(blockedUntilUnix, 0)
-			if now.Before(blockedUntil) {
-				// Still blocked
-				return &RateLimitStatus{
-					Identifier:     identifier,
-					Action:         action,
-					Count:          rule.MaxAttempts,
-					MaxAttempts:    rule.MaxAttempts,
-					RemainingTries: 0,
-					WindowEnd:      blockedUntil,
-					Blocked:        true,
-					BlockedUntil:   &blockedUntil,
-				}, nil
-			}
+		if shadow {
+			l.recordShadowHit(ctx, action)
+			return asShadow(status), nil
 		}
+		return status, nil
 	}
 
 	// Atomically increment counter and set expiry using Lua script
-	// This prevents race condition between INCR and EXPIRE
+	// This prevents race condition between INCRBY and EXPIRE
 	countResult, err := l.scriptIncr.Run(ctx, l.client,
 		[]string{key},
 		int(rule.WindowSize.Seconds()),
+		cost,
 	).Result()
 	if err != nil {
 		return nil, fmt.Errorf("redis incr script failed: %w", err)
@@ -151,8 +237,13 @@ func (l *RedisLimiter) RecordAttempt(ctx context.Context, identifier, action str
 		return nil, fmt.Errorf("unexpected script result type: %T", countResult)
 	}
 
-	// Get TTL for window end (with safe fallback)
-	ttl, err := l.client.TTL(ctx, key).Result()
+	// Get TTL for window end and update the inspection indexes in one round-trip
+	pipe := l.client.Pipeline()
+	ttlCmd := pipe.TTL(ctx, key)
+	l.indexAttempt(ctx, pipe, identifier, action, rule, cost, count, now)
+	pipe.Exec(ctx)
+
+	ttl, err := ttlCmd.Result()
 	if err != nil || ttl <= 0 {
 		// Fallback to window size if TTL query fails or returns invalid value
 		// -1 = no expiry, -2 = key doesn't exist
@@ -169,15 +260,30 @@ func (l *RedisLimiter) RecordAttempt(ctx context.Context, identifier, action str
 		blockedUntil = &blockedTime
 
 		// Store block as Unix timestamp (more efficient than JSON)
-		blockedUntilUnix := blockedTime.Unix()
-		err := l.client.Set(ctx, blockKey,
-			strconv.FormatInt(blockedUntilUnix, 10),
+		// NX: only the request that starts the block counts an offense
+		started, err := l.client.SetNX(ctx, blockKey,
+			strconv.FormatInt(blockedTime.Unix(), 10),
 			rule.BlockDuration,
-		).Err()
+		).Result()
 		if err != nil {
 			// Log but don't fail - block will be enforced on next attempt
 			// In production, you should log this error
 		}
+
+		if shadow {
+			// Would-be block: log it once, count every would-be rejection
+			if started {
+				l.reportShadowBlock(identifier, rule, int(count), windowEnd, blockedTime)
+			}
+			l.recordShadowHit(ctx, action)
+		} else if started {
+			if duration := l.escalate(ctx, identifier, rule, now); duration > rule.BlockDuration {
+				blockedTime = now.Add(duration)
+				l.client.Set(ctx, blockKey, strconv.FormatInt(blockedTime.Unix(), 10), duration)
+			}
+			l.indexBlock(ctx, identifier, action, blockedTime)
+			l.reportBlock(identifier, rule, int(count), blockedTime, now)
+		}
 	}
 
 	// Calculate remaining tries (ensure non-negative)
@@ -191,23 +297,40 @@ func (l *RedisLimiter) RecordAttempt(ctx context.Context, identifier, action str
 		Action:         action,
 		Count:          int(count),
 		MaxAttempts:    rule.MaxAttempts,
+		Window:         rule.WindowSize,
 		RemainingTries: remaining,
 		WindowEnd:      windowEnd,
 		Blocked:        shouldBlock,
 		BlockedUntil:   blockedUntil,
+		Mode:           rule.EffectiveMode(),
 	}
 
+	if shadow {
+		return asShadow(status), nil
+	}
 	return status, nil
 }
 
 // GetStatus gets current status from Redis using optimized multi-get
 func (l *RedisLimiter) GetStatus(ctx context.Context, identifier, action string) (*RateLimitStatus, error) {
-	// Get rule (thread-safe read)
-	l.mu.RLock()
-	rule, exists := l.rules[action]
-	l.mu.RUnlock()
+	// Get rule (per-identifier override first, thread-safe read)
+	rule, exists := l.ruleFor(identifier, action)
+
+	key := l.makeKey(identifier, action)
+	blockKey := l.makeBlockKey(identifier, action)
+	denyKey := l.makeDenyKey(identifier)
+	now := l.clock.Now()
+
+	if !exists || rule.EffectiveMode() == ModeOff {
+		// Denylisted identifiers are blocked for every action, limited or not
+		value, err := l.client.Get(ctx, denyKey).Result()
+		if err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("redis get failed: %w", err)
+		}
+		if until, ok := parseUnixValue(value); ok && now.Before(until) {
+			return denylistedStatus(identifier, action, rule, until), nil
+		}
 
-	if !exists || !rule.IsActive {
 		return &RateLimitStatus{
 			Identifier:     identifier,
 			Action:         action,
@@ -216,13 +339,14 @@ func (l *RedisLimiter) GetStatus(ctx context.Context, identifier, action string)
 		}, nil
 	}
 
-	key := l.makeKey(identifier, action)
-	blockKey := l.makeBlockKey(identifier, action)
-	now := time.Now()
+	mode := rule.EffectiveMode()
+	if mode == ModeShadow {
+		blockKey = l.makeShadowBlockKey(identifier, action)
+	}
 
 	// Use Lua script to get all values in one round-trip
 	result, err := l.scriptGet.Run(ctx, l.client,
-		[]string{key, blockKey},
+		[]string{key, blockKey, denyKey},
 	).Result()
 
 	if err != nil {
@@ -231,10 +355,15 @@ func (l *RedisLimiter) GetStatus(ctx context.Context, identifier, action string)
 
 	// Parse result array
 	values, ok := result.([]interface{})
-	if !ok || len(values) != 3 {
+	if !ok || len(values) != 4 {
 		return nil, fmt.Errorf("unexpected script result format")
 	}
 
+	// Denylisted identifiers are blocked for every action
+	if until, ok := parseUnixValue(values[3]); ok && now.Before(until) {
+		return denylistedStatus(identifier, action, rule, until), nil
+	}
+
 	// Parse count
 	countStr, _ := values[0].(string)
 	count, _ := strconv.Atoi(countStr)
@@ -264,9 +393,9 @@ func (l *RedisLimiter) GetStatus(ctx context.Context, identifier, action string)
 		}
 	}
 
-	// Calculate remaining tries (ensure non-negative)
+	// Calculate remaining tries (ensure non-negative, none while blocked)
 	remaining := rule.MaxAttempts - count
-	if remaining < 0 {
+	if remaining < 0 || blocked {
 		remaining = 0
 	}
 
@@ -275,12 +404,17 @@ func (l *RedisLimiter) GetStatus(ctx context.Context, identifier, action string)
 		Action:         action,
 		Count:          count,
 		MaxAttempts:    rule.MaxAttempts,
+		Window:         rule.WindowSize,
 		RemainingTries: remaining,
 		WindowEnd:      windowEnd,
 		Blocked:        blocked,
 		BlockedUntil:   blockedUntil,
+		Mode:           mode,
 	}
 
+	if mode == ModeShadow {
+		return asShadow(status), nil
+	}
 	return status, nil
 }
 
@@ -289,10 +423,12 @@ func (l *RedisLimiter) Reset(ctx context.Context, identifier, action string) err
 	key := l.makeKey(identifier, action)
 	blockKey := l.makeBlockKey(identifier, action)
 
-	// Use pipeline to delete both keys in one round-trip
+	// Use pipeline to delete all keys in one round-trip
 	pipe := l.client.Pipeline()
 	pipe.Del(ctx, key)
 	pipe.Del(ctx, blockKey)
+	pipe.Del(ctx, l.makeShadowBlockKey(identifier, action))
+	pipe.ZRem(ctx, blockedIndexKey, overrideKey(identifier, action))
 	_, err := pipe.Exec(ctx)
 
 	return err
@@ -302,19 +438,23 @@ func (l *RedisLimiter) Reset(ctx context.Context, identifier, action string) err
 // Duration of 0 means indefinite block (until manual unblock)
 func (l *RedisLimiter) Block(ctx context.Context, identifier, action string, duration time.Duration) error {
 	if duration == 0 {
-		// Indefinite block - use very long duration
-		duration = 365 * 24 * time.Hour // 1 year
+		duration = IndefiniteBlockDuration
 	}
 
 	blockKey := l.makeBlockKey(identifier, action)
-	blockedUntil := time.Now().Add(duration)
+	blockedUntil := l.clock.Now().Add(duration)
 
 	// Store as Unix timestamp (efficient)
 	blockedUntilUnix := blockedUntil.Unix()
-	return l.client.Set(ctx, blockKey,
+	if err := l.client.Set(ctx, blockKey,
 		strconv.FormatInt(blockedUntilUnix, 10),
 		duration,
-	).Err()
+	).Err(); err != nil {
+		return err
+	}
+
+	l.indexBlock(ctx, identifier, action, blockedUntil)
+	return nil
 }
 
 // Unblock manually unblocks an identifier in Redis
@@ -322,10 +462,12 @@ func (l *RedisLimiter) Unblock(ctx context.Context, identifier, action string) e
 	key := l.makeKey(identifier, action)
 	blockKey := l.makeBlockKey(identifier, action)
 
-	// Delete both counter and block keys
+	// Delete counter and block keys
 	pipe := l.client.Pipeline()
 	pipe.Del(ctx, key)
 	pipe.Del(ctx, blockKey)
+	pipe.Del(ctx, l.makeShadowBlockKey(identifier, action))
+	pipe.ZRem(ctx, blockedIndexKey, overrideKey(identifier, action))
 	_, err := pipe.Exec(ctx)
 
 	return err
@@ -338,22 +480,55 @@ func (l *RedisLimiter) Unblock(ctx context.Context, identifier, action string) e
 // makeKey generates Redis key for rate limit counter
 // Uses SHA1 hash of identifier to keep keys short and consistent
 func (l *RedisLimiter) makeKey(identifier, action string) string {
-	// Hash long identifiers (emails, UUIDs) to keep keys short
-	hash := hashIdentifier(identifier)
-	return fmt.Sprintf("rl:c:%s:%s", action, hash)
+	return fmt.Sprintf("rl:c:%s:%s", action, slotTag(identifier))
 }
 
 // makeBlockKey generates Redis key for block status
 func (l *RedisLimiter) makeBlockKey(identifier, action string) string {
-	hash := hashIdentifier(identifier)
-	return fmt.Sprintf("rl:b:%s:%s", action, hash)
+	return fmt.Sprintf("rl:b:%s:%s", action, slotTag(identifier))
+}
+
+// makeShadowBlockKey generates Redis key for the would-be block of a shadow rule
+func (l *RedisLimiter) makeShadowBlockKey(identifier, action string) string {
+	return fmt.Sprintf("rl:sb:%s:%s", action, slotTag(identifier))
+}
+
+// makeDenyKey generates Redis key for the denylist entry of an identifier
+func (l *RedisLimiter) makeDenyKey(identifier string) string {
+	return fmt.Sprintf("rl:d:%s", slotTag(identifier))
+}
+
+// makeOffenseKey generates Redis key for the offense record of an identifier
+func (l *RedisLimiter) makeOffenseKey(identifier string) string {
+	return fmt.Sprintf("rl:o:%s", slotTag(identifier))
+}
+
+// parseUnixValue parses a Unix timestamp stored by Block/escalate
+func parseUnixValue(value interface{}) (time.Time, bool) {
+	str, ok := value.(string)
+	if !ok || str == "" {
+		return time.Time{}, false
+	}
+
+	unix, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(unix, 0), true
+}
+
+// overrideKey generates the override map key for identifier and action
+func overrideKey(identifier, action string) string {
+	return identifier + "|" + action
 }
 
 // hashIdentifier hashes identifier to keep Redis keys short
 // Long identifiers (emails, UUIDs) → 40 character SHA1 hash
 func hashIdentifier(identifier string) string {
 	// For short identifiers (IPs), no need to hash
-	if len(identifier) <= 20 {
+	// Braces are hashed too, they would break the cluster hash tag
+	if len(identifier) <= 20 && !strings.ContainsAny(identifier, "{}") {
 		return identifier
 	}
 
@@ -363,6 +538,11 @@ func hashIdentifier(identifier string) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
+// slotTag is the cluster hash tag shared by all keys of identifier
+func slotTag(identifier string) string {
+	return "{" + hashIdentifier(identifier) + "}"
+}
+
 // ============================================================================
 // RULE MANAGEMENT (Thread-safe)
 // ============================================================================
@@ -385,6 +565,11 @@ func (l *RedisLimiter) RemoveRule(action string) {
 	l.mu.Unlock()
 }
 
+// Now returns the current time of the limiter's clock
+func (l *RedisLimiter) Now() time.Time {
+	return l.clock.Now()
+}
+
 // GetRule gets a rate limit rule (thread-safe)
 func (l *RedisLimiter) GetRule(action string) (*RateLimitRule, bool) {
 	l.mu.RLock()
@@ -407,6 +592,253 @@ func (l *RedisLimiter) ListRules() []*RateLimitRule {
 	return rules
 }
 
+// ruleFor returns the override for identifier if one exists, else the action rule
+func (l *RedisLimiter) ruleFor(identifier, action string) (*RateLimitRule, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if rule, ok := l.overrides[overrideKey(identifier, action)]; ok {
+		return rule, true
+	}
+
+	rule, exists := l.rules[action]
+	return rule, exists
+}
+
+// SetOverride applies a per-identifier quota override (thread-safe)
+func (l *RedisLimiter) SetOverride(override *QuotaOverride) {
+	l.mu.Lock()
+	l.overrides[overrideKey(override.Key(), override.Action)] = override.Rule()
+	l.mu.Unlock()
+}
+
+// RemoveOverride removes a per-identifier quota override (thread-safe)
+func (l *RedisLimiter) RemoveOverride(identifier, action string) {
+	l.mu.Lock()
+	delete(l.overrides, overrideKey(identifier, action))
+	l.mu.Unlock()
+}
+
+// HasOverride checks if identifier has an override for action (thread-safe)
+func (l *RedisLimiter) HasOverride(identifier, action string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	_, ok := l.overrides[overrideKey(identifier, action)]
+	return ok
+}
+
+// ListOverrides returns all override rules keyed by "identifier|action" (thread-safe)
+func (l *RedisLimiter) ListOverrides() map[string]*RateLimitRule {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	overrides := make(map[string]*RateLimitRule, len(l.overrides))
+	for key, rule := range l.overrides {
+		overrides[key] = rule
+	}
+
+	return overrides
+}
+
+// ReplaceOverrides swaps all overrides at once (used by override sync)
+func (l *RedisLimiter) ReplaceOverrides(overrides []*QuotaOverride) {
+	next := make(map[string]*RateLimitRule, len(overrides))
+	for _, o := range overrides {
+		next[overrideKey(o.Key(), o.Action)] = o.Rule()
+	}
+
+	l.mu.Lock()
+	l.overrides = next
+	l.mu.Unlock()
+}
+
+// ============================================================================
+// REPEAT OFFENDERS
+// ============================================================================
+
+// SetEscalation enables escalating blocks (nil restores constant durations)
+func (l *RedisLimiter) SetEscalation(policy *EscalationPolicy) {
+	l.mu.Lock()
+	l.escalation = policy
+	l.mu.Unlock()
+}
+
+// SetSecurityEventSink sets where denylist events are recorded
+func (l *RedisLimiter) SetSecurityEventSink(sink SecurityEventSink) {
+	l.mu.Lock()
+	l.events = sink
+	l.mu.Unlock()
+}
+
+// GetOffender returns the offense history of an identifier
+func (l *RedisLimiter) GetOffender(ctx context.Context, identifier string) (*OffenderStatus, error) {
+	l.mu.RLock()
+	policy := l.escalation
+	l.mu.RUnlock()
+
+	pipe := l.client.Pipeline()
+	offenseCmd := pipe.HMGet(ctx, l.makeOffenseKey(identifier), "count", "last")
+	denyCmd := pipe.Get(ctx, l.makeDenyKey(identifier))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	now := l.clock.Now()
+	status := &OffenderStatus{Identifier: identifier}
+
+	if fields := offenseCmd.Val(); len(fields) == 2 {
+		count, _ := strconv.Atoi(fmt.Sprint(fields[0]))
+		if last, ok := parseUnixValue(fields[1]); ok {
+			var period time.Duration
+			if policy != nil {
+				period = policy.DecayPeriod
+			}
+			status.Offenses = decayOffenses(count, last, now, period)
+			status.LastOffenseAt = &last
+		}
+	}
+
+	if until, ok := parseUnixValue(denyCmd.Val()); ok && now.Before(until) {
+		status.Denylisted = true
+		status.DenylistedUntil = &until
+	}
+
+	return status, nil
+}
+
+// Pardon clears the offense history and denylist entry of an identifier
+// Per-action blocks are left alone (use Unblock)
+func (l *RedisLimiter) Pardon(ctx context.Context, identifier string) error {
+	pipe := l.client.Pipeline()
+	pipe.Del(ctx, l.makeOffenseKey(identifier), l.makeDenyKey(identifier))
+	pipe.ZRem(ctx, deniedIndexKey, identifier)
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+// escalate records an offense for escalating rules and returns how long to
+// block. Crossing the denylist threshold denylists the identifier.
+func (l *RedisLimiter) escalate(ctx context.Context, identifier string, rule *RateLimitRule, now time.Time) time.Duration {
+	l.mu.RLock()
+	policy, sink := l.escalation, l.events
+	l.mu.RUnlock()
+
+	if !rule.Escalate || policy == nil || rule.BlockDuration <= 0 {
+		return rule.BlockDuration
+	}
+
+	result, err := l.scriptOffense.Run(ctx, l.client,
+		[]string{l.makeOffenseKey(identifier)},
+		now.Unix(),
+		int(policy.DecayPeriod.Seconds()),
+		int(policy.permanentOffenseTTL().Seconds()),
+	).Int()
+	if err != nil {
+		// Fall back to the base block duration
+		return rule.BlockDuration
+	}
+
+	duration := policy.BlockDuration(rule.BlockDuration, result)
+
+	if policy.ShouldDenylist(result) {
+		until := now.Add(policy.DenylistDuration)
+		err := l.client.Set(ctx, l.makeDenyKey(identifier),
+			strconv.FormatInt(until.Unix(), 10),
+			policy.DenylistDuration,
+		).Err()
+		if err == nil {
+			l.indexDenylist(ctx, identifier, until)
+			reportDenylist(sink, DenylistEvent{
+				Identifier:      identifier,
+				Action:          rule.Action,
+				Offenses:        result,
+				DenylistedUntil: until,
+				OccurredAt:      now,
+			})
+		}
+		if d := until.Sub(now); d > duration {
+			duration = d
+		}
+	}
+
+	return duration
+}
+
+// ============================================================================
+// SHADOW RULES
+// ============================================================================
+
+// shadowHitsKey is the Redis hash of shadow hits per action (shared by all instances)
+const shadowHitsKey = "rl:shadow_hits"
+
+// SetShadowSink sets where would-be blocks of shadow rules are logged
+func (l *RedisLimiter) SetShadowSink(sink ShadowSink) {
+	l.mu.Lock()
+	l.shadowSink = sink
+	l.mu.Unlock()
+}
+
+// ShadowHits returns would-be rejected requests per action across all instances
+func (l *RedisLimiter) ShadowHits(ctx context.Context) (map[string]int64, error) {
+	values, err := l.client.HGetAll(ctx, shadowHitsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make(map[string]int64, len(values))
+	for action, value := range values {
+		hits[action], _ = strconv.ParseInt(value, 10, 64)
+	}
+
+	return hits, nil
+}
+
+// recordShadowHit counts a would-be rejected request of a shadow rule
+func (l *RedisLimiter) recordShadowHit(ctx context.Context, action string) {
+	shadowHitCounter.Add(action, 1)
+	l.client.HIncrBy(ctx, shadowHitsKey, action, 1)
+}
+
+// reportShadowBlock logs the would-be block of a shadow rule
+func (l *RedisLimiter) reportShadowBlock(identifier string, rule *RateLimitRule, count int, windowEnd, until time.Time) {
+	l.mu.RLock()
+	sink := l.shadowSink
+	l.mu.RUnlock()
+
+	reportShadowBlock(sink, ShadowEvent{
+		Identifier:  identifier,
+		Action:      rule.Action,
+		Count:       count,
+		MaxAttempts: rule.MaxAttempts,
+		WindowStart: windowEnd.Add(-rule.WindowSize),
+		WindowEnd:   windowEnd,
+		WouldUntil:  until,
+	})
+}
+
+// ============================================================================
+// AUDIT
+// ============================================================================
+
+// SetAuditSink sets where blocks started by RecordAttempt are audited
+// The sink is called on the request path, so it must not block (see AuditLog)
+func (l *RedisLimiter) SetAuditSink(sink AuditSink) {
+	l.mu.Lock()
+	l.audit = sink
+	l.mu.Unlock()
+}
+
+// reportBlock audits a block started by RecordAttempt
+func (l *RedisLimiter) reportBlock(identifier string, rule *RateLimitRule, count int, until, now time.Time) {
+	l.mu.RLock()
+	sink := l.audit
+	l.mu.RUnlock()
+
+	reportBlock(sink, identifier, rule, count, until, now)
+}
+
 // ============================================================================
 // CONNECTION MANAGEMENT
 // ============================================================================
@@ -427,26 +859,42 @@ func (l *RedisLimiter) Ping(ctx context.Context) error {
 
 // GetStats returns statistics about rate limiting
 // Useful for monitoring and debugging
+// Counts come from the inspection indexes (never KEYS, which blocks Redis)
 func (l *RedisLimiter) GetStats(ctx context.Context) (map[string]interface{}, error) {
-	// Get total number of rate limit keys
-	counterKeys, err := l.client.Keys(ctx, "rl:c:*").Result()
-	if err != nil {
+	now := l.clock.Now()
+	nowUnix := strconv.FormatInt(now.Unix(), 10)
+	rules := l.ListRules()
+
+	pipe := l.client.Pipeline()
+	counterCmds := make([]*redis.IntCmd, 0, len(rules))
+	for _, rule := range rules {
+		counterCmds = append(counterCmds, pipe.ZCard(ctx, l.makeTopKey(rule.Action, rule.WindowSize, now)))
+	}
+	blockCmd := pipe.ZCount(ctx, blockedIndexKey, "("+nowUnix, "+inf")
+	denyCmd := pipe.ZCount(ctx, deniedIndexKey, "("+nowUnix, "+inf")
+	idsCmd := pipe.ZCard(ctx, identifierIndexKey)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, err
 	}
 
-	blockKeys, err := l.client.Keys(ctx, "rl:b:*").Result()
+	// Identifiers per action in the current window bucket
+	activeCounters := int64(0)
+	for _, cmd := range counterCmds {
+		activeCounters += cmd.Val()
+	}
+
+	shadowHits, err := l.ShadowHits(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	l.mu.RLock()
-	ruleCount := len(l.rules)
-	l.mu.RUnlock()
-
 	stats := map[string]interface{}{
-		"rules_configured": ruleCount,
-		"active_counters":  len(counterKeys),
-		"active_blocks":    len(blockKeys),
+		"rules_configured": len(rules),
+		"active_counters":  activeCounters,
+		"active_blocks":    blockCmd.Val(),
+		"denylisted_ids":   denyCmd.Val(),
+		"tracked_ids":      idsCmd.Val(),
+		"shadow_hits":      shadowHits,
 		"store_type":       "redis",
 		"redis_connected":  l.client.Ping(ctx).Err() == nil,
 	}
@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+)
+
+// ============================================================================
+// ESCALATING BLOCKS
+// ============================================================================
+// Rules marked Escalate count an offense against the identifier every time
+// they block it. The block duration grows with the offense count:
+//
+//	block = rule.BlockDuration * Multiplier^(offenses-1), capped at MaxBlockDuration
+//
+// Offenses decay by one for every DecayPeriod without a new offense.
+// Reaching DenylistThreshold denylists the identifier for every action.
+
+// EscalationPolicy configures escalating blocks for repeat offenders
+type EscalationPolicy struct {
+	Multiplier        float64       // Block duration growth per offense (<= 1 disables escalation)
+	MaxBlockDuration  time.Duration // Upper bound for an escalated block (0 = no cap)
+	DecayPeriod       time.Duration // One offense is forgiven per quiet period
+	DenylistThreshold int           // Offenses before the identifier is denylisted (0 = never)
+	DenylistDuration  time.Duration // How long a denylisted identifier stays blocked
+}
+
+// DefaultEscalationPolicy returns the default escalation policy
+// 30m → 1h → 2h → 4h ... up to 24h, denylisted for 7 days at 5 offenses a day
+func DefaultEscalationPolicy() *EscalationPolicy {
+	return &EscalationPolicy{
+		Multiplier:        2,
+		MaxBlockDuration:  24 * time.Hour,
+		DecayPeriod:       24 * time.Hour,
+		DenylistThreshold: 5,
+		DenylistDuration:  7 * 24 * time.Hour,
+	}
+}
+
+// BlockDuration returns the escalated block duration for the offense count
+func (p *EscalationPolicy) BlockDuration(base time.Duration, offenses int) time.Duration {
+	if p == nil || p.Multiplier <= 1 || offenses <= 1 || base <= 0 {
+		return base
+	}
+
+	scaled := float64(base) * math.Pow(p.Multiplier, float64(offenses-1))
+	if p.MaxBlockDuration > 0 && scaled > float64(p.MaxBlockDuration) {
+		return p.MaxBlockDuration
+	}
+	if scaled > float64(math.MaxInt64) {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration(scaled)
+}
+
+// ShouldDenylist reports whether the offense count crosses the denylist threshold
+func (p *EscalationPolicy) ShouldDenylist(offenses int) bool {
+	return p != nil && p.DenylistThreshold > 0 && offenses >= p.DenylistThreshold
+}
+
+// decayOffenses returns the offense count left after the quiet time since last
+// Must stay in sync with luaRecordOffense
+func decayOffenses(count int, last, now time.Time, period time.Duration) int {
+	if count <= 0 || period <= 0 || !now.After(last) {
+		return count
+	}
+
+	count -= int(now.Sub(last) / period)
+	if count < 0 {
+		return 0
+	}
+	return count
+}
+
+// permanentOffenseTTL is how long offense records live when DecayPeriod is 0
+func (p *EscalationPolicy) permanentOffenseTTL() time.Duration {
+	return p.DenylistDuration + p.MaxBlockDuration + 24*time.Hour
+}
+
+// ============================================================================
+// OFFENDER STATUS
+// ============================================================================
+
+// OffenderStatus describes the offense history of an identifier
+type OffenderStatus struct {
+	Identifier      string     `json:"identifier"`
+	Offenses        int        `json:"offenses"`
+	LastOffenseAt   *time.Time `json:"last_offense_at,omitempty"`
+	Denylisted      bool       `json:"denylisted"`
+	DenylistedUntil *time.Time `json:"denylisted_until,omitempty"`
+}
+
+// denylistedStatus is the status returned for any action of a denylisted identifier
+func denylistedStatus(identifier, action string, rule *RateLimitRule, until time.Time) *RateLimitStatus {
+	status := &RateLimitStatus{
+		Identifier:     identifier,
+		Action:         action,
+		RemainingTries: 0,
+		WindowEnd:      until,
+		Blocked:        true,
+		BlockedUntil:   &until,
+	}
+	if rule != nil {
+		status.Count = rule.MaxAttempts
+		status.MaxAttempts = rule.MaxAttempts
+		status.Window = rule.WindowSize
+	}
+	return status
+}
+
+// ============================================================================
+// SECURITY EVENTS
+// ============================================================================
+
+// DenylistEvent is reported when an identifier is denylisted
+type DenylistEvent struct {
+	Identifier      string
+	Action          string // Action whose block crossed the threshold
+	Offenses        int
+	DenylistedUntil time.Time
+	OccurredAt      time.Time
+}
+
+// SecurityEventSink records security-relevant limiter events
+type SecurityEventSink interface {
+	RecordDenylist(ctx context.Context, event DenylistEvent) error
+}
+
+// reportDenylist sends the event to sink without blocking the request
+func reportDenylist(sink SecurityEventSink, event DenylistEvent) {
+	log.Printf("⚠️  Rate limit denylisted %s after %d offenses (action: %s, until: %s)",
+		event.Identifier, event.Offenses, event.Action, event.DenylistedUntil.Format(time.RFC3339))
+
+	if sink == nil {
+		return
+	}
+
+	dispatchEvent("denylist security event", func(ctx context.Context) error {
+		return sink.RecordDenylist(ctx, event)
+	})
+}
+
+// dispatchEvent runs record in the background with its own timeout so
+// request cancellation never drops an event
+func dispatchEvent(what string, record func(ctx context.Context) error) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := record(ctx); err != nil {
+			log.Printf("⚠️  Failed to record %s: %v", what, err)
+		}
+	}()
+}
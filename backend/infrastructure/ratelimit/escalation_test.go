@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestGetStatusDenylistedForEveryAction(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	until := clk.Now().Add(time.Hour)
+	rules := func() []*RateLimitRule {
+		return []*RateLimitRule{
+			NewRule("login").MaxAttempts(5).Window(time.Minute).BlockFor(time.Minute).Build(),
+			{Action: "export", MaxAttempts: 1, WindowSize: time.Minute, IsActive: true, Mode: ModeOff},
+		}
+	}
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	redisLimiter := NewRedisLimiter(client, rules(), clk)
+	t.Cleanup(func() { redisLimiter.Close() })
+	client.Set(ctx, redisLimiter.makeDenyKey("ip:6.6.6.6"), strconv.FormatInt(until.Unix(), 10), time.Hour)
+
+	memoryLimiter := NewMemoryLimiter(rules(), clk)
+	t.Cleanup(func() { memoryLimiter.Close() })
+	memoryLimiter.denylist["ip:6.6.6.6"] = until
+
+	for name, limiter := range map[string]Limiter{"redis": redisLimiter, "memory": memoryLimiter} {
+		for _, action := range []string{"login", "export", "unconfigured"} {
+			status, err := limiter.GetStatus(ctx, "ip:6.6.6.6", action)
+			if err != nil {
+				t.Fatalf("%s %s: %v", name, action, err)
+			}
+			if !status.Blocked || status.BlockedUntil == nil || !status.BlockedUntil.Equal(until) {
+				t.Errorf("%s %s: status = %+v, want denylisted until %v", name, action, status, until)
+			}
+		}
+
+		status, err := limiter.GetStatus(ctx, "ip:1.1.1.1", "unconfigured")
+		if err != nil || status.Blocked || status.RemainingTries != -1 {
+			t.Errorf("%s: unlimited status = %+v, %v", name, status, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// RATE LIMIT HTTP HEADERS
+// ============================================================================
+// IETF RateLimit header fields (draft-ietf-httpapi-ratelimit-headers):
+//
+//	RateLimit-Policy: "api";q=100;w=60, "api_burst";q=20;w=1
+//	RateLimit: "api";r=42;t=17, "api_burst";r=19;t=1
+//
+// q = quota units, w = window in seconds, r = remaining units,
+// t = seconds until the quota resets. Each stacked policy is one list member.
+//
+// Legacy X-RateLimit-* headers describe the most restrictive policy only and
+// are kept for older clients.
+
+const (
+	HeaderRateLimitPolicy = "RateLimit-Policy"
+	HeaderRateLimit       = "RateLimit"
+	HeaderRetryAfter      = "Retry-After"
+
+	HeaderLegacyLimit     = "X-RateLimit-Limit"
+	HeaderLegacyRemaining = "X-RateLimit-Remaining"
+	HeaderLegacyReset     = "X-RateLimit-Reset"
+)
+
+// PolicyItem formats the RateLimit-Policy list member for this status
+func (s *RateLimitStatus) PolicyItem() string {
+	return fmt.Sprintf("%q;q=%d;w=%d", s.Action, s.MaxAttempts, int(s.Window.Seconds()))
+}
+
+// LimitItemAt formats the RateLimit list member for this status at now
+func (s *RateLimitStatus) LimitItemAt(now time.Time) string {
+	return fmt.Sprintf("%q;r=%d;t=%d", s.Action, s.RemainingTries, s.RetryAfterSecondsAt(now))
+}
+
+// BuildHeaders returns the rate limit headers at now for one or more policy statuses.
+// Statuses of unlimited actions (RemainingTries < 0) and shadow rules are skipped.
+// Retry-After is added whenever one of the statuses is not allowed.
+func BuildHeaders(now time.Time, legacy bool, statuses ...*RateLimitStatus) map[string]string {
+	headers := make(map[string]string)
+
+	var policies, limits []string
+	var tightest, denied *RateLimitStatus
+
+	for _, status := range statuses {
+		// Shadow rules are not advertised to clients
+		if status == nil || status.RemainingTries < 0 || status.Mode == ModeShadow {
+			continue
+		}
+
+		policies = append(policies, status.PolicyItem())
+		limits = append(limits, status.LimitItemAt(now))
+
+		if tightest == nil || status.RemainingTries < tightest.RemainingTries {
+			tightest = status
+		}
+		if denied == nil && !status.IsAllowedAt(now) {
+			denied = status
+		}
+	}
+
+	if len(policies) == 0 {
+		return headers
+	}
+
+	headers[HeaderRateLimitPolicy] = strings.Join(policies, ", ")
+	headers[HeaderRateLimit] = strings.Join(limits, ", ")
+
+	if denied != nil {
+		headers[HeaderRetryAfter] = fmt.Sprintf("%d", denied.RetryAfterSecondsAt(now))
+		tightest = denied
+	}
+
+	if legacy {
+		headers[HeaderLegacyLimit] = fmt.Sprintf("%d", tightest.MaxAttempts)
+		headers[HeaderLegacyRemaining] = fmt.Sprintf("%d", tightest.RemainingTries)
+		if !tightest.WindowEnd.IsZero() {
+			headers[HeaderLegacyReset] = fmt.Sprintf("%d", tightest.WindowEnd.Unix())
+		}
+	}
+
+	return headers
+}
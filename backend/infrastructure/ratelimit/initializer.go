@@ -5,8 +5,9 @@ import (
 	"log"
 	"time"
 
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/config"
-	"github.com/redis/go-redis/v9"
+	redisclient "github.com/LePhuocVuTien/SurvivalPro-Backend/internal/redis"
 )
 
 // ============================================================================
@@ -14,22 +15,12 @@ import (
 // ============================================================================
 
 func InitializeRedisLimiter() *RedisLimiter {
-	// Create Redis client
-	opt, err := redis.ParseURL(config.Cfg.Redis.URL)
+	// Create Redis client with connection pool (single node, Sentinel or Cluster)
+	client, err := redisclient.NewUniversalClient(config.Cfg.Redis)
 	if err != nil {
-		log.Fatalf("❌ Invalid REDIS_URL: %v", err)
+		log.Fatalf("❌ Invalid Redis configuration: %v", err)
 	}
 
-	// Create Redis client with connection pool
-	client := redis.NewClient(&redis.Options{
-		Addr:         opt.Addr,
-		Password:     opt.Password,
-		DB:           opt.DB,
-		PoolSize:     config.Cfg.Redis.PoolSize,
-		MinIdleConns: 5,
-		MaxRetries:   3,
-	})
-
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -40,37 +31,41 @@ func InitializeRedisLimiter() *RedisLimiter {
 
 	// Define rate limit rules
 	rules := []*RateLimitRule{
-		// Login: 5 attempts per 5 minutes, block for 30 minutes
+		// Login: 5 attempts per 5 minutes, block for 30 minutes (escalating)
 		{
 			Action:        "login",
 			MaxAttempts:   5,
 			WindowSize:    5 * time.Minute,
 			BlockDuration: 30 * time.Minute,
 			IsActive:      true,
+			Escalate:      true,
 		},
-		// Register: 3 attempts per hour, block for 2 hours
+		// Register: 3 attempts per hour, block for 2 hours (escalating)
 		{
 			Action:        "register",
 			MaxAttempts:   3,
 			WindowSize:    1 * time.Hour,
 			BlockDuration: 2 * time.Hour,
 			IsActive:      true,
+			Escalate:      true,
 		},
-		// Password reset: 3 attempts per hour, block for 1 hour
+		// Password reset: 3 attempts per hour, block for 1 hour (escalating)
 		{
 			Action:        "password_reset",
 			MaxAttempts:   3,
 			WindowSize:    1 * time.Hour,
 			BlockDuration: 1 * time.Hour,
 			IsActive:      true,
+			Escalate:      true,
 		},
-		// Email verify: 5 attempts per hour, block for 1 hour
+		// Email verify: 5 attempts per hour, block for 1 hour (escalating)
 		{
 			Action:        "email_verify",
 			MaxAttempts:   5,
 			WindowSize:    1 * time.Hour,
 			BlockDuration: 1 * time.Hour,
 			IsActive:      true,
+			Escalate:      true,
 		},
 		// API: 100 requests per minute, block for 5 minutes
 		{
@@ -80,6 +75,51 @@ func InitializeRedisLimiter() *RedisLimiter {
 			BlockDuration: 5 * time.Minute,
 			IsActive:      true,
 		},
+		// API tiers: resolved from the authenticated principal,
+		// per-user/per-key overrides are loaded from Postgres
+		{
+			Action:        TieredAction("api", TierAnonymous),
+			MaxAttempts:   60,
+			WindowSize:    1 * time.Minute,
+			BlockDuration: 5 * time.Minute,
+			IsActive:      true,
+		},
+		{
+			Action:        TieredAction("api", TierUser),
+			MaxAttempts:   100,
+			WindowSize:    1 * time.Minute,
+			BlockDuration: 5 * time.Minute,
+			IsActive:      true,
+		},
+		{
+			Action:        TieredAction("api", TierLeader),
+			MaxAttempts:   300,
+			WindowSize:    1 * time.Minute,
+			BlockDuration: 5 * time.Minute,
+			IsActive:      true,
+		},
+		{
+			Action:        TieredAction("api", TierAdmin),
+			MaxAttempts:   1000,
+			WindowSize:    1 * time.Minute,
+			BlockDuration: 1 * time.Minute,
+			IsActive:      true,
+		},
+		{
+			Action:        TieredAction("api", TierAPIKey),
+			MaxAttempts:   600,
+			WindowSize:    1 * time.Minute,
+			BlockDuration: 5 * time.Minute,
+			IsActive:      true,
+		},
+		// API burst: 20 requests per second, block for 10 seconds
+		{
+			Action:        ActionAPIBurst,
+			MaxAttempts:   20,
+			WindowSize:    1 * time.Second,
+			BlockDuration: 10 * time.Second,
+			IsActive:      true,
+		},
 		// Upload: 10 per hour, block for 1 hour
 		{
 			Action:        "upload",
@@ -88,8 +128,62 @@ func InitializeRedisLimiter() *RedisLimiter {
 			BlockDuration: 1 * time.Hour,
 			IsActive:      true,
 		},
+		// Upload burst: 3 per second, block for 30 seconds
+		{
+			Action:        ActionUploadBurst,
+			MaxAttempts:   3,
+			WindowSize:    1 * time.Second,
+			BlockDuration: 30 * time.Second,
+			IsActive:      true,
+		},
+	}
+
+	// Rules listed in RATE_LIMIT_SHADOW_ACTIONS only log would-be blocks
+	shadow := make(map[string]bool, len(config.Cfg.RateLimit.ShadowActions))
+	for _, action := range config.Cfg.RateLimit.ShadowActions {
+		shadow[action] = true
+	}
+	for _, rule := range rules {
+		if shadow[rule.Action] {
+			rule.Mode = ModeShadow
+			log.Printf("👻 Rate limit rule %s runs in shadow mode", rule.Action)
+		}
 	}
 
 	// Create rate limiter
-	return NewRedisLimiter(client, rules)
+	limiter := NewRedisLimiter(client, rules, clock.System)
+
+	// Repeat offenders on escalating rules get longer blocks, then the denylist
+	if cfg := config.Cfg.RateLimit; cfg.EscalationEnabled {
+		limiter.SetEscalation(&EscalationPolicy{
+			Multiplier:        cfg.EscalationMultiplier,
+			MaxBlockDuration:  cfg.MaxBlockDuration,
+			DecayPeriod:       cfg.OffenseDecay,
+			DenylistThreshold: cfg.DenylistThreshold,
+			DenylistDuration:  cfg.DenylistDuration,
+		})
+	}
+
+	return limiter
+}
+
+// InitializeConcurrencyLimiter creates the concurrency limiter sharing the
+// Redis connection of limiter
+func InitializeConcurrencyLimiter(limiter *RedisLimiter) *ConcurrencyLimiter {
+	cfg := config.Cfg.RateLimit
+
+	return NewConcurrencyLimiter(limiter.client, []*ConcurrencyRule{
+		// Uploads: at most N at once per principal
+		{
+			Action:        ActionConcurrentUpload,
+			MaxConcurrent: cfg.MaxConcurrentUploads,
+			LeaseTTL:      cfg.LeaseTTL,
+		},
+		// GDPR data exports are heavy, run them one at a time per principal
+		{
+			Action:        ActionDataExport,
+			MaxConcurrent: cfg.MaxConcurrentExports,
+			LeaseTTL:      cfg.LeaseTTL,
+		},
+	}, clock.System)
 }
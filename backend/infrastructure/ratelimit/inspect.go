@@ -0,0 +1,365 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// INSPECTION (admin views during an attack)
+// ============================================================================
+// Redis keys hash long identifiers, so the limiter maintains small indexes
+// next to the counters instead of walking the keyspace with KEYS:
+//   rl:i:blocked           ZSET "action|identifier" → blocked until (unix)
+//   rl:i:denied            ZSET identifier → denylisted until (unix)
+//   rl:i:ids               ZSET identifier → last seen (unix)
+//   rl:i:top:action:bucket ZSET identifier → attempts in the window bucket
+//
+// The global indexes are trimmed whenever they are written: entries past
+// their time are dropped and the size is capped, oldest first. The
+// identifier index is written on the first attempt of a window only, so
+// last seen is the start of the latest window rather than every hit.
+
+const (
+	blockedIndexKey    = "rl:i:blocked"
+	deniedIndexKey     = "rl:i:denied"
+	identifierIndexKey = "rl:i:ids"
+
+	// identifierRetention is how long an identifier stays searchable after its last attempt
+	identifierRetention = 24 * time.Hour
+
+	// maxIndexedIdentifiers and maxIndexedBlocks cap the global indexes
+	// during an attack with many distinct identifiers
+	maxIndexedIdentifiers = 100_000
+	maxIndexedBlocks      = 10_000
+
+	// scanBatchSize is the COUNT hint for incremental ZSCAN iteration
+	scanBatchSize = 500
+
+	// DenylistAction marks denylisted entries in BlockedIdentifiers
+	DenylistAction = "*"
+)
+
+// BlockedIdentifier is a currently blocked identifier
+type BlockedIdentifier struct {
+	Identifier       string    `json:"identifier"`
+	Action           string    `json:"action"` // DenylistAction for denylisted identifiers
+	BlockedUntil     time.Time `json:"blocked_until"`
+	RemainingSeconds int       `json:"remaining_seconds"`
+}
+
+// IdentifierAttempts is the attempt count of an identifier for an action
+type IdentifierAttempts struct {
+	Identifier string `json:"identifier"`
+	Action     string `json:"action"`
+	Attempts   int64  `json:"attempts"`
+}
+
+// IdentifierActivity is an identifier seen by the limiter
+type IdentifierActivity struct {
+	Identifier string    `json:"identifier"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// newBlockedIdentifier computes the remaining time of a block
+func newBlockedIdentifier(identifier, action string, until, now time.Time) BlockedIdentifier {
+	return BlockedIdentifier{
+		Identifier:       identifier,
+		Action:           action,
+		BlockedUntil:     until,
+		RemainingSeconds: int(until.Sub(now).Seconds()),
+	}
+}
+
+// sortBlocked orders blocks by the soonest to expire
+func sortBlocked(blocked []BlockedIdentifier) {
+	sort.Slice(blocked, func(i, j int) bool {
+		return blocked[i].BlockedUntil.Before(blocked[j].BlockedUntil)
+	})
+}
+
+// ============================================================================
+// REDIS INDEXES
+// ============================================================================
+
+// makeTopKey generates the attempts index key of the window bucket containing now
+func (l *RedisLimiter) makeTopKey(action string, window time.Duration, now time.Time) string {
+	seconds := int64(window.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("rl:i:top:%s:%d", action, now.Unix()/seconds)
+}
+
+// indexAttempt queues the index updates of an attempt on pipe. count is
+// the window counter after the attempt; the identifier index is only
+// written when the attempt opened the window.
+func (l *RedisLimiter) indexAttempt(ctx context.Context, pipe redis.Pipeliner, identifier, action string, rule *RateLimitRule, cost int, count int64, now time.Time) {
+	topKey := l.makeTopKey(action, rule.WindowSize, now)
+	pipe.ZIncrBy(ctx, topKey, float64(cost), identifier)
+	pipe.Expire(ctx, topKey, 2*rule.WindowSize+time.Second)
+
+	if count > int64(cost) {
+		return
+	}
+	pipe.ZAdd(ctx, identifierIndexKey, redis.Z{Score: float64(now.Unix()), Member: identifier})
+	trimIndex(ctx, pipe, identifierIndexKey, now.Add(-identifierRetention), maxIndexedIdentifiers)
+}
+
+// indexBlock records a block in the blocked index (best effort)
+func (l *RedisLimiter) indexBlock(ctx context.Context, identifier, action string, until time.Time) {
+	pipe := l.client.Pipeline()
+	pipe.ZAdd(ctx, blockedIndexKey, redis.Z{
+		Score:  float64(until.Unix()),
+		Member: overrideKey(identifier, action),
+	})
+	trimIndex(ctx, pipe, blockedIndexKey, l.clock.Now(), maxIndexedBlocks)
+	pipe.Exec(ctx)
+}
+
+// indexDenylist records a denylist entry in the denied index (best effort)
+func (l *RedisLimiter) indexDenylist(ctx context.Context, identifier string, until time.Time) {
+	pipe := l.client.Pipeline()
+	pipe.ZAdd(ctx, deniedIndexKey, redis.Z{Score: float64(until.Unix()), Member: identifier})
+	trimIndex(ctx, pipe, deniedIndexKey, l.clock.Now(), maxIndexedBlocks)
+	pipe.Exec(ctx)
+}
+
+// trimIndex queues the removal of the entries of a time-scored index up to
+// expired, and of the lowest-scored entries beyond max
+func trimIndex(ctx context.Context, pipe redis.Pipeliner, key string, expired time.Time, max int64) {
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(expired.Unix(), 10))
+	pipe.ZRemRangeByRank(ctx, key, 0, -max-1)
+}
+
+// BlockedIdentifiers lists identifiers with an active block or denylist entry
+// limit <= 0 returns every entry
+func (l *RedisLimiter) BlockedIdentifiers(ctx context.Context, limit int) ([]BlockedIdentifier, error) {
+	now := l.clock.Now()
+	min := "(" + strconv.FormatInt(now.Unix(), 10)
+
+	// Drop expired entries so the indexes stay small
+	pipe := l.client.Pipeline()
+	pipe.ZRemRangeByScore(ctx, blockedIndexKey, "-inf", strconv.FormatInt(now.Unix(), 10))
+	pipe.ZRemRangeByScore(ctx, deniedIndexKey, "-inf", strconv.FormatInt(now.Unix(), 10))
+	blockedCmd := pipe.ZRangeByScoreWithScores(ctx, blockedIndexKey, &redis.ZRangeBy{
+		Min: min, Max: "+inf", Count: int64(limit),
+	})
+	deniedCmd := pipe.ZRangeByScoreWithScores(ctx, deniedIndexKey, &redis.ZRangeBy{
+		Min: min, Max: "+inf", Count: int64(limit),
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	blocked := []BlockedIdentifier{}
+	for _, z := range deniedCmd.Val() {
+		identifier, _ := z.Member.(string)
+		blocked = append(blocked, newBlockedIdentifier(identifier, DenylistAction, time.Unix(int64(z.Score), 0), now))
+	}
+	for _, z := range blockedCmd.Val() {
+		member, _ := z.Member.(string)
+		identifier, action, ok := strings.Cut(member, "|")
+		if !ok {
+			continue
+		}
+		blocked = append(blocked, newBlockedIdentifier(identifier, action, time.Unix(int64(z.Score), 0), now))
+	}
+
+	sortBlocked(blocked)
+	if limit > 0 && len(blocked) > limit {
+		blocked = blocked[:limit]
+	}
+
+	return blocked, nil
+}
+
+// TopIdentifiers lists the identifiers with the most attempts for action in
+// the current window bucket
+func (l *RedisLimiter) TopIdentifiers(ctx context.Context, action string, limit int) ([]IdentifierAttempts, error) {
+	rule, exists := l.GetRule(action)
+	if !exists {
+		return nil, fmt.Errorf("no rule configured for action: %s", action)
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	values, err := l.client.ZRevRangeWithScores(ctx,
+		l.makeTopKey(action, rule.WindowSize, l.clock.Now()), 0, int64(limit-1),
+	).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	top := make([]IdentifierAttempts, 0, len(values))
+	for _, z := range values {
+		identifier, _ := z.Member.(string)
+		top = append(top, IdentifierAttempts{
+			Identifier: identifier,
+			Action:     action,
+			Attempts:   int64(z.Score),
+		})
+	}
+
+	return top, nil
+}
+
+// SearchIdentifiers lists identifiers seen in the last 24h starting with prefix
+// Iterates the index with ZSCAN so Redis is never blocked
+func (l *RedisLimiter) SearchIdentifiers(ctx context.Context, prefix string, limit int) ([]IdentifierActivity, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	cutoff := l.clock.Now().Add(-identifierRetention).Unix()
+	if err := l.client.ZRemRangeByScore(ctx, identifierIndexKey, "-inf", strconv.FormatInt(cutoff, 10)).Err(); err != nil {
+		return nil, err
+	}
+
+	match := escapeGlob(prefix) + "*"
+	found := []IdentifierActivity{}
+
+	var cursor uint64
+	for {
+		values, next, err := l.client.ZScan(ctx, identifierIndexKey, cursor, match, scanBatchSize).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		// ZSCAN returns member, score pairs
+		for i := 0; i+1 < len(values) && len(found) < limit; i += 2 {
+			lastSeen, _ := strconv.ParseFloat(values[i+1], 64)
+			found = append(found, IdentifierActivity{
+				Identifier: values[i],
+				LastSeen:   time.Unix(int64(lastSeen), 0),
+			})
+		}
+
+		cursor = next
+		if cursor == 0 || len(found) >= limit {
+			break
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Identifier < found[j].Identifier
+	})
+
+	return found, nil
+}
+
+// escapeGlob escapes glob characters so prefix matches literally
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ============================================================================
+// MEMORY LIMITER
+// ============================================================================
+
+// BlockedIdentifiers lists identifiers with an active block or denylist entry
+// limit <= 0 returns every entry
+func (l *MemoryLimiter) BlockedIdentifiers(ctx context.Context, limit int) ([]BlockedIdentifier, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := l.clock.Now()
+	blocked := []BlockedIdentifier{}
+
+	for identifier, until := range l.denylist {
+		if now.Before(until) {
+			blocked = append(blocked, newBlockedIdentifier(identifier, DenylistAction, until, now))
+		}
+	}
+	for _, log := range l.logs {
+		if log.Blocked && log.BlockedUntil != nil && now.Before(*log.BlockedUntil) {
+			blocked = append(blocked, newBlockedIdentifier(log.Identifier, log.Action, *log.BlockedUntil, now))
+		}
+	}
+
+	sortBlocked(blocked)
+	if limit > 0 && len(blocked) > limit {
+		blocked = blocked[:limit]
+	}
+
+	return blocked, nil
+}
+
+// TopIdentifiers lists the identifiers with the most attempts for action in
+// their current window
+func (l *MemoryLimiter) TopIdentifiers(ctx context.Context, action string, limit int) ([]IdentifierAttempts, error) {
+	if _, exists := l.GetRule(action); !exists {
+		return nil, fmt.Errorf("no rule configured for action: %s", action)
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	l.mu.RLock()
+	now := l.clock.Now()
+	top := []IdentifierAttempts{}
+	for _, log := range l.logs {
+		if log.Action == action && now.Before(log.WindowEnd) {
+			top = append(top, IdentifierAttempts{
+				Identifier: log.Identifier,
+				Action:     action,
+				Attempts:   int64(log.Count),
+			})
+		}
+	}
+	l.mu.RUnlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		return top[i].Attempts > top[j].Attempts
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+
+	return top, nil
+}
+
+// SearchIdentifiers lists tracked identifiers starting with prefix
+func (l *MemoryLimiter) SearchIdentifiers(ctx context.Context, prefix string, limit int) ([]IdentifierActivity, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	l.mu.RLock()
+	lastSeen := make(map[string]time.Time)
+	for _, log := range l.logs {
+		if strings.HasPrefix(log.Identifier, prefix) && log.UpdatedAt.After(lastSeen[log.Identifier]) {
+			lastSeen[log.Identifier] = log.UpdatedAt
+		}
+	}
+	l.mu.RUnlock()
+
+	found := make([]IdentifierActivity, 0, len(lastSeen))
+	for identifier, seen := range lastSeen {
+		found = append(found, IdentifierActivity{Identifier: identifier, LastSeen: seen})
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Identifier < found[j].Identifier
+	})
+	if len(found) > limit {
+		found = found[:limit]
+	}
+
+	return found, nil
+}
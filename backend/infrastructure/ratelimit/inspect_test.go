@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestIdentifierIndexWrittenOncePerWindow(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	rule := NewRule("search").MaxAttempts(100).Window(time.Minute).BlockFor(time.Minute).Build()
+	limiter := NewRedisLimiter(client, []*RateLimitRule{rule}, clk)
+	t.Cleanup(func() { limiter.Close() })
+
+	lastSeen := func() time.Time {
+		t.Helper()
+		score, err := client.ZScore(ctx, identifierIndexKey, "ip:1.2.3.4").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return time.Unix(int64(score), 0).UTC()
+	}
+
+	start := clk.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := limiter.RecordAttempt(ctx, "ip:1.2.3.4", "search"); err != nil {
+			t.Fatal(err)
+		}
+		clk.Advance(time.Second)
+	}
+	if seen := lastSeen(); !seen.Equal(start) {
+		t.Errorf("last seen = %v, want the window start %v", seen, start)
+	}
+
+	// The next window records the identifier again
+	clk.Advance(time.Minute)
+	server.FastForward(time.Minute + 3*time.Second)
+	if _, err := limiter.RecordAttempt(ctx, "ip:1.2.3.4", "search"); err != nil {
+		t.Fatal(err)
+	}
+	if seen := lastSeen(); !seen.Equal(clk.Now()) {
+		t.Errorf("last seen = %v, want %v", seen, clk.Now())
+	}
+}
+
+func TestTrimIndex(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+	now := time.Unix(1000, 0)
+	for i, member := range []string{"a", "b", "c", "d", "e"} {
+		client.ZAdd(ctx, "index", redis.Z{Score: float64(now.Unix()) + float64(i-1), Member: member})
+	}
+
+	pipe := client.Pipeline()
+	trimIndex(ctx, pipe, "index", now, 2)
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// a and b are expired, c is dropped by the cap
+	members, err := client.ZRange(ctx, "index", 0, -1).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 2 || members[0] != "d" || members[1] != "e" {
+		t.Errorf("members = %v, want [d e]", members)
+	}
+}
@@ -12,6 +12,10 @@ import (
 // This defines the contract for rate limiting implementations.
 // Implementations can use Redis, memory, database, or any other storage.
 
+// IndefiniteBlockDuration is how long Block(..., 0) blocks: Redis keys need
+// an expiry, so "indefinite" blocks end after a year
+const IndefiniteBlockDuration = 365 * 24 * time.Hour
+
 // Limiter defines the interface for rate limiting operations.
 // All business logic lives in the implementations (MemoryLimiter, RedisLimiter),
 // not in the models.
@@ -46,11 +50,20 @@ type Limiter interface {
 	//   if err != nil {
 	//       return err
 	//   }
-	//   if !status.IsAllowed() {
+	//   if !status.IsAllowedAt(clk.Now()) {
 	//       return RateLimitError{Status: status}
 	//   }
 	RecordAttempt(ctx context.Context, identifier, action string) (*RateLimitStatus, error)
 
+	// RecordAttemptWithCost is RecordAttempt for weighted requests.
+	// The counter is incremented by cost instead of 1, so expensive operations
+	// (uploads, exports, geospatial searches) consume more of the quota.
+	// A cost below 1 is treated as 1.
+	//
+	// RecordAttempt(ctx, id, action) is equivalent to
+	// RecordAttemptWithCost(ctx, id, action, 1).
+	RecordAttemptWithCost(ctx context.Context, identifier, action string, cost int) (*RateLimitStatus, error)
+
 	// GetStatus gets current rate limit status without recording an attempt.
 	// Similar to Check(), but returns detailed status information.
 	//
@@ -69,7 +82,8 @@ type Limiter interface {
 
 	// Block manually blocks an identifier for an action.
 	//
-	// If duration is nil or zero, blocks indefinitely (until manual Unblock).
+	// If duration is zero, blocks indefinitely (until manual Unblock or
+	// IndefiniteBlockDuration elapses).
 	// If duration is provided, blocks for that specific duration.
 	//
 	// This is typically used by admins to manually block abusive users.
@@ -136,9 +150,9 @@ type Repository interface {
 // Example usage in HTTP middleware:
 //
 //	result := &CheckResult{
-//	    Allowed: status.IsAllowed(),
+//	    Allowed: status.IsAllowedAt(now),
 //	    Status: status,
-//	    RetryAfter: status.TimeUntilReset(),
+//	    RetryAfter: status.TimeUntilResetAt(now),
 //	    ResetAt: &status.WindowEnd,
 //	}
 //	if !result.Allowed {
@@ -160,6 +174,9 @@ type CheckResult struct {
 	// ResetAt indicates when the rate limit resets (for HTTP X-RateLimit-Reset header)
 	// This is nil if no active rate limit
 	ResetAt *time.Time
+
+	// CheckedAt is the time the result was computed for
+	CheckedAt time.Time
 }
 
 // ShouldRetry indicates if the client should retry later
@@ -169,22 +186,26 @@ func (r *CheckResult) ShouldRetry() bool {
 
 // HTTPHeaders returns suggested HTTP headers for rate limit response
 // This is a convenience method for HTTP handlers
+//
+// Emits the IETF RateLimit-Policy / RateLimit fields plus the legacy
+// X-RateLimit-* headers; Retry-After is always set when not allowed.
 func (r *CheckResult) HTTPHeaders() map[string]string {
-	headers := make(map[string]string)
+	if r.Status == nil {
+		return make(map[string]string)
+	}
 
-	if r.Status != nil {
-		// Standard rate limit headers (draft RFC)
-		headers["X-RateLimit-Limit"] = fmt.Sprintf("%d", r.Status.MaxAttempts)
-		headers["X-RateLimit-Remaining"] = fmt.Sprintf("%d", r.Status.RemainingTries)
+	headers := BuildHeaders(r.CheckedAt, true, r.Status)
 
-		if r.ResetAt != nil {
-			headers["X-RateLimit-Reset"] = fmt.Sprintf("%d", r.ResetAt.Unix())
+	if !r.Allowed && r.RetryAfter != nil {
+		retryAfter := int(r.RetryAfter.Seconds())
+		if retryAfter < 0 {
+			retryAfter = 0
 		}
+		headers[HeaderRetryAfter] = fmt.Sprintf("%d", retryAfter)
+	}
 
-		// Retry-After header for blocked requests (HTTP standard)
-		if !r.Allowed && r.RetryAfter != nil {
-			headers["Retry-After"] = fmt.Sprintf("%d", int(r.RetryAfter.Seconds()))
-		}
+	if r.ResetAt != nil {
+		headers[HeaderLegacyReset] = fmt.Sprintf("%d", r.ResetAt.Unix())
 	}
 
 	return headers
@@ -194,20 +215,21 @@ func (r *CheckResult) HTTPHeaders() map[string]string {
 // HELPER FUNCTIONS
 // ============================================================================
 
-// ToCheckResult converts a RateLimitStatus to CheckResult
+// ToCheckResult converts a RateLimitStatus to CheckResult at now
 // This is a convenience function for middleware/handler layers
-func ToCheckResult(status *RateLimitStatus) *CheckResult {
+func ToCheckResult(status *RateLimitStatus, now time.Time) *CheckResult {
 	if status == nil {
-		return &CheckResult{Allowed: true}
+		return &CheckResult{Allowed: true, CheckedAt: now}
 	}
 
 	result := &CheckResult{
-		Allowed: status.IsAllowed(),
-		Status:  status,
+		Allowed:   status.IsAllowedAt(now),
+		Status:    status,
+		CheckedAt: now,
 	}
 
 	if !result.Allowed {
-		retryAfter := status.TimeUntilReset()
+		retryAfter := status.TimeUntilResetAt(now)
 		result.RetryAfter = &retryAfter
 		result.ResetAt = &status.WindowEnd
 
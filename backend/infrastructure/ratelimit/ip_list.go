@@ -0,0 +1,184 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+)
+
+// ============================================================================
+// CIDR ALLOW / DENY LISTS
+// ============================================================================
+// Allowlisted networks (partner NGOs, internal monitoring) bypass rate limits.
+// Denylisted networks are rejected before any limiter is consulted.
+// Entries live in Postgres (rate_limit_ip_rules) and are cached in IPList.
+
+// IPListType is the list an IP rule belongs to
+type IPListType string
+
+const (
+	IPListAllow IPListType = "allow"
+	IPListDeny  IPListType = "deny"
+)
+
+// IsValid checks if the list type is supported
+func (t IPListType) IsValid() bool {
+	return t == IPListAllow || t == IPListDeny
+}
+
+// IPRule is one CIDR entry of the allow or deny list
+type IPRule struct {
+	ID        int        `json:"id" db:"id"`
+	CIDR      string     `json:"cidr" db:"cidr"`
+	ListType  IPListType `json:"list_type" db:"list_type"`
+	Reason    *string    `json:"reason,omitempty" db:"reason"`
+	CreatedBy *int       `json:"created_by,omitempty" db:"created_by"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsExpiredAt checks if the rule has expired at now
+func (r *IPRule) IsExpiredAt(now time.Time) bool {
+	return r.ExpiresAt != nil && now.After(*r.ExpiresAt)
+}
+
+// IPListStore persists IP rules
+type IPListStore interface {
+	// ListIPRules lists all IP rules, including expired ones
+	ListIPRules(ctx context.Context) ([]*IPRule, error)
+
+	// SaveIPRule creates or updates the rule for rule.CIDR
+	SaveIPRule(ctx context.Context, rule *IPRule) error
+
+	// DeleteIPRule removes a rule by id
+	DeleteIPRule(ctx context.Context, id int) error
+}
+
+// ============================================================================
+// IN-MEMORY CACHE
+// ============================================================================
+
+// ipEntry is a parsed rule
+type ipEntry struct {
+	network *net.IPNet
+	rule    *IPRule
+}
+
+// IPList caches the IP rules of a store for per-request matching
+type IPList struct {
+	store IPListStore
+	clock clock.Clock
+
+	mu      sync.RWMutex
+	entries []ipEntry
+}
+
+// NewIPList creates an IP list backed by store (call Reload to populate).
+// Pass the limiter's clock so rules expire on the same time as blocks.
+func NewIPList(store IPListStore, clk clock.Clock) *IPList {
+	return &IPList{store: store, clock: clock.OrSystem(clk)}
+}
+
+// Match returns the rule covering ip, deny entries win over allow entries
+func (l *IPList) Match(ip net.IP) (*IPRule, bool) {
+	if ip == nil {
+		return nil, false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := l.clock.Now()
+	var allowed *IPRule
+	for _, entry := range l.entries {
+		if entry.rule.IsExpiredAt(now) || !entry.network.Contains(ip) {
+			continue
+		}
+		if entry.rule.ListType == IPListDeny {
+			return entry.rule, true
+		}
+		if allowed == nil {
+			allowed = entry.rule
+		}
+	}
+
+	return allowed, allowed != nil
+}
+
+// Rules returns all cached rules
+func (l *IPList) Rules() []*IPRule {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	rules := make([]*IPRule, 0, len(l.entries))
+	for _, entry := range l.entries {
+		rules = append(rules, entry.rule)
+	}
+
+	return rules
+}
+
+// Reload replaces the cache with the rules from the store
+func (l *IPList) Reload(ctx context.Context) error {
+	rules, err := l.store.ListIPRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	l.replace(rules)
+	return nil
+}
+
+// Save validates and stores a rule, then reloads the cache
+func (l *IPList) Save(ctx context.Context, rule *IPRule) error {
+	if !rule.ListType.IsValid() {
+		return ErrInvalidIPRule
+	}
+
+	network, err := ParseCIDR(rule.CIDR)
+	if err != nil {
+		return ErrInvalidIPRule
+	}
+	rule.CIDR = network.String()
+
+	if err := l.store.SaveIPRule(ctx, rule); err != nil {
+		return err
+	}
+
+	return l.Reload(ctx)
+}
+
+// Delete removes a rule, then reloads the cache
+func (l *IPList) Delete(ctx context.Context, id int) error {
+	if err := l.store.DeleteIPRule(ctx, id); err != nil {
+		return err
+	}
+
+	return l.Reload(ctx)
+}
+
+// replace swaps the cached entries (rules with an invalid CIDR are skipped)
+func (l *IPList) replace(rules []*IPRule) {
+	entries := make([]ipEntry, 0, len(rules))
+	for _, rule := range rules {
+		network, err := ParseCIDR(rule.CIDR)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, ipEntry{network: network, rule: rule})
+	}
+
+	l.mu.Lock()
+	l.entries = entries
+	l.mu.Unlock()
+}
+
+// StartIPListSync periodically reloads the list so edits made on one
+// instance reach every instance. It stops when ctx is cancelled.
+func StartIPListSync(ctx context.Context, list *IPList, interval time.Duration) {
+	startPeriodicSync(ctx, interval, "IP rules", list.Reload)
+}
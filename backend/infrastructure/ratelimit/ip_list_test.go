@@ -0,0 +1,26 @@
+package ratelimit
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+)
+
+func TestIPListRuleExpiresOnListClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	expiresAt := clk.Now().Add(time.Hour)
+	list := NewIPList(nil, clk)
+	list.replace([]*IPRule{{ID: 1, CIDR: "203.0.113.0/24", ListType: IPListDeny, ExpiresAt: &expiresAt}})
+
+	ip := net.ParseIP("203.0.113.7")
+	if _, ok := list.Match(ip); !ok {
+		t.Fatal("rule should match before it expires")
+	}
+
+	clk.Advance(time.Hour + time.Second)
+	if rule, ok := list.Match(ip); ok {
+		t.Errorf("expired rule matched: %+v", rule)
+	}
+}
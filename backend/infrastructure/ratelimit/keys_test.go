@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"strings"
+	"testing"
+)
+
+// hashTag returns the part of key Redis Cluster hashes to pick a slot
+func hashTag(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
+
+func TestIdentifierKeysShareSlot(t *testing.T) {
+	limiter := &RedisLimiter{}
+	concurrency := &ConcurrencyLimiter{}
+
+	identifiers := []string{
+		"ip:1.2.3.4",
+		"email:" + strings.Repeat("a", 64),
+		"user_id:{42}", // braces would otherwise end the tag early
+	}
+
+	for _, identifier := range identifiers {
+		keys := []string{
+			limiter.makeKey(identifier, "login"),
+			limiter.makeBlockKey(identifier, "login"),
+			limiter.makeShadowBlockKey(identifier, "login"),
+			limiter.makeDenyKey(identifier),
+			limiter.makeOffenseKey(identifier),
+			concurrency.makeKey(identifier, ActionConcurrentUpload),
+		}
+
+		want := hashTag(keys[0])
+		if want == keys[0] {
+			t.Fatalf("%q: key %q has no hash tag", identifier, keys[0])
+		}
+		for _, key := range keys[1:] {
+			if got := hashTag(key); got != want {
+				t.Errorf("%q: key %q hashes on %q, want %q", identifier, key, got, want)
+			}
+		}
+	}
+
+	// Different identifiers spread across slots
+	if hashTag(limiter.makeKey("ip:1.1.1.1", "login")) == hashTag(limiter.makeKey("ip:2.2.2.2", "login")) {
+		t.Error("different identifiers share a hash tag")
+	}
+}
@@ -0,0 +1,499 @@
+// Package limitertest is a conformance suite for ratelimit.Limiter
+// implementations. Every limiter must pass it so handlers behave the same
+// whichever store backs them:
+//
+//	func TestMyLimiter(t *testing.T) {
+//		limitertest.Run(t, func(t *testing.T, clk clock.Clock, rules []*ratelimit.RateLimitRule) limitertest.Target {
+//			return limitertest.Target{Limiter: NewMyLimiter(rules, clk)}
+//		})
+//	}
+package limitertest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
+)
+
+// ============================================================================
+// SUITE SETUP
+// ============================================================================
+
+// Target is a limiter under test
+type Target struct {
+	Limiter ratelimit.Limiter
+
+	// Advance lets the storage follow the fake clock (e.g. miniredis
+	// FastForward for key TTLs). Optional.
+	Advance func(d time.Duration)
+}
+
+// Factory creates a fresh limiter with rules that reads time from clk
+type Factory func(t *testing.T, clk clock.Clock, rules []*ratelimit.RateLimitRule) Target
+
+const (
+	actionLogin    = "conformance_login"
+	actionDisabled = "conformance_disabled"
+	actionOff      = "conformance_off"
+	actionUnknown  = "conformance_unknown"
+
+	maxAttempts   = 3
+	window        = 1 * time.Minute
+	blockDuration = 5 * time.Minute
+)
+
+// start is a whole second so Unix-second storage round-trips exactly
+var start = time.Unix(1_700_000_000, 0)
+
+// rules returns fresh rules for every test (limiters may mutate them)
+func rules() []*ratelimit.RateLimitRule {
+	return []*ratelimit.RateLimitRule{
+		{
+			Action:        actionLogin,
+			MaxAttempts:   maxAttempts,
+			WindowSize:    window,
+			BlockDuration: blockDuration,
+			IsActive:      true,
+		},
+		{
+			Action:        actionDisabled,
+			MaxAttempts:   1,
+			WindowSize:    window,
+			BlockDuration: blockDuration,
+			IsActive:      false,
+		},
+		{
+			Action:        actionOff,
+			MaxAttempts:   1,
+			WindowSize:    window,
+			BlockDuration: blockDuration,
+			IsActive:      true,
+			Mode:          ratelimit.ModeOff,
+		},
+	}
+}
+
+// harness is one test's limiter and fake clock
+type harness struct {
+	t       *testing.T
+	ctx     context.Context
+	clock   *clock.Fake
+	limiter ratelimit.Limiter
+	advance func(d time.Duration)
+}
+
+func newHarness(t *testing.T, factory Factory) *harness {
+	t.Helper()
+
+	clk := clock.NewFake(start)
+	target := factory(t, clk, rules())
+
+	return &harness{
+		t:       t,
+		ctx:     context.Background(),
+		clock:   clk,
+		limiter: target.Limiter,
+		advance: target.Advance,
+	}
+}
+
+// Advance moves the fake clock and the storage forward by d
+func (h *harness) Advance(d time.Duration) {
+	h.clock.Advance(d)
+	if h.advance != nil {
+		h.advance(d)
+	}
+}
+
+func (h *harness) record(identifier, action string) *ratelimit.RateLimitStatus {
+	h.t.Helper()
+
+	status, err := h.limiter.RecordAttempt(h.ctx, identifier, action)
+	if err != nil {
+		h.t.Fatalf("RecordAttempt(%q, %q): %v", identifier, action, err)
+	}
+	return status
+}
+
+func (h *harness) status(identifier, action string) *ratelimit.RateLimitStatus {
+	h.t.Helper()
+
+	status, err := h.limiter.GetStatus(h.ctx, identifier, action)
+	if err != nil {
+		h.t.Fatalf("GetStatus(%q, %q): %v", identifier, action, err)
+	}
+	return status
+}
+
+func (h *harness) check(identifier, action string) bool {
+	h.t.Helper()
+
+	allowed, err := h.limiter.Check(h.ctx, identifier, action)
+	if err != nil {
+		h.t.Fatalf("Check(%q, %q): %v", identifier, action, err)
+	}
+	return allowed
+}
+
+// exhaust records attempts until the rule blocks
+func (h *harness) exhaust(identifier string) *ratelimit.RateLimitStatus {
+	h.t.Helper()
+
+	var status *ratelimit.RateLimitStatus
+	for i := 0; i < maxAttempts; i++ {
+		status = h.record(identifier, actionLogin)
+	}
+	if !status.Blocked {
+		h.t.Fatalf("not blocked after %d attempts: %+v", maxAttempts, status)
+	}
+	return status
+}
+
+// ============================================================================
+// SUITE
+// ============================================================================
+
+// Run runs the conformance suite against limiters created by factory
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, h *harness)
+	}{
+		{"RemainingTries", testRemainingTries},
+		{"CostCountsAgainstQuota", testCost},
+		{"BlocksAtMaxAttempts", testBlocksAtMaxAttempts},
+		{"BlockExpires", testBlockExpires},
+		{"BlockOutlivesWindow", testBlockOutlivesWindow},
+		{"WindowReset", testWindowReset},
+		{"GetStatusDoesNotRecord", testGetStatusDoesNotRecord},
+		{"Reset", testReset},
+		{"ManualBlockAndUnblock", testManualBlockAndUnblock},
+		{"DisabledRules", testDisabledRules},
+		{"UnknownAction", testUnknownAction},
+		{"IdentifiersAreIsolated", testIsolation},
+		{"ConcurrentAttempts", testConcurrentAttempts},
+		{"ConcurrentIdentifiers", testConcurrentIdentifiers},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newHarness(t, factory))
+		})
+	}
+}
+
+func testRemainingTries(t *testing.T, h *harness) {
+	for i := 1; i < maxAttempts; i++ {
+		status := h.record("1.1.1.1", actionLogin)
+
+		if status.Count != i {
+			t.Errorf("attempt %d: Count = %d, want %d", i, status.Count, i)
+		}
+		if want := maxAttempts - i; status.RemainingTries != want {
+			t.Errorf("attempt %d: RemainingTries = %d, want %d", i, status.RemainingTries, want)
+		}
+		if status.MaxAttempts != maxAttempts {
+			t.Errorf("attempt %d: MaxAttempts = %d, want %d", i, status.MaxAttempts, maxAttempts)
+		}
+		if status.Blocked {
+			t.Errorf("attempt %d: blocked before reaching the limit", i)
+		}
+		if !status.WindowEnd.Equal(start.Add(window)) {
+			t.Errorf("attempt %d: WindowEnd = %v, want %v", i, status.WindowEnd, start.Add(window))
+		}
+		if !h.check("1.1.1.1", actionLogin) {
+			t.Errorf("attempt %d: Check = false, want true", i)
+		}
+	}
+}
+
+func testCost(t *testing.T, h *harness) {
+	status, err := h.limiter.RecordAttemptWithCost(h.ctx, "1.1.1.1", actionLogin, 2)
+	if err != nil {
+		t.Fatalf("RecordAttemptWithCost: %v", err)
+	}
+	if status.Count != 2 || status.RemainingTries != maxAttempts-2 {
+		t.Errorf("cost 2: Count = %d, RemainingTries = %d, want 2, %d", status.Count, status.RemainingTries, maxAttempts-2)
+	}
+
+	// A cost below 1 is treated as 1 and exhausts the quota here
+	status, err = h.limiter.RecordAttemptWithCost(h.ctx, "1.1.1.1", actionLogin, 0)
+	if err != nil {
+		t.Fatalf("RecordAttemptWithCost: %v", err)
+	}
+	if status.Count != 3 || !status.Blocked {
+		t.Errorf("cost 0: Count = %d, Blocked = %t, want 3, true", status.Count, status.Blocked)
+	}
+}
+
+func testBlocksAtMaxAttempts(t *testing.T, h *harness) {
+	status := h.exhaust("1.1.1.1")
+
+	wantUntil := start.Add(blockDuration)
+	if status.RemainingTries != 0 {
+		t.Errorf("RemainingTries = %d, want 0", status.RemainingTries)
+	}
+	if status.BlockedUntil == nil || !status.BlockedUntil.Equal(wantUntil) {
+		t.Errorf("BlockedUntil = %v, want %v", status.BlockedUntil, wantUntil)
+	}
+
+	// Attempts while blocked stay blocked until the same time
+	h.Advance(10 * time.Second)
+	status = h.record("1.1.1.1", actionLogin)
+	if !status.Blocked || status.RemainingTries != 0 {
+		t.Errorf("attempt while blocked: Blocked = %t, RemainingTries = %d", status.Blocked, status.RemainingTries)
+	}
+	if status.BlockedUntil == nil || !status.BlockedUntil.Equal(wantUntil) {
+		t.Errorf("attempt while blocked: BlockedUntil = %v, want %v", status.BlockedUntil, wantUntil)
+	}
+
+	status = h.status("1.1.1.1", actionLogin)
+	if !status.Blocked || status.RemainingTries != 0 {
+		t.Errorf("GetStatus while blocked: Blocked = %t, RemainingTries = %d", status.Blocked, status.RemainingTries)
+	}
+	if h.check("1.1.1.1", actionLogin) {
+		t.Error("Check while blocked = true, want false")
+	}
+}
+
+func testBlockExpires(t *testing.T, h *harness) {
+	h.exhaust("1.1.1.1")
+
+	h.Advance(blockDuration - time.Second)
+	if h.check("1.1.1.1", actionLogin) {
+		t.Error("Check 1s before the block ends = true, want false")
+	}
+
+	h.Advance(time.Second)
+	if !h.check("1.1.1.1", actionLogin) {
+		t.Error("Check when the block ends = false, want true")
+	}
+
+	// The window ended long ago, so counting starts over
+	status := h.record("1.1.1.1", actionLogin)
+	if status.Blocked || status.Count != 1 || status.RemainingTries != maxAttempts-1 {
+		t.Errorf("after block: Blocked = %t, Count = %d, RemainingTries = %d", status.Blocked, status.Count, status.RemainingTries)
+	}
+}
+
+func testBlockOutlivesWindow(t *testing.T, h *harness) {
+	h.exhaust("1.1.1.1")
+
+	// The window is over but the block is not
+	h.Advance(window + time.Second)
+
+	status := h.status("1.1.1.1", actionLogin)
+	if !status.Blocked || status.RemainingTries != 0 {
+		t.Errorf("GetStatus after window: Blocked = %t, RemainingTries = %d, want true, 0", status.Blocked, status.RemainingTries)
+	}
+	if h.check("1.1.1.1", actionLogin) {
+		t.Error("Check after window = true, want false")
+	}
+	if status := h.record("1.1.1.1", actionLogin); !status.Blocked {
+		t.Error("RecordAttempt after window: not blocked")
+	}
+}
+
+func testWindowReset(t *testing.T, h *harness) {
+	h.record("1.1.1.1", actionLogin)
+	h.record("1.1.1.1", actionLogin)
+
+	h.Advance(window - time.Second)
+	if status := h.status("1.1.1.1", actionLogin); status.Count != 2 {
+		t.Errorf("Count before window end = %d, want 2", status.Count)
+	}
+
+	h.Advance(time.Second)
+	if status := h.status("1.1.1.1", actionLogin); status.Count != 0 || status.RemainingTries != maxAttempts {
+		t.Errorf("after window: Count = %d, RemainingTries = %d, want 0, %d", status.Count, status.RemainingTries, maxAttempts)
+	}
+
+	status := h.record("1.1.1.1", actionLogin)
+	if status.Count != 1 || status.RemainingTries != maxAttempts-1 {
+		t.Errorf("new window: Count = %d, RemainingTries = %d", status.Count, status.RemainingTries)
+	}
+	if want := h.clock.Now().Add(window); !status.WindowEnd.Equal(want) {
+		t.Errorf("new window: WindowEnd = %v, want %v", status.WindowEnd, want)
+	}
+}
+
+func testGetStatusDoesNotRecord(t *testing.T, h *harness) {
+	status := h.status("1.1.1.1", actionLogin)
+	if status.Count != 0 || status.RemainingTries != maxAttempts || status.Blocked {
+		t.Errorf("fresh status: Count = %d, RemainingTries = %d, Blocked = %t", status.Count, status.RemainingTries, status.Blocked)
+	}
+
+	h.record("1.1.1.1", actionLogin)
+	for i := 0; i < 5; i++ {
+		h.status("1.1.1.1", actionLogin)
+		h.check("1.1.1.1", actionLogin)
+	}
+
+	if status := h.status("1.1.1.1", actionLogin); status.Count != 1 {
+		t.Errorf("Count after reads = %d, want 1", status.Count)
+	}
+}
+
+func testReset(t *testing.T, h *harness) {
+	h.exhaust("1.1.1.1")
+
+	if err := h.limiter.Reset(h.ctx, "1.1.1.1", actionLogin); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+
+	status := h.status("1.1.1.1", actionLogin)
+	if status.Blocked || status.Count != 0 || status.RemainingTries != maxAttempts {
+		t.Errorf("after Reset: Blocked = %t, Count = %d, RemainingTries = %d", status.Blocked, status.Count, status.RemainingTries)
+	}
+	if status := h.record("1.1.1.1", actionLogin); status.Count != 1 {
+		t.Errorf("first attempt after Reset: Count = %d, want 1", status.Count)
+	}
+}
+
+func testManualBlockAndUnblock(t *testing.T, h *harness) {
+	if err := h.limiter.Block(h.ctx, "1.1.1.1", actionLogin, 10*time.Minute); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+
+	status := h.status("1.1.1.1", actionLogin)
+	wantUntil := start.Add(10 * time.Minute)
+	if !status.Blocked || status.BlockedUntil == nil || !status.BlockedUntil.Equal(wantUntil) {
+		t.Errorf("after Block: Blocked = %t, BlockedUntil = %v, want true, %v", status.Blocked, status.BlockedUntil, wantUntil)
+	}
+	if h.check("1.1.1.1", actionLogin) {
+		t.Error("Check after Block = true, want false")
+	}
+	if status := h.record("1.1.1.1", actionLogin); !status.Blocked {
+		t.Error("RecordAttempt after Block: not blocked")
+	}
+
+	if err := h.limiter.Unblock(h.ctx, "1.1.1.1", actionLogin); err != nil {
+		t.Fatalf("Unblock: %v", err)
+	}
+	if !h.check("1.1.1.1", actionLogin) {
+		t.Error("Check after Unblock = false, want true")
+	}
+
+	// Unblock clears the counter too
+	status = h.record("1.1.1.1", actionLogin)
+	if status.Blocked || status.Count != 1 {
+		t.Errorf("attempt after Unblock: Blocked = %t, Count = %d, want false, 1", status.Blocked, status.Count)
+	}
+
+	// Duration 0 blocks indefinitely (for a year)
+	if err := h.limiter.Block(h.ctx, "2.2.2.2", actionLogin, 0); err != nil {
+		t.Fatalf("Block indefinitely: %v", err)
+	}
+	h.Advance(30 * 24 * time.Hour)
+	if h.check("2.2.2.2", actionLogin) {
+		t.Error("Check 30 days into an indefinite block = true, want false")
+	}
+}
+
+func testDisabledRules(t *testing.T, h *harness) {
+	for _, action := range []string{actionDisabled, actionOff} {
+		for i := 0; i < 5; i++ {
+			status := h.record("1.1.1.1", action)
+			if status.Blocked || status.RemainingTries != -1 {
+				t.Errorf("%s attempt %d: Blocked = %t, RemainingTries = %d, want false, -1", action, i+1, status.Blocked, status.RemainingTries)
+			}
+		}
+		if !h.check("1.1.1.1", action) {
+			t.Errorf("%s: Check = false, want true", action)
+		}
+	}
+}
+
+func testUnknownAction(t *testing.T, h *harness) {
+	status := h.record("1.1.1.1", actionUnknown)
+	if status.Blocked || status.RemainingTries != -1 {
+		t.Errorf("RecordAttempt: Blocked = %t, RemainingTries = %d, want false, -1", status.Blocked, status.RemainingTries)
+	}
+	if status := h.status("1.1.1.1", actionUnknown); status.RemainingTries != -1 {
+		t.Errorf("GetStatus: RemainingTries = %d, want -1", status.RemainingTries)
+	}
+	if !h.check("1.1.1.1", actionUnknown) {
+		t.Error("Check = false, want true")
+	}
+}
+
+func testIsolation(t *testing.T, h *harness) {
+	h.exhaust("1.1.1.1")
+
+	if status := h.record("2.2.2.2", actionLogin); status.Blocked || status.Count != 1 {
+		t.Errorf("other identifier: Blocked = %t, Count = %d, want false, 1", status.Blocked, status.Count)
+	}
+	if status := h.record("1.1.1.1", actionUnknown); status.Blocked {
+		t.Error("other action: blocked")
+	}
+}
+
+func testConcurrentAttempts(t *testing.T, h *harness) {
+	const workers = 50
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			status, err := h.limiter.RecordAttempt(h.ctx, "1.1.1.1", actionLogin)
+			if err != nil {
+				t.Errorf("RecordAttempt: %v", err)
+				return
+			}
+			if !status.Blocked {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The attempt reaching MaxAttempts blocks, so MaxAttempts-1 get through
+	if allowed != maxAttempts-1 {
+		t.Errorf("allowed %d of %d concurrent attempts, want %d", allowed, workers, maxAttempts-1)
+	}
+	if h.check("1.1.1.1", actionLogin) {
+		t.Error("Check after concurrent attempts = true, want false")
+	}
+}
+
+func testConcurrentIdentifiers(t *testing.T, h *harness) {
+	const identifiers = 10
+
+	var wg sync.WaitGroup
+	for i := 0; i < identifiers; i++ {
+		identifier := fmt.Sprintf("10.0.0.%d", i)
+		for j := 0; j < maxAttempts-1; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				if _, err := h.limiter.RecordAttempt(h.ctx, identifier, actionLogin); err != nil {
+					t.Errorf("RecordAttempt: %v", err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	for i := 0; i < identifiers; i++ {
+		identifier := fmt.Sprintf("10.0.0.%d", i)
+		status := h.status(identifier, actionLogin)
+		if status.Count != maxAttempts-1 || status.Blocked {
+			t.Errorf("%s: Count = %d, Blocked = %t, want %d, false", identifier, status.Count, status.Blocked, maxAttempts-1)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
 )
 
 // ============================================================================
@@ -14,9 +16,27 @@ import (
 
 // MemoryLimiter implements Limiter using in-memory storage
 type MemoryLimiter struct {
-	mu    sync.RWMutex
-	logs  map[string]*RateLimitLog  // key: "identifier:action"
-	rules map[string]*RateLimitRule // key: action
+	mu        sync.RWMutex
+	logs      map[string]*RateLimitLog  // key: "identifier:action"
+	rules     map[string]*RateLimitRule // key: action
+	overrides map[string]*RateLimitRule // key: "identifier|action"
+
+	// Repeat offender tracking (nil escalation = constant block durations)
+	escalation *EscalationPolicy
+	events     SecurityEventSink
+	offenses   map[string]*offenseRecord // key: identifier
+	denylist   map[string]time.Time      // key: identifier, value: denylisted until
+
+	// Shadow rules (would-be blocks are tracked apart from real blocks)
+	shadowSink   ShadowSink
+	shadowBlocks map[string]time.Time // key: "identifier:action", value: would-be until
+	shadowHits   map[string]int64     // key: action
+
+	// Where blocks are audited (nil = not audited)
+	audit AuditSink
+
+	// Time source (clock.System unless a test injects a fake)
+	clock clock.Clock
 
 	// Cleanup configuration
 	cleanupInterval time.Duration
@@ -24,10 +44,17 @@ type MemoryLimiter struct {
 }
 
 // NewMemoryLimiter creates a new in-memory rate limiter
-func NewMemoryLimiter(rules []*RateLimitRule) *MemoryLimiter {
+// clk is the time source (nil = clock.System)
+func NewMemoryLimiter(rules []*RateLimitRule, clk clock.Clock) *MemoryLimiter {
 	limiter := &MemoryLimiter{
 		logs:            make(map[string]*RateLimitLog),
 		rules:           make(map[string]*RateLimitRule),
+		overrides:       make(map[string]*RateLimitRule),
+		offenses:        make(map[string]*offenseRecord),
+		denylist:        make(map[string]time.Time),
+		shadowBlocks:    make(map[string]time.Time),
+		shadowHits:      make(map[string]int64),
+		clock:           clock.OrSystem(clk),
 		cleanupInterval: 5 * time.Minute,
 		stopCleanup:     make(chan struct{}),
 	}
@@ -52,18 +79,35 @@ func (l *MemoryLimiter) Check(ctx context.Context, identifier, action string) (b
 		return false, err
 	}
 
-	return status.IsAllowed(), nil
+	return status.IsAllowedAt(l.clock.Now()), nil
 }
 
 // RecordAttempt records an attempt and returns status
-// THIS IS WHERE THE BUSINESS LOGIC IS
 func (l *MemoryLimiter) RecordAttempt(ctx context.Context, identifier, action string) (*RateLimitStatus, error) {
+	return l.RecordAttemptWithCost(ctx, identifier, action, 1)
+}
+
+// RecordAttemptWithCost records an attempt weighing cost units against the quota
+// THIS IS WHERE THE BUSINESS LOGIC IS
+func (l *MemoryLimiter) RecordAttemptWithCost(ctx context.Context, identifier, action string, cost int) (*RateLimitStatus, error) {
+	if cost < 1 {
+		cost = 1
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	// Get rule for action
-	rule, exists := l.rules[action]
-	if !exists || !rule.IsActive {
+	now := l.clock.Now()
+
+	// Get rule for action (per-identifier override first)
+	rule, exists := l.ruleFor(identifier, action)
+
+	// Denylisted identifiers are blocked for every action
+	if until, ok := l.denylist[identifier]; ok && now.Before(until) {
+		return denylistedStatus(identifier, action, rule, until), nil
+	}
+
+	if !exists || rule.EffectiveMode() == ModeOff {
 		// No rate limiting for this action
 		return &RateLimitStatus{
 			Identifier:     identifier,
@@ -75,18 +119,40 @@ func (l *MemoryLimiter) RecordAttempt(ctx context.Context, identifier, action st
 		}, nil
 	}
 
+	mode := rule.EffectiveMode()
 	key := l.makeKey(identifier, action)
 	log, exists := l.logs[key]
-	now := time.Now()
+
+	// Shadow rules: a would-be block counts a hit but never rejects
+	if until, ok := l.shadowBlocks[key]; ok && mode == ModeShadow && now.Before(until) {
+		l.recordShadowHit(action)
+
+		count := rule.MaxAttempts
+		if exists {
+			count = log.Count
+		}
+		return asShadow(&RateLimitStatus{
+			Identifier:     identifier,
+			Action:         action,
+			Count:          count,
+			MaxAttempts:    rule.MaxAttempts,
+			Window:         rule.WindowSize,
+			RemainingTries: 0,
+			WindowEnd:      until,
+			Blocked:        true,
+			BlockedUntil:   &until,
+		}), nil
+	}
 
 	// Early return if already blocked (consistent with Redis implementation)
 	// This prevents Count from increasing while blocked
-	if exists && log.IsCurrentlyBlocked() {
+	if mode == ModeEnforce && exists && log.IsBlockedAt(now) {
 		return &RateLimitStatus{
 			Identifier:     identifier,
 			Action:         action,
 			Count:          log.Count,
 			MaxAttempts:    rule.MaxAttempts,
+			Window:         rule.WindowSize,
 			RemainingTries: 0, // Already blocked = no remaining tries
 			WindowEnd:      log.WindowEnd,
 			Blocked:        true,
@@ -94,12 +160,12 @@ func (l *MemoryLimiter) RecordAttempt(ctx context.Context, identifier, action st
 		}, nil
 	}
 
-	if !exists || !log.IsWindowActive() {
+	if !exists || !log.IsWindowActiveAt(now) {
 		// Create new window
 		log = &RateLimitLog{
 			Identifier:  identifier,
 			Action:      action,
-			Count:       1,
+			Count:       cost,
 			WindowStart: now,
 			WindowEnd:   now.Add(rule.WindowSize),
 			Blocked:     false,
@@ -109,21 +175,53 @@ func (l *MemoryLimiter) RecordAttempt(ctx context.Context, identifier, action st
 		l.logs[key] = log
 	} else {
 		// Increment counter in existing window
-		log.Count++
+		log.Count += cost
 		log.UpdatedAt = now
+	}
 
-		// Check if should block
-		if log.Count >= rule.MaxAttempts && !log.Blocked {
-			log.Blocked = true
-			blockedUntil := now.Add(rule.BlockDuration)
-			log.BlockedUntil = &blockedUntil
-		}
+	// Shadow rules log the would-be block instead of blocking
+	if mode == ModeShadow && log.Count >= rule.MaxAttempts {
+		until := now.Add(rule.BlockDuration)
+		l.shadowBlocks[key] = until
+		l.recordShadowHit(action)
+
+		reportShadowBlock(l.shadowSink, ShadowEvent{
+			Identifier:  identifier,
+			Action:      action,
+			Count:       log.Count,
+			MaxAttempts: rule.MaxAttempts,
+			WindowStart: log.WindowStart,
+			WindowEnd:   log.WindowEnd,
+			WouldUntil:  until,
+		})
+
+		return asShadow(&RateLimitStatus{
+			Identifier:     identifier,
+			Action:         action,
+			Count:          log.Count,
+			MaxAttempts:    rule.MaxAttempts,
+			Window:         rule.WindowSize,
+			RemainingTries: 0,
+			WindowEnd:      log.WindowEnd,
+			Blocked:        true,
+			BlockedUntil:   &until,
+		}), nil
+	}
+
+	// Check if should block (a single expensive attempt can exhaust the window)
+	// Blocks that already expired inside the window block again, like Redis
+	if mode == ModeEnforce && log.Count >= rule.MaxAttempts {
+		log.Blocked = true
+		blockedUntil := now.Add(l.blockDuration(identifier, rule, now))
+		log.BlockedUntil = &blockedUntil
+
+		reportBlock(l.audit, identifier, rule, log.Count, blockedUntil, now)
 	}
 
 	// Build status response
 	// Calculate remaining tries (ensure non-negative)
 	var remaining int
-	if log.Blocked {
+	if mode == ModeEnforce && log.Blocked {
 		// Already blocked = no remaining tries
 		remaining = 0
 	} else {
@@ -138,10 +236,14 @@ func (l *MemoryLimiter) RecordAttempt(ctx context.Context, identifier, action st
 		Action:         action,
 		Count:          log.Count,
 		MaxAttempts:    rule.MaxAttempts,
+		Window:         rule.WindowSize,
 		RemainingTries: remaining,
 		WindowEnd:      log.WindowEnd,
-		Blocked:        log.Blocked,
-		BlockedUntil:   log.BlockedUntil,
+		Mode:           mode,
+	}
+	if mode == ModeEnforce {
+		status.Blocked = log.Blocked
+		status.BlockedUntil = log.BlockedUntil
 	}
 
 	return status, nil
@@ -152,8 +254,14 @@ func (l *MemoryLimiter) GetStatus(ctx context.Context, identifier, action string
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
-	rule, exists := l.rules[action]
-	if !exists || !rule.IsActive {
+	rule, exists := l.ruleFor(identifier, action)
+	now := l.clock.Now()
+
+	if until, ok := l.denylist[identifier]; ok && now.Before(until) {
+		return denylistedStatus(identifier, action, rule, until), nil
+	}
+
+	if !exists || rule.EffectiveMode() == ModeOff {
 		return &RateLimitStatus{
 			Identifier:     identifier,
 			Action:         action,
@@ -164,26 +272,29 @@ func (l *MemoryLimiter) GetStatus(ctx context.Context, identifier, action string
 		}, nil
 	}
 
+	mode := rule.EffectiveMode()
 	key := l.makeKey(identifier, action)
 	log, exists := l.logs[key]
 
-	if !exists || !log.IsWindowActive() {
+	// Check if still blocked (shadow rules never block)
+	// Blocks can outlive the window, so check them first
+	blocked := mode == ModeEnforce && exists && log.IsBlockedAt(now)
+
+	if !blocked && (!exists || !log.IsWindowActiveAt(now)) {
 		// No active rate limit
 		// WindowEnd is estimated when no active window exists
-		return &RateLimitStatus{
+		return l.withMode(key, mode, &RateLimitStatus{
 			Identifier:     identifier,
 			Action:         action,
 			Count:          0,
 			MaxAttempts:    rule.MaxAttempts,
+			Window:         rule.WindowSize,
 			RemainingTries: rule.MaxAttempts,
-			WindowEnd:      time.Now().Add(rule.WindowSize),
+			WindowEnd:      now.Add(rule.WindowSize),
 			Blocked:        false,
-		}, nil
+		}), nil
 	}
 
-	// Check if still blocked
-	blocked := log.IsCurrentlyBlocked()
-
 	// Calculate remaining tries (ensure non-negative)
 	var remaining int
 	if blocked {
@@ -201,13 +312,14 @@ func (l *MemoryLimiter) GetStatus(ctx context.Context, identifier, action string
 		Action:         action,
 		Count:          log.Count,
 		MaxAttempts:    rule.MaxAttempts,
+		Window:         rule.WindowSize,
 		RemainingTries: remaining,
 		WindowEnd:      log.WindowEnd,
 		Blocked:        blocked,
 		BlockedUntil:   log.BlockedUntil,
 	}
 
-	return status, nil
+	return l.withMode(key, mode, status), nil
 }
 
 // Reset resets rate limit for identifier and action
@@ -217,6 +329,7 @@ func (l *MemoryLimiter) Reset(ctx context.Context, identifier, action string) er
 
 	key := l.makeKey(identifier, action)
 	delete(l.logs, key)
+	delete(l.shadowBlocks, key)
 
 	return nil
 }
@@ -228,12 +341,11 @@ func (l *MemoryLimiter) Block(ctx context.Context, identifier, action string, du
 	defer l.mu.Unlock()
 
 	if duration == 0 {
-		// Indefinite block - use very long duration
-		duration = 365 * 24 * time.Hour // 1 year
+		duration = IndefiniteBlockDuration
 	}
 
 	key := l.makeKey(identifier, action)
-	now := time.Now()
+	now := l.clock.Now()
 	blockedUntil := now.Add(duration)
 
 	log, exists := l.logs[key]
@@ -266,17 +378,14 @@ func (l *MemoryLimiter) Block(ctx context.Context, identifier, action string, du
 }
 
 // Unblock manually unblocks an identifier
+// The counter is cleared too (like Redis), so the next attempt starts a new window
 func (l *MemoryLimiter) Unblock(ctx context.Context, identifier, action string) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	key := l.makeKey(identifier, action)
-	log, exists := l.logs[key]
-	if exists {
-		log.Blocked = false
-		log.BlockedUntil = nil
-		log.UpdatedAt = time.Now()
-	}
+	delete(l.logs, key)
+	delete(l.shadowBlocks, key)
 
 	return nil
 }
@@ -324,10 +433,76 @@ func (l *MemoryLimiter) ListRules() []*RateLimitRule {
 	return rules
 }
 
+// SetOverride applies a per-identifier quota override (thread-safe)
+func (l *MemoryLimiter) SetOverride(override *QuotaOverride) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.overrides[overrideKey(override.Key(), override.Action)] = override.Rule()
+}
+
+// RemoveOverride removes a per-identifier quota override (thread-safe)
+func (l *MemoryLimiter) RemoveOverride(identifier, action string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.overrides, overrideKey(identifier, action))
+}
+
+// ReplaceOverrides swaps all overrides at once (used by override sync)
+func (l *MemoryLimiter) ReplaceOverrides(overrides []*QuotaOverride) {
+	next := make(map[string]*RateLimitRule, len(overrides))
+	for _, o := range overrides {
+		next[overrideKey(o.Key(), o.Action)] = o.Rule()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.overrides = next
+}
+
 // ============================================================================
 // HELPER METHODS
 // ============================================================================
 
+// ruleFor returns the override for identifier if one exists, else the action rule
+// Caller must hold l.mu
+func (l *MemoryLimiter) ruleFor(identifier, action string) (*RateLimitRule, bool) {
+	if rule, ok := l.overrides[overrideKey(identifier, action)]; ok {
+		return rule, true
+	}
+
+	rule, exists := l.rules[action]
+	return rule, exists
+}
+
+// withMode sets the rule mode on status and reports would-be blocks of shadow rules
+// Caller must hold l.mu
+func (l *MemoryLimiter) withMode(key string, mode RuleMode, status *RateLimitStatus) *RateLimitStatus {
+	status.Mode = mode
+	if mode != ModeShadow {
+		return status
+	}
+
+	status.Blocked = false
+	status.BlockedUntil = nil
+	if until, ok := l.shadowBlocks[key]; ok && l.clock.Now().Before(until) {
+		status.ShadowBlocked = true
+		status.BlockedUntil = &until
+		status.RemainingTries = 0
+	}
+
+	return status
+}
+
+// recordShadowHit counts a would-be rejected request of a shadow rule
+// Caller must hold l.mu
+func (l *MemoryLimiter) recordShadowHit(action string) {
+	l.shadowHits[action]++
+	shadowHitCounter.Add(action, 1)
+}
+
 // makeKey creates a key for the rate limit log
 // Optimized: uses string concatenation instead of fmt.Sprintf
 func (l *MemoryLimiter) makeKey(identifier, action string) string {
@@ -343,14 +518,7 @@ func (l *MemoryLimiter) cleanup() {
 		select {
 		case <-ticker.C:
 			l.mu.Lock()
-
-			for key, log := range l.logs {
-				// Remove if window expired and not blocked, or if block expired
-				if !log.IsWindowActive() && !log.IsCurrentlyBlocked() {
-					delete(l.logs, key)
-				}
-			}
-
+			l.cleanExpired(l.clock.Now())
 			l.mu.Unlock()
 
 		case <-l.stopCleanup:
@@ -359,6 +527,152 @@ func (l *MemoryLimiter) cleanup() {
 	}
 }
 
+// ============================================================================
+// REPEAT OFFENDERS
+// ============================================================================
+
+// offenseRecord tracks the decaying offense count of an identifier
+type offenseRecord struct {
+	count int
+	last  time.Time
+}
+
+// SetEscalation enables escalating blocks (nil restores constant durations)
+func (l *MemoryLimiter) SetEscalation(policy *EscalationPolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.escalation = policy
+}
+
+// SetSecurityEventSink sets where denylist events are recorded
+func (l *MemoryLimiter) SetSecurityEventSink(sink SecurityEventSink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.events = sink
+}
+
+// SetShadowSink sets where would-be blocks of shadow rules are logged
+func (l *MemoryLimiter) SetShadowSink(sink ShadowSink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.shadowSink = sink
+}
+
+// SetAuditSink sets where blocks started by RecordAttempt are audited
+// The sink is called under the limiter lock, so it must not block (see AuditLog)
+func (l *MemoryLimiter) SetAuditSink(sink AuditSink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.audit = sink
+}
+
+// GetOffender returns the offense history of an identifier
+func (l *MemoryLimiter) GetOffender(ctx context.Context, identifier string) (*OffenderStatus, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := l.clock.Now()
+	status := &OffenderStatus{Identifier: identifier}
+
+	if record, ok := l.offenses[identifier]; ok {
+		var period time.Duration
+		if l.escalation != nil {
+			period = l.escalation.DecayPeriod
+		}
+		status.Offenses = decayOffenses(record.count, record.last, now, period)
+		last := record.last
+		status.LastOffenseAt = &last
+	}
+
+	if until, ok := l.denylist[identifier]; ok && now.Before(until) {
+		status.Denylisted = true
+		status.DenylistedUntil = &until
+	}
+
+	return status, nil
+}
+
+// Pardon clears the offense history and denylist entry of an identifier
+// Per-action blocks are left alone (use Unblock)
+func (l *MemoryLimiter) Pardon(ctx context.Context, identifier string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.offenses, identifier)
+	delete(l.denylist, identifier)
+
+	return nil
+}
+
+// blockDuration records an offense for escalating rules and returns how long
+// to block. Crossing the denylist threshold denylists the identifier.
+// Caller must hold l.mu
+func (l *MemoryLimiter) blockDuration(identifier string, rule *RateLimitRule, now time.Time) time.Duration {
+	if !rule.Escalate || l.escalation == nil || rule.BlockDuration <= 0 {
+		return rule.BlockDuration
+	}
+
+	record, ok := l.offenses[identifier]
+	if !ok {
+		record = &offenseRecord{}
+		l.offenses[identifier] = record
+	}
+	record.count = decayOffenses(record.count, record.last, now, l.escalation.DecayPeriod) + 1
+	record.last = now
+
+	duration := l.escalation.BlockDuration(rule.BlockDuration, record.count)
+
+	if l.escalation.ShouldDenylist(record.count) {
+		until := now.Add(l.escalation.DenylistDuration)
+		if current, ok := l.denylist[identifier]; !ok || current.Before(until) {
+			l.denylist[identifier] = until
+			reportDenylist(l.events, DenylistEvent{
+				Identifier:      identifier,
+				Action:          rule.Action,
+				Offenses:        record.count,
+				DenylistedUntil: until,
+				OccurredAt:      now,
+			})
+		}
+		if d := until.Sub(now); d > duration {
+			duration = d
+		}
+	}
+
+	return duration
+}
+
+// cleanOffenders drops fully decayed offense records, expired denylist entries
+// and expired shadow blocks
+// Caller must hold l.mu
+func (l *MemoryLimiter) cleanOffenders(now time.Time) {
+	for key, until := range l.shadowBlocks {
+		if !now.Before(until) {
+			delete(l.shadowBlocks, key)
+		}
+	}
+
+	for identifier, until := range l.denylist {
+		if !now.Before(until) {
+			delete(l.denylist, identifier)
+		}
+	}
+
+	if l.escalation == nil {
+		return
+	}
+
+	for identifier, record := range l.offenses {
+		if decayOffenses(record.count, record.last, now, l.escalation.DecayPeriod) == 0 {
+			delete(l.offenses, identifier)
+		}
+	}
+}
+
 // ============================================================================
 // LIFECYCLE MANAGEMENT
 // ============================================================================
@@ -389,22 +703,38 @@ func (l *MemoryLimiter) GetStats() map[string]interface{} {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
+	now := l.clock.Now()
 	blocked := 0
 	active := 0
 
 	for _, log := range l.logs {
-		if log.IsCurrentlyBlocked() {
+		if log.IsBlockedAt(now) {
 			blocked++
 		}
-		if log.IsWindowActive() {
+		if log.IsWindowActiveAt(now) {
 			active++
 		}
 	}
 
+	shadowHits := make(map[string]int64, len(l.shadowHits))
+	for action, hits := range l.shadowHits {
+		shadowHits[action] = hits
+	}
+
+	denylisted := 0
+	for _, until := range l.denylist {
+		if now.Before(until) {
+			denylisted++
+		}
+	}
+
 	return map[string]interface{}{
 		"total_logs":       len(l.logs),
 		"active_windows":   active,
 		"blocked_ids":      blocked,
+		"denylisted_ids":   denylisted,
+		"offenders":        len(l.offenses),
+		"shadow_hits":      shadowHits,
 		"configured_rules": len(l.rules),
 		"store_type":       "memory",
 		"cleanup_interval": l.cleanupInterval.String(),
@@ -417,13 +747,20 @@ func (l *MemoryLimiter) CleanExpired(ctx context.Context) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	l.cleanExpired(l.clock.Now())
+
+	return nil
+}
+
+// cleanExpired removes logs whose window and block both expired
+// Caller must hold l.mu
+func (l *MemoryLimiter) cleanExpired(now time.Time) {
 	for key, log := range l.logs {
-		if !log.IsWindowActive() && !log.IsCurrentlyBlocked() {
+		if !log.IsWindowActiveAt(now) && !log.IsBlockedAt(now) {
 			delete(l.logs, key)
 		}
 	}
-
-	return nil
+	l.cleanOffenders(now)
 }
 
 // GetLog returns a copy of the rate limit log for debugging
@@ -449,4 +786,7 @@ func (l *MemoryLimiter) Clear() {
 	defer l.mu.Unlock()
 
 	l.logs = make(map[string]*RateLimitLog)
+	l.offenses = make(map[string]*offenseRecord)
+	l.denylist = make(map[string]time.Time)
+	l.shadowBlocks = make(map[string]time.Time)
 }
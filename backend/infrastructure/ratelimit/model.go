@@ -21,19 +21,17 @@ type RateLimitLog struct {
 	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
 }
 
-// IsCurrentlyBlocked is a simple query helper (not business logic)
-// Just checks current state, doesn't make decisions
-func (r *RateLimitLog) IsCurrentlyBlocked() bool {
+// IsBlockedAt checks if the log is blocked at now
+func (r *RateLimitLog) IsBlockedAt(now time.Time) bool {
 	if !r.Blocked || r.BlockedUntil == nil {
 		return false
 	}
-	return time.Now().Before(*r.BlockedUntil)
+	return now.Before(*r.BlockedUntil)
 }
 
-// IsWindowActive is a simple query helper
-func (r *RateLimitLog) IsWindowActive() bool {
-	now := time.Now()
-	return now.After(r.WindowStart) && now.Before(r.WindowEnd)
+// IsWindowActiveAt checks if now falls in [WindowStart, WindowEnd)
+func (r *RateLimitLog) IsWindowActiveAt(now time.Time) bool {
+	return !now.Before(r.WindowStart) && now.Before(r.WindowEnd)
 }
 
 // ============================================================================
@@ -51,12 +49,22 @@ type RateLimitRule struct {
 	WindowSizeSeconds    int           `json:"window_size_seconds" db:"window_size_seconds"`
 	BlockDurationSeconds int           `json:"block_duration_seconds" db:"block_duration_seconds"`
 	IsActive             bool          `json:"is_active" db:"is_active"`
+	Mode                 RuleMode      `json:"mode" db:"mode"` // enforce (default), shadow or off
+	Escalate             bool          `json:"escalate"`       // Not stored, repeat blocks escalate (see EscalationPolicy)
 	CreatedAt            time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt            time.Time     `json:"updated_at" db:"updated_at"`
 }
 
 // LoadDurations converts stored seconds to time.Duration for easier use
+// Rules declared in code with only Duration fields get their seconds filled in
 func (r *RateLimitRule) LoadDurations() {
+	if r.WindowSizeSeconds == 0 && r.WindowSize > 0 {
+		r.WindowSizeSeconds = int(r.WindowSize.Seconds())
+	}
+	if r.BlockDurationSeconds == 0 && r.BlockDuration > 0 {
+		r.BlockDurationSeconds = int(r.BlockDuration.Seconds())
+	}
+
 	r.WindowSize = time.Duration(r.WindowSizeSeconds) * time.Second
 	r.BlockDuration = time.Duration(r.BlockDurationSeconds) * time.Second
 }
@@ -72,26 +80,50 @@ type RateLimitStatus struct {
 	Action         string
 	Count          int
 	MaxAttempts    int
+	Window         time.Duration
 	RemainingTries int
 	WindowEnd      time.Time
 	Blocked        bool
 	BlockedUntil   *time.Time
+
+	// Shadow rules never block: ShadowBlocked reports a would-be block
+	Mode          RuleMode
+	ShadowBlocked bool
 }
 
-// IsAllowed is a simple query helper
-func (s *RateLimitStatus) IsAllowed() bool {
+// IsAllowedAt checks if the status allows a request at now
+// RemainingTries of -1 means unlimited
+func (s *RateLimitStatus) IsAllowedAt(now time.Time) bool {
+	if s.Mode == ModeShadow {
+		return true
+	}
 	if s.Blocked && s.BlockedUntil != nil {
-		return time.Now().After(*s.BlockedUntil)
+		return !now.Before(*s.BlockedUntil)
 	}
-	return s.RemainingTries > 0
+	return s.RemainingTries > 0 || s.RemainingTries == -1
 }
 
-// TimeUntilReset returns time until rate limit resets
-func (s *RateLimitStatus) TimeUntilReset() time.Duration {
+// TimeUntilResetAt returns time from now until rate limit resets
+func (s *RateLimitStatus) TimeUntilResetAt(now time.Time) time.Duration {
 	if s.Blocked && s.BlockedUntil != nil {
-		return time.Until(*s.BlockedUntil)
+		return s.BlockedUntil.Sub(now)
+	}
+	return s.WindowEnd.Sub(now)
+}
+
+// RetryAfterSecondsAt returns whole seconds from now until the limit resets
+// (never negative). Rounded up so clients never retry before the reset
+func (s *RateLimitStatus) RetryAfterSecondsAt(now time.Time) int {
+	d := s.TimeUntilResetAt(now)
+	if d <= 0 {
+		return 0
+	}
+
+	seconds := int(d / time.Second)
+	if d%time.Second != 0 {
+		seconds++
 	}
-	return time.Until(s.WindowEnd)
+	return seconds
 }
 
 // ============================================================================
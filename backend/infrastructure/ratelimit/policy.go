@@ -0,0 +1,45 @@
+package ratelimit
+
+// ============================================================================
+// RATE LIMIT POLICY (Rule + Cost)
+// ============================================================================
+// A policy binds a configured rule (by action) to the cost a single request
+// consumes. Routes can stack several policies, e.g. a per-second burst limit
+// on top of an hourly quota, and every policy is charged independently.
+
+// Policy describes how one request is charged against one rule
+type Policy struct {
+	// Action selects the RateLimitRule that enforces this policy
+	Action string `json:"action"`
+
+	// Cost is the number of units a single request consumes (default 1)
+	Cost int `json:"cost"`
+}
+
+// NewPolicy creates a policy that charges a single unit against action
+func NewPolicy(action string) Policy {
+	return Policy{Action: action, Cost: 1}
+}
+
+// WithCost returns a copy of the policy charging cost units per request
+func (p Policy) WithCost(cost int) Policy {
+	p.Cost = cost
+	return p
+}
+
+// EffectiveCost returns the cost to record, never less than 1
+func (p Policy) EffectiveCost() int {
+	if p.Cost < 1 {
+		return 1
+	}
+	return p.Cost
+}
+
+// ============================================================================
+// POLICY ACTIONS (burst limits used alongside the quotas)
+// ============================================================================
+
+const (
+	ActionAPIBurst    = "api_burst"
+	ActionUploadBurst = "upload_burst"
+)
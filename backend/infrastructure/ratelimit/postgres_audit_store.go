@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/persistence/query"
+)
+
+// ============================================================================
+// POSTGRES AUDIT STORE
+// ============================================================================
+
+// PostgresAuditStore implements AuditStore using PostgreSQL
+type PostgresAuditStore struct {
+	db *sql.DB
+}
+
+// NewPostgresAuditStore creates a new Postgres-backed audit store
+func NewPostgresAuditStore(db *sql.DB) *PostgresAuditStore {
+	return &PostgresAuditStore{db: db}
+}
+
+// RecordAudit inserts a rate_limit_audit row
+func (s *PostgresAuditStore) RecordAudit(ctx context.Context, event *AuditEvent) error {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO rate_limit_audit
+			(event_type, identifier, action, count, max_attempts, blocked_until, actor_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`,
+		event.EventType, event.Identifier, event.Action, event.Count,
+		event.MaxAttempts, event.BlockedUntil, event.ActorID, event.CreatedAt,
+	).Scan(&event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit event: %w", err)
+	}
+
+	return nil
+}
+
+// ListAudit returns one page of events matching filter, newest first
+func (s *PostgresAuditStore) ListAudit(ctx context.Context, filter AuditFilter) ([]*AuditEvent, int, error) {
+	b := query.New()
+	if filter.Action != "" {
+		b.Where("action = ?", filter.Action)
+	}
+	if filter.Identifier != "" {
+		b.Where("identifier = ?", filter.Identifier)
+	}
+	if filter.Since != nil {
+		b.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		b.Where("created_at < ?", *filter.Until)
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM rate_limit_audit`+b.WhereClause(), b.Args()...,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	page, limit := filter.Page, filter.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 50
+	}
+	where := b.WhereClause()
+	limitArg, offsetArg := b.Arg(limit), b.Arg((page-1)*limit)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, event_type, identifier, action, count, max_attempts,
+			blocked_until, actor_id, created_at
+		FROM rate_limit_audit`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT `+limitArg+` OFFSET `+offsetArg,
+		b.Args()...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*AuditEvent{}
+	for rows.Next() {
+		e := &AuditEvent{}
+		if err := rows.Scan(
+			&e.ID, &e.EventType, &e.Identifier, &e.Action, &e.Count, &e.MaxAttempts,
+			&e.BlockedUntil, &e.ActorID, &e.CreatedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	return events, total, rows.Err()
+}
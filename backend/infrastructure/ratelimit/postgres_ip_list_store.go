@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ============================================================================
+// POSTGRES IP LIST STORE
+// ============================================================================
+
+var (
+	ErrIPRuleNotFound = errors.New("ip rule not found")
+	ErrInvalidIPRule  = errors.New("invalid ip rule")
+)
+
+// PostgresIPListStore implements IPListStore using PostgreSQL
+type PostgresIPListStore struct {
+	db *sql.DB
+}
+
+// NewPostgresIPListStore creates a new Postgres-backed IP list store
+func NewPostgresIPListStore(db *sql.DB) *PostgresIPListStore {
+	return &PostgresIPListStore{db: db}
+}
+
+// ListIPRules lists all IP rules
+func (s *PostgresIPListStore) ListIPRules(ctx context.Context) ([]*IPRule, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, cidr::TEXT, list_type, reason, created_by,
+			expires_at, created_at, updated_at
+		FROM rate_limit_ip_rules
+		ORDER BY list_type, cidr
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ip rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []*IPRule{}
+	for rows.Next() {
+		r := &IPRule{}
+		if err := rows.Scan(
+			&r.ID, &r.CIDR, &r.ListType, &r.Reason, &r.CreatedBy,
+			&r.ExpiresAt, &r.CreatedAt, &r.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan ip rule: %w", err)
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, rows.Err()
+}
+
+// SaveIPRule creates or updates the rule for rule.CIDR
+func (s *PostgresIPListStore) SaveIPRule(ctx context.Context, rule *IPRule) error {
+	if rule.CIDR == "" || !rule.ListType.IsValid() {
+		return ErrInvalidIPRule
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO rate_limit_ip_rules (cidr, list_type, reason, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (cidr) DO UPDATE SET
+			list_type = EXCLUDED.list_type,
+			reason = EXCLUDED.reason,
+			created_by = EXCLUDED.created_by,
+			expires_at = EXCLUDED.expires_at
+		RETURNING id, created_at, updated_at
+	`,
+		rule.CIDR, rule.ListType, rule.Reason, rule.CreatedBy, rule.ExpiresAt,
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save ip rule: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteIPRule removes a rule by id
+func (s *PostgresIPListStore) DeleteIPRule(ctx context.Context, id int) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM rate_limit_ip_rules WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete ip rule: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrIPRuleNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ============================================================================
+// POSTGRES OVERRIDE STORE
+// ============================================================================
+// User/IP overrides are rows in rate_limit_overrides.
+// API key quotas are stored on the key itself (api_keys.rate_limit_*).
+
+// apiKeyQuotaAction is the action an API key's stored quota applies to
+const apiKeyQuotaAction = "api"
+
+var (
+	ErrOverrideNotFound       = errors.New("rate limit override not found")
+	ErrInvalidOverride        = errors.New("invalid rate limit override")
+	ErrUnsupportedKeyOverride = errors.New("api key quotas only apply to the api action")
+)
+
+// PostgresOverrideStore implements OverrideStore using PostgreSQL
+type PostgresOverrideStore struct {
+	db *sql.DB
+}
+
+// NewPostgresOverrideStore creates a new Postgres-backed override store
+func NewPostgresOverrideStore(db *sql.DB) *PostgresOverrideStore {
+	return &PostgresOverrideStore{db: db}
+}
+
+// ListOverrides lists all user and API key overrides
+func (s *PostgresOverrideStore) ListOverrides(ctx context.Context) ([]*QuotaOverride, error) {
+	overrides := []*QuotaOverride{}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, identifier_type, identifier, action,
+			max_attempts, window_size_seconds, block_duration_seconds,
+			reason, created_by, created_at, updated_at
+		FROM rate_limit_overrides
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rate limit overrides: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		o := &QuotaOverride{}
+		if err := rows.Scan(
+			&o.ID, &o.IdentifierType, &o.Identifier, &o.Action,
+			&o.MaxAttempts, &o.WindowSizeSeconds, &o.BlockDurationSeconds,
+			&o.Reason, &o.CreatedBy, &o.CreatedAt, &o.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan rate limit override: %w", err)
+		}
+		overrides = append(overrides, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	keyRows, err := s.db.QueryContext(ctx, `
+		SELECT id, rate_limit_max_requests,
+			COALESCE(rate_limit_window_seconds, 60),
+			COALESCE(rate_limit_block_seconds, 300),
+			user_id, created_at
+		FROM api_keys
+		WHERE rate_limit_max_requests IS NOT NULL
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api key quotas: %w", err)
+	}
+	defer keyRows.Close()
+
+	for keyRows.Next() {
+		var keyID, userID int
+		o := &QuotaOverride{
+			IdentifierType: IdentifierAPIKey,
+			Action:         apiKeyQuotaAction,
+		}
+		if err := keyRows.Scan(
+			&keyID, &o.MaxAttempts, &o.WindowSizeSeconds, &o.BlockDurationSeconds,
+			&userID, &o.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan api key quota: %w", err)
+		}
+		o.Identifier = strconv.Itoa(keyID)
+		o.CreatedBy = &userID
+		o.UpdatedAt = o.CreatedAt
+		overrides = append(overrides, o)
+	}
+
+	return overrides, keyRows.Err()
+}
+
+// SaveOverride creates or updates an override
+func (s *PostgresOverrideStore) SaveOverride(ctx context.Context, o *QuotaOverride) error {
+	if o.Identifier == "" || o.Action == "" || o.MaxAttempts <= 0 || o.WindowSizeSeconds <= 0 || o.BlockDurationSeconds < 0 {
+		return ErrInvalidOverride
+	}
+
+	if o.IdentifierType == IdentifierAPIKey {
+		return s.saveAPIKeyQuota(ctx, o)
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO rate_limit_overrides
+			(identifier_type, identifier, action, max_attempts,
+			 window_size_seconds, block_duration_seconds, reason, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (identifier_type, identifier, action) DO UPDATE SET
+			max_attempts = EXCLUDED.max_attempts,
+			window_size_seconds = EXCLUDED.window_size_seconds,
+			block_duration_seconds = EXCLUDED.block_duration_seconds,
+			reason = EXCLUDED.reason,
+			created_by = EXCLUDED.created_by
+		RETURNING id, created_at, updated_at
+	`,
+		o.IdentifierType, o.Identifier, o.Action, o.MaxAttempts,
+		o.WindowSizeSeconds, o.BlockDurationSeconds, o.Reason, o.CreatedBy,
+	).Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save rate limit override: %w", err)
+	}
+
+	return nil
+}
+
+// saveAPIKeyQuota stores the quota on the API key row
+func (s *PostgresOverrideStore) saveAPIKeyQuota(ctx context.Context, o *QuotaOverride) error {
+	if o.Action != apiKeyQuotaAction {
+		return ErrUnsupportedKeyOverride
+	}
+
+	keyID, err := strconv.Atoi(o.Identifier)
+	if err != nil {
+		return ErrInvalidOverride
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE api_keys
+		SET rate_limit_max_requests = $1,
+			rate_limit_window_seconds = $2,
+			rate_limit_block_seconds = $3
+		WHERE id = $4
+	`, o.MaxAttempts, o.WindowSizeSeconds, o.BlockDurationSeconds, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to save api key quota: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrOverrideNotFound
+	}
+
+	return nil
+}
+
+// DeleteOverride removes an override
+// For API keys this clears the stored quota so the tier default applies
+func (s *PostgresOverrideStore) DeleteOverride(ctx context.Context, typ IdentifierType, identifier, action string) error {
+	var result sql.Result
+	var err error
+
+	if typ == IdentifierAPIKey {
+		keyID, convErr := strconv.Atoi(identifier)
+		if convErr != nil || action != apiKeyQuotaAction {
+			return ErrOverrideNotFound
+		}
+
+		result, err = s.db.ExecContext(ctx, `
+			UPDATE api_keys
+			SET rate_limit_max_requests = NULL,
+				rate_limit_window_seconds = NULL,
+				rate_limit_block_seconds = NULL
+			WHERE id = $1 AND rate_limit_max_requests IS NOT NULL
+		`, keyID)
+	} else {
+		result, err = s.db.ExecContext(ctx, `
+			DELETE FROM rate_limit_overrides
+			WHERE identifier_type = $1 AND identifier = $2 AND action = $3
+		`, typ, identifier, action)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete rate limit override: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrOverrideNotFound
+	}
+
+	return nil
+}
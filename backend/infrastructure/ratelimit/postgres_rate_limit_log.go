@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ============================================================================
+// POSTGRES RATE LIMIT LOG
+// ============================================================================
+// Would-be blocks of shadow rules are written to rate_limit_log with
+// mode = 'shadow' so they can be reviewed before a rule is enforced.
+
+// PostgresRateLimitLog implements ShadowSink using PostgreSQL
+type PostgresRateLimitLog struct {
+	db *sql.DB
+}
+
+// NewPostgresRateLimitLog creates a new Postgres-backed rate limit log
+func NewPostgresRateLimitLog(db *sql.DB) *PostgresRateLimitLog {
+	return &PostgresRateLimitLog{db: db}
+}
+
+// RecordShadowBlock inserts a rate_limit_log row for a would-be block
+func (s *PostgresRateLimitLog) RecordShadowBlock(ctx context.Context, event ShadowEvent) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO rate_limit_log
+			(identifier, action, count, window_start, window_end, blocked, blocked_until, mode)
+		VALUES ($1, $2, $3, $4, $5, TRUE, $6, $7)
+	`,
+		event.Identifier, event.Action, event.Count,
+		event.WindowStart, event.WindowEnd, event.WouldUntil, ModeShadow,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to log shadow block: %w", err)
+	}
+
+	return nil
+}
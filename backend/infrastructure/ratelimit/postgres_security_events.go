@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// POSTGRES SECURITY EVENT SINK
+// ============================================================================
+// Denylist events become security_events rows so they show up next to
+// suspicious logins and lockouts.
+
+// SecurityEventRateLimitDenylist is the security_events.event_type for denylisting
+const SecurityEventRateLimitDenylist = "rate_limit_denylist"
+
+// PostgresSecurityEventSink implements SecurityEventSink using PostgreSQL
+type PostgresSecurityEventSink struct {
+	db *sql.DB
+}
+
+// NewPostgresSecurityEventSink creates a new Postgres-backed event sink
+func NewPostgresSecurityEventSink(db *sql.DB) *PostgresSecurityEventSink {
+	return &PostgresSecurityEventSink{db: db}
+}
+
+// RecordDenylist inserts a security_events row for a denylisted identifier
+func (s *PostgresSecurityEventSink) RecordDenylist(ctx context.Context, event DenylistEvent) error {
+	userID, ip := splitEventIdentifier(event.Identifier)
+
+	metadata, err := json.Marshal(map[string]interface{}{
+		"identifier":       event.Identifier,
+		"action":           event.Action,
+		"offenses":         event.Offenses,
+		"denylisted_until": event.DenylistedUntil.Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO security_events
+			(user_id, event_type, severity, description, ip_address, metadata, created_at)
+		VALUES ($1, $2, 'high', $3, $4, $5, $6)
+	`,
+		userID,
+		SecurityEventRateLimitDenylist,
+		fmt.Sprintf("Denylisted after %d rate limit offenses (last action: %s) until %s",
+			event.Offenses, event.Action, event.DenylistedUntil.Format(time.RFC3339)),
+		ip,
+		metadata,
+		event.OccurredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert security event: %w", err)
+	}
+
+	return nil
+}
+
+// splitEventIdentifier extracts the user id and IP from a limiter identifier
+// ("user_id:42", "user:42", "ip:1.2.3.4" or a bare IP)
+func splitEventIdentifier(identifier string) (*int, string) {
+	typ, value, found := strings.Cut(identifier, ":")
+	if !found || net.ParseIP(identifier) != nil {
+		return nil, identifier
+	}
+
+	switch typ {
+	case string(IdentifierUserID), "user":
+		if id, err := strconv.Atoi(value); err == nil {
+			return &id, ""
+		}
+	case string(IdentifierIP):
+		return nil, value
+	}
+
+	return nil, ""
+}
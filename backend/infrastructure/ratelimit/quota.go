@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ============================================================================
+// QUOTA TIERS
+// ============================================================================
+// The same base action (e.g. "api") can be configured per tier by adding a
+// rule named "<action>:<tier>" (e.g. "api:leader"). Callers that resolve a
+// tier fall back to the base action when no tiered rule exists.
+
+// Tier represents the quota tier of the caller a request is charged to
+type Tier string
+
+const (
+	TierAnonymous Tier = "anonymous"
+	TierUser      Tier = "user"
+	TierLeader    Tier = "leader"
+	TierAdmin     Tier = "admin"
+	TierAPIKey    Tier = "api_key"
+)
+
+// TieredAction returns the rule action for a base action in a tier
+//
+// Example:
+//
+//	TieredAction("api", TierLeader) → "api:leader"
+func TieredAction(action string, tier Tier) string {
+	return action + ":" + string(tier)
+}
+
+// ============================================================================
+// PRINCIPAL
+// ============================================================================
+
+// Principal is the caller a rate-limited request is charged to
+type Principal struct {
+	Type  IdentifierType
+	Value string
+	Tier  Tier
+}
+
+// Identifier returns the formatted limiter identifier (e.g. "user_id:42")
+func (p Principal) Identifier() string {
+	return FormatIdentifier(p.Type, p.Value)
+}
+
+// ============================================================================
+// QUOTA OVERRIDES
+// ============================================================================
+
+// QuotaOverride replaces the rule of an action for a single identifier
+// User overrides live in rate_limit_overrides, API key quotas on api_keys
+type QuotaOverride struct {
+	ID                   int            `json:"id,omitempty" db:"id"`
+	IdentifierType       IdentifierType `json:"identifier_type" db:"identifier_type"`
+	Identifier           string         `json:"identifier" db:"identifier"`
+	Action               string         `json:"action" db:"action"`
+	MaxAttempts          int            `json:"max_attempts" db:"max_attempts"`
+	WindowSizeSeconds    int            `json:"window_size_seconds" db:"window_size_seconds"`
+	BlockDurationSeconds int            `json:"block_duration_seconds" db:"block_duration_seconds"`
+	Reason               *string        `json:"reason,omitempty" db:"reason"`
+	CreatedBy            *int           `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt            time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// Key returns the formatted limiter identifier the override applies to
+func (o *QuotaOverride) Key() string {
+	return FormatIdentifier(o.IdentifierType, o.Identifier)
+}
+
+// Rule converts the override into a rule for the overridden action
+// The action is kept so counters are shared with the base rule
+func (o *QuotaOverride) Rule() *RateLimitRule {
+	rule := &RateLimitRule{
+		Action:               o.Action,
+		MaxAttempts:          o.MaxAttempts,
+		WindowSizeSeconds:    o.WindowSizeSeconds,
+		BlockDurationSeconds: o.BlockDurationSeconds,
+		IsActive:             true,
+	}
+	rule.LoadDurations()
+	return rule
+}
+
+// OverrideStore persists quota overrides
+type OverrideStore interface {
+	// ListOverrides lists all user and API key overrides
+	ListOverrides(ctx context.Context) ([]*QuotaOverride, error)
+
+	// SaveOverride creates or updates an override
+	SaveOverride(ctx context.Context, override *QuotaOverride) error
+
+	// DeleteOverride removes an override
+	DeleteOverride(ctx context.Context, typ IdentifierType, identifier, action string) error
+}
+
+// OverrideTarget is a limiter that can apply per-identifier overrides
+type OverrideTarget interface {
+	ReplaceOverrides(overrides []*QuotaOverride)
+}
+
+// SyncOverrides loads all overrides from store into the limiter
+func SyncOverrides(ctx context.Context, store OverrideStore, target OverrideTarget) error {
+	overrides, err := store.ListOverrides(ctx)
+	if err != nil {
+		return err
+	}
+
+	target.ReplaceOverrides(overrides)
+	return nil
+}
+
+// StartOverrideSync periodically reloads overrides so edits made on one
+// instance reach every instance. It stops when ctx is cancelled.
+func StartOverrideSync(ctx context.Context, store OverrideStore, target OverrideTarget, interval time.Duration) {
+	startPeriodicSync(ctx, interval, "rate limit overrides", func(ctx context.Context) error {
+		return SyncOverrides(ctx, store, target)
+	})
+}
+
+// startPeriodicSync runs sync once now and then every interval until ctx is done
+func startPeriodicSync(ctx context.Context, interval time.Duration, what string, sync func(context.Context) error) {
+	if err := sync(ctx); err != nil {
+		log.Printf("⚠️  Failed to load %s: %v", what, err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := sync(ctx); err != nil {
+					log.Printf("⚠️  Failed to refresh %s: %v", what, err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"expvar"
+	"time"
+)
+
+// ============================================================================
+// RULE MODES
+// ============================================================================
+// enforce: attempts are counted and blocks reject requests (default)
+// shadow:  attempts are counted, but would-be blocks are only logged and
+//          counted as shadow hits; the request is always allowed
+// off:     the rule is ignored (same as IsActive = false)
+
+// RuleMode controls how a rule is applied
+type RuleMode string
+
+const (
+	ModeEnforce RuleMode = "enforce"
+	ModeShadow  RuleMode = "shadow"
+	ModeOff     RuleMode = "off"
+)
+
+// IsValid checks if the mode is supported
+func (m RuleMode) IsValid() bool {
+	return m == ModeEnforce || m == ModeShadow || m == ModeOff
+}
+
+// EffectiveMode returns the mode the limiter applies to the rule
+// Inactive rules are off, rules without a mode are enforced
+func (r *RateLimitRule) EffectiveMode() RuleMode {
+	if !r.IsActive || r.Mode == ModeOff {
+		return ModeOff
+	}
+	if r.Mode == ModeShadow {
+		return ModeShadow
+	}
+	return ModeEnforce
+}
+
+// ============================================================================
+// SHADOW HITS
+// ============================================================================
+
+// shadowHitCounter counts would-be rejected requests per action (exported via expvar)
+var shadowHitCounter = expvar.NewMap("ratelimit_shadow_hits")
+
+// ShadowEvent is reported when a shadow rule would have blocked an identifier
+type ShadowEvent struct {
+	Identifier  string
+	Action      string
+	Count       int
+	MaxAttempts int
+	WindowStart time.Time
+	WindowEnd   time.Time
+	WouldUntil  time.Time // When the block would have ended
+}
+
+// ShadowSink records would-be blocks of shadow rules
+type ShadowSink interface {
+	RecordShadowBlock(ctx context.Context, event ShadowEvent) error
+}
+
+// asShadow turns a blocked status into the allowed status of a shadow rule
+// BlockedUntil keeps the would-be block end for debugging
+func asShadow(status *RateLimitStatus) *RateLimitStatus {
+	status.Mode = ModeShadow
+	status.ShadowBlocked = status.Blocked
+	status.Blocked = false
+	if status.ShadowBlocked {
+		status.RemainingTries = 0
+	}
+	return status
+}
+
+// reportShadowBlock sends the event to sink without blocking the request
+func reportShadowBlock(sink ShadowSink, event ShadowEvent) {
+	if sink == nil {
+		return
+	}
+
+	dispatchEvent("shadow block", func(ctx context.Context) error {
+		return sink.RecordShadowBlock(ctx, event)
+	})
+}
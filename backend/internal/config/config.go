@@ -17,11 +17,14 @@ type Config struct {
 	App      AppConfig
 	Database DatabaseConfig
 	Redis    RedisConfig
+	Cache    CacheConfig
 	JWT      JWTConfig
 	Security SecurityConfig
 	External ExternalConfig
 	Email    EmailConfig
 	Storage  StorageConfig
+
+	RateLimit RateLimitConfig
 }
 
 // AppConfig contains application-level configuration
@@ -48,6 +51,25 @@ type DatabaseConfig struct {
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
+	AutoMigrate     bool // Apply pending migrations on startup
+
+	// Read replicas (same pool settings as the primary)
+	ReplicaURLs          []string
+	ReplicaCheckInterval time.Duration
+	ReplicaMaxLag        time.Duration // Lagging replicas leave rotation (0 = not checked)
+
+	// Monthly partitions of login_activity and user_activity_log
+	PartitionMaintenance bool
+	PartitionMonthsAhead int           // Future months created in advance
+	PartitionRetention   int           // Months kept attached (0 = keep all)
+	PartitionArchiveDir  string        // Detached partitions go here as .csv.gz (empty = keep the table)
+	PartitionInterval    time.Duration // How often maintenance runs
+
+	// Transactional outbox dispatcher
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+	OutboxMaxAttempts  int           // Failed deliveries before an event is parked
+	OutboxRetention    time.Duration // Published events are deleted after this (0 = keep)
 }
 
 // RedisConfig contains Redis connection configuration
@@ -55,9 +77,77 @@ type RedisConfig struct {
 	URL      string
 	Host     string
 	Port     string
+	Username string // ACL user (empty = default user)
 	Password string
 	DB       int
 	PoolSize int
+
+	// Sentinel failover (used when SentinelMaster is set)
+	SentinelMaster   string
+	SentinelAddrs    []string
+	SentinelPassword string
+
+	// Cluster (used when ClusterAddrs is set, DB must be 0)
+	ClusterAddrs []string
+
+	// TLS (rediss:// URLs enable it as well)
+	TLSEnabled    bool
+	TLSCAFile     string // PEM bundle to verify the server (empty = system roots)
+	TLSCertFile   string // Client certificate for mutual TLS
+	TLSKeyFile    string
+	TLSServerName string // Overrides the name checked against the certificate
+}
+
+// IsCluster reports whether Redis runs as a cluster
+func (c RedisConfig) IsCluster() bool {
+	return len(c.ClusterAddrs) > 0
+}
+
+// IsSentinel reports whether Redis is reached through Sentinel
+func (c RedisConfig) IsSentinel() bool {
+	return c.SentinelMaster != ""
+}
+
+// CacheConfig contains read-through cache (stampede protection) configuration
+type CacheConfig struct {
+	// Distributed lock so one instance fetches a missing key
+	LockEnabled bool
+	LockTTL     time.Duration
+	LockWait    time.Duration // Waiters fetch themselves after this
+
+	// Probabilistic early refresh (0 = off, 1 = usual)
+	EarlyRefreshBeta float64
+
+	// In-process LRU tier in front of Redis (0 = off)
+	LocalSize           int
+	LocalTTL            time.Duration
+	InvalidationChannel string // Pub/sub channel that drops local copies
+}
+
+// RateLimitConfig contains rate limiting configuration
+type RateLimitConfig struct {
+	LegacyHeaders bool // Also emit X-RateLimit-* next to the IETF RateLimit headers
+
+	// Client IP resolution behind load balancers
+	TrustedProxies   []string // CIDRs or IPs whose proxy header is believed
+	ProxyHeader      string   // "X-Forwarded-For" or "Forwarded"
+	IPv6PrefixLength int      // IPv6 clients share a bucket per prefix
+
+	// Actions whose rules only log would-be blocks (dry run before enforcing)
+	ShadowActions []string
+
+	// Escalating blocks for repeat offenders
+	EscalationEnabled    bool
+	EscalationMultiplier float64
+	MaxBlockDuration     time.Duration
+	OffenseDecay         time.Duration
+	DenylistThreshold    int
+	DenylistDuration     time.Duration
+
+	// In-flight request caps per identifier (0 = unlimited)
+	MaxConcurrentUploads int
+	MaxConcurrentExports int
+	LeaseTTL             time.Duration // Leases of crashed instances expire after this
 }
 
 // JWTConfig contains JWT token configuration
@@ -83,6 +173,11 @@ type SecurityConfig struct {
 	RequireUppercase     bool
 	PasswordHistoryCount int
 	TwoFactorEnabled     bool
+
+	// Personal data of deleted users is erased after the retention
+	// (0 = never); purge runs every interval
+	DeletedUserRetention time.Duration
+	UserPurgeInterval    time.Duration
 }
 
 // ExternalConfig contains external API configuration
@@ -142,6 +237,14 @@ func Load() error {
 		return fmt.Errorf("failed to load redis config: %w", err)
 	}
 
+	if err := loadCacheConfig(&cfg.Cache); err != nil {
+		return fmt.Errorf("failed to load cache config: %w", err)
+	}
+
+	if err := loadRateLimitConfig(&cfg.RateLimit); err != nil {
+		return fmt.Errorf("failed to load rate limit config: %w", err)
+	}
+
 	if err := loadJWTConfig(&cfg.JWT); err != nil {
 		return fmt.Errorf("failed to load JWT config: %w", err)
 	}
@@ -261,6 +364,25 @@ func loadDatabaseConfig(cfg *DatabaseConfig) error {
 	cfg.MaxIdleConns = getIntEnv("DB_MAX_IDLE_CONNS", 5)
 	cfg.ConnMaxLifetime = getDurationEnv("DB_CONN_MAX_LIFETIME", 5*time.Minute)
 	cfg.ConnMaxIdleTime = getDurationEnv("DB_CONN_MAX_IDLE_TIME", 5*time.Minute)
+	cfg.AutoMigrate = getBoolEnv("DB_AUTO_MIGRATE", true)
+
+	// Read replicas
+	cfg.ReplicaURLs = getListEnv("DB_REPLICA_URLS")
+	cfg.ReplicaCheckInterval = getDurationEnv("DB_REPLICA_CHECK_INTERVAL", 10*time.Second)
+	cfg.ReplicaMaxLag = getDurationEnv("DB_REPLICA_MAX_LAG", 5*time.Second)
+
+	// Partition maintenance
+	cfg.PartitionMaintenance = getBoolEnv("DB_PARTITION_MAINTENANCE", true)
+	cfg.PartitionMonthsAhead = getIntEnv("DB_PARTITION_MONTHS_AHEAD", 3)
+	cfg.PartitionRetention = getIntEnv("DB_PARTITION_RETENTION_MONTHS", 6)
+	cfg.PartitionArchiveDir = getEnvOrDefault("DB_PARTITION_ARCHIVE_DIR", "")
+	cfg.PartitionInterval = getDurationEnv("DB_PARTITION_INTERVAL", 6*time.Hour)
+
+	// Outbox dispatcher
+	cfg.OutboxPollInterval = getDurationEnv("DB_OUTBOX_POLL_INTERVAL", time.Second)
+	cfg.OutboxBatchSize = getIntEnv("DB_OUTBOX_BATCH_SIZE", 100)
+	cfg.OutboxMaxAttempts = getIntEnv("DB_OUTBOX_MAX_ATTEMPTS", 10)
+	cfg.OutboxRetention = getDurationEnv("DB_OUTBOX_RETENTION", 7*24*time.Hour)
 
 	return nil
 }
@@ -283,6 +405,7 @@ func loadRedisConfig(cfg *RedisConfig) error {
 		}
 
 		if parsed.User != nil {
+			cfg.Username = parsed.User.Username()
 			cfg.Password, _ = parsed.User.Password()
 		}
 
@@ -308,6 +431,65 @@ func loadRedisConfig(cfg *RedisConfig) error {
 	}
 
 	cfg.PoolSize = getIntEnv("REDIS_POOL_SIZE", 10)
+	if username := os.Getenv("REDIS_USERNAME"); username != "" {
+		cfg.Username = username
+	}
+
+	cfg.SentinelMaster = os.Getenv("REDIS_SENTINEL_MASTER")
+	cfg.SentinelAddrs = getListEnv("REDIS_SENTINEL_ADDRS")
+	cfg.SentinelPassword = os.Getenv("REDIS_SENTINEL_PASSWORD")
+	cfg.ClusterAddrs = getListEnv("REDIS_CLUSTER_ADDRS")
+
+	cfg.TLSEnabled = getBoolEnv("REDIS_TLS", strings.HasPrefix(cfg.URL, "rediss://"))
+	cfg.TLSCAFile = os.Getenv("REDIS_TLS_CA_FILE")
+	cfg.TLSCertFile = os.Getenv("REDIS_TLS_CERT_FILE")
+	cfg.TLSKeyFile = os.Getenv("REDIS_TLS_KEY_FILE")
+	cfg.TLSServerName = os.Getenv("REDIS_TLS_SERVER_NAME")
+
+	return nil
+}
+
+func loadCacheConfig(cfg *CacheConfig) error {
+	cfg.LockEnabled = getBoolEnv("CACHE_LOCK_ENABLED", false)
+	cfg.LockTTL = getDurationEnv("CACHE_LOCK_TTL", 10*time.Second)
+	cfg.LockWait = getDurationEnv("CACHE_LOCK_WAIT", 2*time.Second)
+
+	cfg.EarlyRefreshBeta = getFloatEnv("CACHE_EARLY_REFRESH_BETA", 1)
+
+	cfg.LocalSize = getIntEnv("CACHE_LOCAL_SIZE", 0)
+	cfg.LocalTTL = getDurationEnv("CACHE_LOCAL_TTL", 30*time.Second)
+	cfg.InvalidationChannel = getEnvOrDefault("CACHE_INVALIDATION_CHANNEL", "cache:invalidate")
+
+	return nil
+}
+
+func loadRateLimitConfig(cfg *RateLimitConfig) error {
+	cfg.LegacyHeaders = getBoolEnv("RATE_LIMIT_LEGACY_HEADERS", true)
+
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		cfg.TrustedProxies = strings.Split(proxies, ",")
+	}
+	cfg.ProxyHeader = getEnvOrDefault("PROXY_HEADER", "X-Forwarded-For")
+	cfg.IPv6PrefixLength = getIntEnv("RATE_LIMIT_IPV6_PREFIX", 64)
+
+	if actions := os.Getenv("RATE_LIMIT_SHADOW_ACTIONS"); actions != "" {
+		for _, action := range strings.Split(actions, ",") {
+			if action = strings.TrimSpace(action); action != "" {
+				cfg.ShadowActions = append(cfg.ShadowActions, action)
+			}
+		}
+	}
+
+	cfg.EscalationEnabled = getBoolEnv("RATE_LIMIT_ESCALATION_ENABLED", true)
+	cfg.EscalationMultiplier = getFloatEnv("RATE_LIMIT_ESCALATION_MULTIPLIER", 2)
+	cfg.MaxBlockDuration = getDurationEnv("RATE_LIMIT_MAX_BLOCK_DURATION", 24*time.Hour)
+	cfg.OffenseDecay = getDurationEnv("RATE_LIMIT_OFFENSE_DECAY", 24*time.Hour)
+	cfg.DenylistThreshold = getIntEnv("RATE_LIMIT_DENYLIST_THRESHOLD", 5)
+	cfg.DenylistDuration = getDurationEnv("RATE_LIMIT_DENYLIST_DURATION", 7*24*time.Hour)
+
+	cfg.MaxConcurrentUploads = getIntEnv("RATE_LIMIT_MAX_CONCURRENT_UPLOADS", 2)
+	cfg.MaxConcurrentExports = getIntEnv("RATE_LIMIT_MAX_CONCURRENT_EXPORTS", 1)
+	cfg.LeaseTTL = getDurationEnv("RATE_LIMIT_LEASE_TTL", 1*time.Minute)
 
 	return nil
 }
@@ -340,6 +522,8 @@ func loadSecurityConfig(cfg *SecurityConfig) error {
 	cfg.RequireUppercase = getBoolEnv("PASSWORD_REQUIRE_UPPERCASE", true)
 	cfg.PasswordHistoryCount = getIntEnv("PASSWORD_HISTORY_COUNT", 5)
 	cfg.TwoFactorEnabled = getBoolEnv("TWO_FACTOR_ENABLED", false)
+	cfg.DeletedUserRetention = getDurationEnv("DELETED_USER_RETENTION", 30*24*time.Hour)
+	cfg.UserPurgeInterval = getDurationEnv("USER_PURGE_INTERVAL", time.Hour)
 
 	return nil
 }
@@ -406,11 +590,82 @@ func (c *Config) Validate() error {
 	if c.Database.MaxOpenConns < c.Database.MaxIdleConns {
 		return fmt.Errorf("max open connections must be >= max idle connections")
 	}
+	if len(c.Database.ReplicaURLs) > 0 && c.Database.ReplicaCheckInterval <= 0 {
+		return fmt.Errorf("replica check interval must be positive")
+	}
+	if c.Database.PartitionMaintenance {
+		if c.Database.PartitionMonthsAhead < 1 {
+			return fmt.Errorf("partition months ahead must be at least 1")
+		}
+		if c.Database.PartitionRetention < 0 {
+			return fmt.Errorf("partition retention cannot be negative")
+		}
+		if c.Database.PartitionInterval <= 0 {
+			return fmt.Errorf("partition maintenance interval must be positive")
+		}
+	}
+	if c.Database.OutboxPollInterval <= 0 {
+		return fmt.Errorf("outbox poll interval must be positive")
+	}
+	if c.Database.OutboxBatchSize < 1 {
+		return fmt.Errorf("outbox batch size must be at least 1")
+	}
+	if c.Database.OutboxMaxAttempts < 1 {
+		return fmt.Errorf("outbox max attempts must be at least 1")
+	}
+	if c.Database.OutboxRetention < 0 {
+		return fmt.Errorf("outbox retention cannot be negative")
+	}
 
 	// Validate Redis
-	if c.Redis.URL == "" {
+	if c.Redis.URL == "" && !c.Redis.IsSentinel() && !c.Redis.IsCluster() {
 		return fmt.Errorf("redis URL is required")
 	}
+	if c.Redis.IsSentinel() && c.Redis.IsCluster() {
+		return fmt.Errorf("redis sentinel and cluster are mutually exclusive")
+	}
+	if c.Redis.IsSentinel() && len(c.Redis.SentinelAddrs) == 0 {
+		return fmt.Errorf("redis sentinel addresses are required with a sentinel master")
+	}
+	if c.Redis.IsCluster() && c.Redis.DB != 0 {
+		return fmt.Errorf("redis cluster only supports DB 0")
+	}
+	if (c.Redis.TLSCertFile == "") != (c.Redis.TLSKeyFile == "") {
+		return fmt.Errorf("redis TLS certificate and key must be set together")
+	}
+
+	// Validate Cache
+	if c.Cache.LockEnabled && (c.Cache.LockTTL <= 0 || c.Cache.LockWait <= 0) {
+		return fmt.Errorf("cache lock TTL and wait must be positive")
+	}
+	if c.Cache.EarlyRefreshBeta < 0 {
+		return fmt.Errorf("cache early refresh beta cannot be negative")
+	}
+	if c.Cache.LocalSize < 0 {
+		return fmt.Errorf("cache local size cannot be negative")
+	}
+	if c.Cache.LocalSize > 0 && c.Cache.LocalTTL <= 0 {
+		return fmt.Errorf("cache local TTL must be positive")
+	}
+
+	// Validate Rate Limit
+	if c.RateLimit.IPv6PrefixLength < 1 || c.RateLimit.IPv6PrefixLength > 128 {
+		return fmt.Errorf("rate limit IPv6 prefix must be between 1 and 128")
+	}
+	if c.RateLimit.EscalationEnabled {
+		if c.RateLimit.EscalationMultiplier < 1 {
+			return fmt.Errorf("rate limit escalation multiplier must be >= 1")
+		}
+		if c.RateLimit.DenylistThreshold > 0 && c.RateLimit.DenylistDuration <= 0 {
+			return fmt.Errorf("rate limit denylist duration must be positive")
+		}
+	}
+	if c.RateLimit.MaxConcurrentUploads < 0 || c.RateLimit.MaxConcurrentExports < 0 {
+		return fmt.Errorf("rate limit concurrency caps cannot be negative")
+	}
+	if c.RateLimit.LeaseTTL < 3*time.Second {
+		return fmt.Errorf("rate limit lease TTL must be at least 3s")
+	}
 
 	// Validate JWT
 	if len(c.JWT.Secret) < 32 {
@@ -430,6 +685,12 @@ func (c *Config) Validate() error {
 	if c.Security.PasswordMinLength < 8 {
 		return fmt.Errorf("password minimum length must be at least 8")
 	}
+	if c.Security.DeletedUserRetention < 0 {
+		return fmt.Errorf("deleted user retention cannot be negative")
+	}
+	if c.Security.DeletedUserRetention > 0 && c.Security.UserPurgeInterval <= 0 {
+		return fmt.Errorf("user purge interval must be positive")
+	}
 
 	// Validate Storage
 	if c.Storage.Type == "s3" {
@@ -485,6 +746,27 @@ func getBoolEnv(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+		log.Printf("⚠️  Invalid float value for %s: %s, using default: %g", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+// getListEnv splits a comma-separated variable, skipping empty items
+func getListEnv(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -514,11 +796,37 @@ func logConfiguration() {
 	log.Printf("   SSL Mode: %s", Cfg.Database.SSLMode)
 	log.Printf("   Max Open Connections: %d", Cfg.Database.MaxOpenConns)
 	log.Printf("   Max Idle Connections: %d", Cfg.Database.MaxIdleConns)
+	log.Printf("   Auto Migrate: %t", Cfg.Database.AutoMigrate)
+	log.Printf("   Replicas: %d (check every %s, max lag %s)", len(Cfg.Database.ReplicaURLs), Cfg.Database.ReplicaCheckInterval, Cfg.Database.ReplicaMaxLag)
+	log.Printf("   Partitions: %t (%d months ahead, keep %d, every %s)", Cfg.Database.PartitionMaintenance, Cfg.Database.PartitionMonthsAhead, Cfg.Database.PartitionRetention, Cfg.Database.PartitionInterval)
+	log.Printf("   Outbox: every %s (batch %d, %d attempts, keep %s)", Cfg.Database.OutboxPollInterval, Cfg.Database.OutboxBatchSize, Cfg.Database.OutboxMaxAttempts, Cfg.Database.OutboxRetention)
 
 	log.Printf("🔴 Redis:")
-	log.Printf("   Host: %s:%s", Cfg.Redis.Host, Cfg.Redis.Port)
+	switch {
+	case Cfg.Redis.IsCluster():
+		log.Printf("   Cluster: %s", strings.Join(Cfg.Redis.ClusterAddrs, ", "))
+	case Cfg.Redis.IsSentinel():
+		log.Printf("   Sentinel: %s via %s", Cfg.Redis.SentinelMaster, strings.Join(Cfg.Redis.SentinelAddrs, ", "))
+	default:
+		log.Printf("   Host: %s:%s", Cfg.Redis.Host, Cfg.Redis.Port)
+	}
 	log.Printf("   DB: %d", Cfg.Redis.DB)
 	log.Printf("   Pool Size: %d", Cfg.Redis.PoolSize)
+	log.Printf("   TLS: %t", Cfg.Redis.TLSEnabled)
+
+	log.Printf("🧊 Cache:")
+	log.Printf("   Lock: %t (ttl %s, wait %s)", Cfg.Cache.LockEnabled, Cfg.Cache.LockTTL, Cfg.Cache.LockWait)
+	log.Printf("   Early Refresh Beta: %g", Cfg.Cache.EarlyRefreshBeta)
+	log.Printf("   Local Tier: %d entries for %s", Cfg.Cache.LocalSize, Cfg.Cache.LocalTTL)
+
+	log.Printf("🚦 Rate Limit:")
+	log.Printf("   Legacy Headers: %t", Cfg.RateLimit.LegacyHeaders)
+	log.Printf("   Trusted Proxies: %d (%s)", len(Cfg.RateLimit.TrustedProxies), Cfg.RateLimit.ProxyHeader)
+	log.Printf("   IPv6 Prefix: /%d", Cfg.RateLimit.IPv6PrefixLength)
+	log.Printf("   Shadow Actions: %v", Cfg.RateLimit.ShadowActions)
+	log.Printf("   Escalation: %t (x%g, max %s)", Cfg.RateLimit.EscalationEnabled, Cfg.RateLimit.EscalationMultiplier, Cfg.RateLimit.MaxBlockDuration)
+	log.Printf("   Denylist: %d offenses → %s", Cfg.RateLimit.DenylistThreshold, Cfg.RateLimit.DenylistDuration)
+	log.Printf("   Concurrency: %d uploads, %d exports (lease %s)", Cfg.RateLimit.MaxConcurrentUploads, Cfg.RateLimit.MaxConcurrentExports, Cfg.RateLimit.LeaseTTL)
 
 	log.Printf("🔐 JWT:")
 	log.Printf("   Access Token Duration: %s", Cfg.JWT.AccessTokenDuration)
@@ -530,6 +838,7 @@ func logConfiguration() {
 	log.Printf("   Max Login Attempts: %d", Cfg.Security.MaxLoginAttempts)
 	log.Printf("   Lockout Duration: %s", Cfg.Security.LockoutDuration)
 	log.Printf("   Two-Factor: %t", Cfg.Security.TwoFactorEnabled)
+	log.Printf("   Deleted User Retention: %s (purge every %s)", Cfg.Security.DeletedUserRetention, Cfg.Security.UserPurgeInterval)
 
 	log.Printf("📧 Email:")
 	if Cfg.Email.SMTPHost != "" {
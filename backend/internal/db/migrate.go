@@ -0,0 +1,500 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// MIGRATIONS
+// ============================================================================
+// Migrations live in migrations/ as numbered pairs embedded into the binary:
+//
+//	0002_add_kits.up.sql
+//	0002_add_kits.down.sql
+//
+// Applied versions are recorded in schema_migrations with the checksum of
+// their up file, so editing an applied migration is reported as drift instead
+// of silently diverging between environments. A Postgres advisory lock makes
+// concurrent instances wait for the one that migrates.
+//
+// Each migration runs in a transaction unless its up/down file starts with
+// "-- migrate:no-transaction" (needed for CREATE INDEX CONCURRENTLY).
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey identifies the advisory lock held while migrating
+const migrationLockKey int64 = 4_815_162_342
+
+const noTransactionDirective = "-- migrate:no-transaction"
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var (
+	ErrChecksumDrift    = errors.New("applied migration was modified")
+	ErrMissingMigration = errors.New("applied migration has no file")
+	ErrNoDownMigration  = errors.New("migration has no down file")
+)
+
+// Migration is one numbered schema change
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 of Up
+}
+
+// MigrationState describes a migration relative to the database
+type MigrationState string
+
+const (
+	MigrationApplied MigrationState = "applied"
+	MigrationPending MigrationState = "pending"
+	MigrationDrifted MigrationState = "drifted" // Applied, file changed since
+	MigrationMissing MigrationState = "missing" // Applied, file deleted
+)
+
+// MigrationStatus is one row of Migrator.Status
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	State     MigrationState
+	AppliedAt *time.Time
+}
+
+// appliedMigration is one schema_migrations row
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator applies and reverts migrations
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration // Sorted by version
+
+	// DryRun logs what would run without changing the database
+	DryRun bool
+}
+
+// Migrations returns the migrations embedded in the binary
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		panic(err) // The embedded directory always exists
+	}
+	return sub
+}
+
+// NewMigrator creates a migrator for the migrations in fsys
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// loadMigrations reads and pairs up/down files, sorted by version
+func loadMigrations(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q (want NNNN_name.up.sql or NNNN_name.down.sql)", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d used by both %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+			m.Checksum = checksum(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// checksum returns the hex SHA-256 of content
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// ----------------------------------------------------------------------------
+// Commands
+// ----------------------------------------------------------------------------
+
+// Up applies every pending migration, returning how many ran
+// It refuses to run while an applied migration drifted or went missing.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		var latest int64
+		for version := range applied {
+			latest = max(latest, version)
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if migration.Version < latest {
+				log.Printf("⚠️  Migration %04d_%s is older than applied version %d, applying it out of order", migration.Version, migration.Name, latest)
+			}
+
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down reverts the n most recently applied migrations, returning how many ran
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		targets, err := m.latestApplied(ctx, conn, n)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range targets {
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Redo reverts and reapplies the most recently applied migration
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		targets, err := m.latestApplied(ctx, conn, 1)
+		if err != nil {
+			return err
+		}
+		if len(targets) == 0 {
+			log.Println("⚠️  No applied migration to redo")
+			return nil
+		}
+
+		if err := m.apply(ctx, conn, targets[0], false); err != nil {
+			return err
+		}
+		return m.apply(ctx, conn, targets[0], true)
+	})
+}
+
+// Status lists every migration with its state, by version
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, State: MigrationPending}
+		if row, ok := applied[migration.Version]; ok {
+			status.State = MigrationApplied
+			if row.Checksum != migration.Checksum {
+				status.State = MigrationDrifted
+			}
+			status.AppliedAt = &row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Version:   row.Version,
+			Name:      row.Name,
+			State:     MigrationMissing,
+			AppliedAt: &appliedAt,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// ----------------------------------------------------------------------------
+// Helpers
+// ----------------------------------------------------------------------------
+
+// withLock runs fn on one connection holding the migration advisory lock
+// (advisory locks belong to the session, so everything shares the connection)
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Unlock even if ctx was cancelled mid-migration
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			log.Printf("⚠️  Failed to release migration lock: %v", err)
+		}
+	}()
+
+	if !m.DryRun {
+		if err := ensureMigrationsTable(ctx, conn); err != nil {
+			return err
+		}
+	}
+
+	return fn(conn)
+}
+
+// ensureMigrationsTable creates schema_migrations
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			execution_ms INT NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// applied returns the recorded migrations by version (none before the
+// table exists, e.g. in a dry run against a fresh database)
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]*appliedMigration, error) {
+	var exists bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check schema_migrations: %w", err)
+	}
+
+	applied := make(map[int64]*appliedMigration)
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := &appliedMigration{}
+		if err := rows.Scan(&row.Version, &row.Name, &row.Checksum, &row.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[row.Version] = row
+	}
+	return applied, rows.Err()
+}
+
+// verify fails when an applied migration drifted or has no file
+func (m *Migrator) verify(applied map[int64]*appliedMigration) error {
+	known := make(map[int64]*Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	var drifted, missing []string
+	for version, row := range applied {
+		migration, ok := known[version]
+		switch {
+		case !ok:
+			missing = append(missing, fmt.Sprintf("%04d_%s", version, row.Name))
+		case migration.Checksum != row.Checksum:
+			drifted = append(drifted, fmt.Sprintf("%04d_%s", version, row.Name))
+		}
+	}
+	sort.Strings(drifted)
+	sort.Strings(missing)
+
+	if len(drifted) > 0 {
+		return fmt.Errorf("%w: %s (restore the original file and put the change in a new migration)", ErrChecksumDrift, strings.Join(drifted, ", "))
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingMigration, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// latestApplied returns up to n applied migrations, newest first
+func (m *Migrator) latestApplied(ctx context.Context, conn *sql.Conn, n int) ([]*Migration, error) {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	if n < len(versions) {
+		versions = versions[:n]
+	}
+
+	known := make(map[int64]*Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	targets := make([]*Migration, 0, len(versions))
+	for _, version := range versions {
+		migration, ok := known[version]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%w: %04d_%s", ErrMissingMigration, version, applied[version].Name)
+		case strings.TrimSpace(migration.Down) == "":
+			return nil, fmt.Errorf("%w: %04d_%s", ErrNoDownMigration, version, migration.Name)
+		case migration.Checksum != applied[version].Checksum:
+			log.Printf("⚠️  Migration %04d_%s changed since it was applied, reverting with the current down file", version, migration.Name)
+		}
+		targets = append(targets, migration)
+	}
+	return targets, nil
+}
+
+// apply runs the up or down file of migration and records the result
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration *Migration, up bool) error {
+	direction, script := "down", migration.Down
+	if up {
+		direction, script = "up", migration.Up
+	}
+	label := fmt.Sprintf("%04d_%s.%s", migration.Version, migration.Name, direction)
+
+	if m.DryRun {
+		log.Printf("🔎 [dry run] Would apply %s", label)
+		return nil
+	}
+
+	start := time.Now()
+	record := func(exec func(query string, args ...interface{}) (sql.Result, error)) error {
+		if _, err := exec(script); err != nil {
+			return fmt.Errorf("migration %s failed: %w", label, err)
+		}
+
+		var err error
+		if up {
+			_, err = exec(`
+				INSERT INTO schema_migrations (version, name, checksum, execution_ms)
+				VALUES ($1, $2, $3, $4)
+			`, migration.Version, migration.Name, migration.Checksum, time.Since(start).Milliseconds())
+		} else {
+			_, err = exec(`DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", label, err)
+		}
+		return nil
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(script), noTransactionDirective) {
+		if err := record(func(query string, args ...interface{}) (sql.Result, error) {
+			return conn.ExecContext(ctx, query, args...)
+		}); err != nil {
+			return err
+		}
+	} else {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %s: %w", label, err)
+		}
+		if err := record(func(query string, args ...interface{}) (sql.Result, error) {
+			return tx.ExecContext(ctx, query, args...)
+		}); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %s: %w", label, err)
+		}
+	}
+
+	log.Printf("✅ Applied %s (%s)", label, time.Since(start).Round(time.Millisecond))
+	return nil
+}
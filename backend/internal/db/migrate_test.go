@@ -0,0 +1,89 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(Migrations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 {
+		t.Fatalf("want migrations starting at version 1, got %d", len(migrations))
+	}
+
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("gap before %04d_%s", m.Version, m.Name)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("%04d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_kits.up.sql":          {Data: []byte("CREATE TABLE kits ();")},
+		"0002_add_kits.down.sql":        {Data: []byte("DROP TABLE kits;")},
+		"0001_initial_schema.up.sql":    {Data: []byte("CREATE TABLE users ();")},
+		"0001_initial_schema.down.sql":  {Data: []byte("DROP TABLE users;")},
+		"README.md":                     {Data: []byte("ignored")},
+		"0010_without_down_file.up.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, m := range migrations {
+		names = append(names, m.Name)
+	}
+	if got := strings.Join(names, ","); got != "initial_schema,add_kits,without_down_file" {
+		t.Errorf("order = %s", got)
+	}
+	if migrations[0].Checksum != checksum([]byte("CREATE TABLE users ();")) {
+		t.Error("checksum is not the SHA-256 of the up file")
+	}
+
+	invalid := map[string]fstest.MapFS{
+		"bad name":        {"1-init.up.sql": {Data: []byte("x")}},
+		"down only":       {"0001_init.down.sql": {Data: []byte("x")}},
+		"reused version":  {"0001_a.up.sql": {Data: []byte("x")}, "0001_b.up.sql": {Data: []byte("y")}},
+		"version zero":    {"0000_init.up.sql": {Data: []byte("x")}},
+		"uppercase names": {"0001_Init.up.sql": {Data: []byte("x")}},
+	}
+	for name, fsys := range invalid {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestVerifyDetectsDrift(t *testing.T) {
+	m := &Migrator{migrations: []*Migration{
+		{Version: 1, Name: "init", Checksum: "aaa"},
+		{Version: 2, Name: "kits", Checksum: "bbb"},
+	}}
+
+	ok := map[int64]*appliedMigration{1: {Version: 1, Name: "init", Checksum: "aaa"}}
+	if err := m.verify(ok); err != nil {
+		t.Errorf("verify = %v, want nil", err)
+	}
+
+	drifted := map[int64]*appliedMigration{1: {Version: 1, Name: "init", Checksum: "changed"}}
+	if err := m.verify(drifted); !errors.Is(err, ErrChecksumDrift) {
+		t.Errorf("verify = %v, want ErrChecksumDrift", err)
+	}
+
+	missing := map[int64]*appliedMigration{3: {Version: 3, Name: "deleted", Checksum: "ccc"}}
+	if err := m.verify(missing); !errors.Is(err, ErrMissingMigration) {
+		t.Errorf("verify = %v, want ErrMissingMigration", err)
+	}
+}
@@ -0,0 +1,67 @@
+-- ============================================================================
+-- REVERT INITIAL SCHEMA
+-- Drops every object created by 0001_initial_schema.up.sql (all data is lost)
+-- ============================================================================
+
+-- Views
+DROP VIEW IF EXISTS recent_security_events;
+DROP VIEW IF EXISTS active_sessions;
+DROP VIEW IF EXISTS active_users;
+
+-- Tables (partitions go with their parent, triggers with their table)
+DROP TABLE IF EXISTS
+    user_tags,
+    tags,
+    data_export_requests,
+    scheduled_jobs,
+    notifications,
+    api_keys,
+    email_queue,
+    rate_limit_audit,
+    rate_limit_ip_rules,
+    rate_limit_overrides,
+    rate_limit_log,
+    rate_limit_rules,
+    account_status_changes,
+    security_events,
+    user_activity_log,
+    login_activity,
+    user_consents,
+    user_preferences,
+    user_push_tokens,
+    user_sessions,
+    two_factor_backup_codes,
+    user_social_auth,
+    phone_verification_codes,
+    email_verification_tokens,
+    password_reset_tokens,
+    password_history,
+    user_security_info,
+    user_credentials,
+    users
+CASCADE;
+
+-- Functions
+DROP FUNCTION IF EXISTS calculate_security_score;
+DROP FUNCTION IF EXISTS get_user_security_summary;
+DROP FUNCTION IF EXISTS search_users;
+DROP FUNCTION IF EXISTS check_rate_limit;
+DROP FUNCTION IF EXISTS restore_user;
+DROP FUNCTION IF EXISTS soft_delete_user;
+DROP FUNCTION IF EXISTS archive_old_activity_logs;
+DROP FUNCTION IF EXISTS archive_old_login_activity;
+DROP FUNCTION IF EXISTS clean_expired_tokens;
+DROP FUNCTION IF EXISTS update_session_last_used;
+DROP FUNCTION IF EXISTS initialize_user_defaults;
+DROP FUNCTION IF EXISTS update_updated_at_column;
+
+-- Types
+DROP TYPE IF EXISTS email_status;
+DROP TYPE IF EXISTS theme_type;
+DROP TYPE IF EXISTS platform_type;
+DROP TYPE IF EXISTS social_provider;
+DROP TYPE IF EXISTS severity_type;
+DROP TYPE IF EXISTS account_status_type;
+DROP TYPE IF EXISTS user_role;
+
+-- Extensions are left installed: other schemas may depend on them
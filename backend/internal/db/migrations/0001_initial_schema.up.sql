@@ -3,6 +3,11 @@
 -- PostgreSQL Database Schema
 -- Version: 2.0
 -- Last Updated: January 14, 2026
+--
+-- Baseline migration (formerly schema.sql). Every statement is idempotent so
+-- databases created from schema.sql adopt it without changes. Schema changes
+-- go into new numbered migrations, never into this file: its checksum is
+-- recorded in schema_migrations.
 -- ============================================================================
 
 -- ============================================================================
@@ -603,6 +608,62 @@ CREATE INDEX IF NOT EXISTS idx_rate_limit_action ON rate_limit_log(action);
 CREATE INDEX IF NOT EXISTS idx_rate_limit_window_end ON rate_limit_log(window_end);
 CREATE INDEX IF NOT EXISTS idx_rate_limit_blocked ON rate_limit_log(blocked);
 
+-- Rule modes: 'enforce' blocks, 'shadow' only logs would-be blocks, 'off' ignores the rule
+ALTER TABLE rate_limit_rules ADD COLUMN IF NOT EXISTS mode VARCHAR(10) NOT NULL DEFAULT 'enforce';
+ALTER TABLE rate_limit_log ADD COLUMN IF NOT EXISTS mode VARCHAR(10) NOT NULL DEFAULT 'enforce';
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_log_mode_action ON rate_limit_log(mode, action);
+
+-- Admin-managed quota overrides per identifier (user_id, ip, ...)
+-- API key quotas are stored on api_keys directly
+CREATE TABLE IF NOT EXISTS rate_limit_overrides (
+    id SERIAL PRIMARY KEY,
+    identifier_type VARCHAR(20) NOT NULL, -- 'user_id', 'ip', 'email'
+    identifier VARCHAR(255) NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    max_attempts INT NOT NULL,
+    window_size_seconds INT NOT NULL,
+    block_duration_seconds INT NOT NULL,
+    reason TEXT,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (identifier_type, identifier, action)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_overrides_action ON rate_limit_overrides(action);
+
+-- Admin-managed CIDR lists: 'allow' bypasses rate limits, 'deny' is rejected
+CREATE TABLE IF NOT EXISTS rate_limit_ip_rules (
+    id SERIAL PRIMARY KEY,
+    cidr CIDR UNIQUE NOT NULL,
+    list_type VARCHAR(10) NOT NULL CHECK (list_type IN ('allow', 'deny')),
+    reason TEXT,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_ip_rules_list_type ON rate_limit_ip_rules(list_type);
+
+-- Audit trail of blocks and admin interventions (Redis keys expire, this does not)
+CREATE TABLE IF NOT EXISTS rate_limit_audit (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(20) NOT NULL, -- 'blocked', 'manual_block', 'unblock', 'reset'
+    identifier VARCHAR(255) NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    count INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 0,
+    blocked_until TIMESTAMP,
+    actor_id INT REFERENCES users(id) ON DELETE SET NULL, -- NULL = blocked by the limiter
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_audit_created_at ON rate_limit_audit(created_at);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_audit_action ON rate_limit_audit(action, created_at);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_audit_identifier ON rate_limit_audit(identifier, created_at);
+
 -- ============================================================================
 -- EMAIL QUEUE
 -- ============================================================================
@@ -675,6 +736,11 @@ CREATE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys(key_hash);
 CREATE INDEX IF NOT EXISTS idx_api_keys_key_prefix ON api_keys(key_prefix);
 CREATE INDEX IF NOT EXISTS idx_api_keys_expires_at ON api_keys(expires_at);
 
+-- Per-key API quota (NULL = tier default)
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS rate_limit_max_requests INT;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS rate_limit_window_seconds INT;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS rate_limit_block_seconds INT;
+
 -- ============================================================================
 -- NOTIFICATIONS
 -- ============================================================================
@@ -843,6 +909,16 @@ CREATE TRIGGER update_rate_limit_rules_updated_at
 BEFORE UPDATE ON rate_limit_rules
 FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_rate_limit_overrides_updated_at ON rate_limit_overrides;
+CREATE TRIGGER update_rate_limit_overrides_updated_at
+BEFORE UPDATE ON rate_limit_overrides
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_rate_limit_ip_rules_updated_at ON rate_limit_ip_rules;
+CREATE TRIGGER update_rate_limit_ip_rules_updated_at
+BEFORE UPDATE ON rate_limit_ip_rules
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
 DROP TRIGGER IF EXISTS update_scheduled_jobs_updated_at ON scheduled_jobs;
 CREATE TRIGGER update_scheduled_jobs_updated_at
 BEFORE UPDATE ON scheduled_jobs
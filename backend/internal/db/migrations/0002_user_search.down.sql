@@ -0,0 +1,51 @@
+-- ============================================================================
+-- REVERT USER SEARCH
+-- Restores the English full-text search_users of the initial schema
+-- ============================================================================
+
+DROP FUNCTION IF EXISTS search_users(TEXT, INT, REAL, account_status_type);
+
+DROP INDEX IF EXISTS idx_users_search_document;
+DROP INDEX IF EXISTS idx_users_name_trgm;
+DROP INDEX IF EXISTS idx_users_email_trgm;
+
+DROP FUNCTION IF EXISTS user_search_document(TEXT, TEXT);
+DROP FUNCTION IF EXISTS user_search_text(TEXT);
+DROP TEXT SEARCH CONFIGURATION IF EXISTS user_search;
+DROP EXTENSION IF EXISTS "unaccent";
+
+CREATE INDEX IF NOT EXISTS idx_users_name_gin ON users USING gin(to_tsvector('english', name));
+CREATE INDEX IF NOT EXISTS idx_users_email_gin ON users USING gin(to_tsvector('english', email));
+
+CREATE OR REPLACE FUNCTION search_users(
+    search_query TEXT,
+    limit_param INT DEFAULT 50
+)
+RETURNS TABLE (
+    id INT,
+    email VARCHAR,
+    name VARCHAR,
+    role user_role,
+    rank REAL
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT 
+        u.id,
+        u.email,
+        u.name,
+        u.role,
+        ts_rank(
+            to_tsvector('english', u.name || ' ' || u.email),
+            plainto_tsquery('english', search_query)
+        ) AS rank
+    FROM users u
+    WHERE u.deleted_at IS NULL
+    AND u.account_status = 'active'
+    AND (
+        to_tsvector('english', u.name || ' ' || u.email) @@ plainto_tsquery('english', search_query)
+    )
+    ORDER BY rank DESC
+    LIMIT limit_param;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,133 @@
+-- ============================================================================
+-- USER SEARCH
+-- Accent-insensitive full-text search with trigram fallback for typos.
+-- Names are not stemmed ('simple' dictionary): most of them are Vietnamese,
+-- and unaccent folds diacritics so "nguyen" finds "Nguyễn" and "dung" "Dũng".
+-- ============================================================================
+
+CREATE EXTENSION IF NOT EXISTS "unaccent";
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'user_search') THEN
+        CREATE TEXT SEARCH CONFIGURATION user_search (COPY = simple);
+        ALTER TEXT SEARCH CONFIGURATION user_search
+            ALTER MAPPING FOR asciiword, asciihword, hword_asciipart, word, hword, hword_part
+            WITH unaccent, simple;
+    END IF;
+END
+$$;
+
+-- Lowercased, unaccented text for trigram matching. unaccent() itself is
+-- only STABLE; pinning the dictionary makes this safe to index.
+CREATE OR REPLACE FUNCTION user_search_text(value TEXT)
+RETURNS TEXT
+LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE AS $$
+    SELECT lower(public.unaccent('public.unaccent'::regdictionary, value))
+$$;
+
+-- Full-text document of a user. Email separators become spaces so every
+-- part of the address is a word ("john.doe@example.com" matches "doe").
+CREATE OR REPLACE FUNCTION user_search_document(name TEXT, email TEXT)
+RETURNS tsvector
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT to_tsvector(
+        'public.user_search'::regconfig,
+        COALESCE(name, '') || ' ' || regexp_replace(COALESCE(email, ''), '[@._+-]+', ' ', 'g')
+    )
+$$;
+
+-- The English-stemmed indexes never matched search_users' expression
+DROP INDEX IF EXISTS idx_users_name_gin;
+DROP INDEX IF EXISTS idx_users_email_gin;
+
+CREATE INDEX IF NOT EXISTS idx_users_search_document ON users USING gin (user_search_document(name, email));
+CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON users USING gin (user_search_text(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON users USING gin (user_search_text(email) gin_trgm_ops);
+
+-- Search users: full-text prefix matches first, ranked with ts_rank_cd
+-- (normalized to 0..1), then trigram matches for typos ranked by word
+-- similarity. Full-text matches come back with <mark> highlights (an
+-- address is one token there, so it is marked whole when it matches).
+DROP FUNCTION IF EXISTS search_users(TEXT, INT);
+
+CREATE OR REPLACE FUNCTION search_users(
+    search_query TEXT,
+    limit_param INT DEFAULT 20,
+    min_similarity REAL DEFAULT 0.3,
+    status_filter account_status_type DEFAULT NULL
+)
+RETURNS TABLE (
+    id INT,
+    email VARCHAR,
+    name VARCHAR,
+    role user_role,
+    account_status account_status_type,
+    match_type TEXT,
+    score REAL,
+    name_highlight TEXT,
+    email_highlight TEXT
+) AS $$
+DECLARE
+    normalized TEXT := user_search_text(trim(search_query));
+    prefix_query TEXT;
+    tsq tsquery;
+BEGIN
+    -- Every word becomes a prefix term: "nguy van" matches "Nguyễn Văn An"
+    SELECT string_agg(word || ':*', ' & ')
+    INTO prefix_query
+    FROM regexp_split_to_table(normalized, '[^[:alnum:]]+') AS word
+    WHERE word <> '';
+
+    IF prefix_query IS NULL THEN
+        RETURN;
+    END IF;
+    tsq := to_tsquery('user_search', prefix_query);
+
+    -- Threshold of the index-backed <% operator below
+    PERFORM set_config('pg_trgm.word_similarity_threshold', min_similarity::TEXT, TRUE);
+
+    RETURN QUERY
+    WITH matches AS (
+        SELECT
+            u.id AS user_id,
+            u.email AS user_email,
+            u.name AS user_name,
+            u.role AS user_role,
+            u.account_status AS user_status,
+            user_search_document(u.name, u.email) @@ tsq AS fulltext,
+            ts_rank_cd(user_search_document(u.name, u.email), tsq, 32) AS rank,
+            GREATEST(
+                word_similarity(normalized, user_search_text(u.name)),
+                word_similarity(normalized, user_search_text(u.email))
+            ) AS similarity
+        FROM users u
+        WHERE u.deleted_at IS NULL
+        AND (status_filter IS NULL OR u.account_status = status_filter)
+        AND (
+            user_search_document(u.name, u.email) @@ tsq
+            OR normalized <% user_search_text(u.name)
+            OR normalized <% user_search_text(u.email)
+        )
+    )
+    SELECT
+        m.user_id,
+        m.user_email,
+        m.user_name,
+        m.user_role,
+        m.user_status,
+        CASE WHEN m.fulltext THEN 'fulltext' ELSE 'fuzzy' END,
+        (CASE WHEN m.fulltext THEN m.rank ELSE m.similarity END)::REAL,
+        CASE WHEN m.fulltext
+            THEN ts_headline('user_search', m.user_name, tsq, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')
+            ELSE m.user_name
+        END,
+        CASE WHEN m.fulltext
+            THEN ts_headline('user_search', m.user_email, tsq, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')
+            ELSE m.user_email
+        END
+    FROM matches m
+    ORDER BY m.fulltext DESC, 7 DESC, m.user_id
+    LIMIT limit_param;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,11 @@
+-- ============================================================================
+-- REVERT ROW VERSIONS
+-- ============================================================================
+
+DROP TRIGGER IF EXISTS bump_user_preferences_version ON user_preferences;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS version;
+
+DROP TRIGGER IF EXISTS bump_users_version ON users;
+ALTER TABLE users DROP COLUMN IF EXISTS version;
+
+DROP FUNCTION IF EXISTS bump_row_version();
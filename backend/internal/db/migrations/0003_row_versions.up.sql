@@ -0,0 +1,27 @@
+-- ============================================================================
+-- ROW VERSIONS (Optimistic concurrency)
+-- Every update of a versioned table increments its version, whoever makes
+-- it; writers that read a row first update it only if the version they
+-- read is still current (see postgres.UpdateVersioned) and expose it as
+-- the ETag of the resource.
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION bump_row_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+DROP TRIGGER IF EXISTS bump_users_version ON users;
+CREATE TRIGGER bump_users_version
+BEFORE UPDATE ON users
+FOR EACH ROW EXECUTE FUNCTION bump_row_version();
+
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+DROP TRIGGER IF EXISTS bump_user_preferences_version ON user_preferences;
+CREATE TRIGGER bump_user_preferences_version
+BEFORE UPDATE ON user_preferences
+FOR EACH ROW EXECUTE FUNCTION bump_row_version();
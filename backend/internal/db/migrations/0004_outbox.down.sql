@@ -0,0 +1,6 @@
+-- ============================================================================
+-- REVERT TRANSACTIONAL OUTBOX
+-- ============================================================================
+
+DROP TABLE IF EXISTS outbox_deliveries;
+DROP TABLE IF EXISTS outbox;
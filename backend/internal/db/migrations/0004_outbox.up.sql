@@ -0,0 +1,39 @@
+-- ============================================================================
+-- TRANSACTIONAL OUTBOX
+-- Domain events are inserted in the transaction of the state change they
+-- describe and delivered afterwards by the outbox dispatcher, at least once
+-- per subscriber.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id VARCHAR(100) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL UNIQUE, -- Same key = same event, inserted once
+    payload JSONB NOT NULL,
+
+    -- Delivery
+    attempts INT NOT NULL DEFAULT 0,
+    available_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, -- Next attempt (retry backoff)
+    locked_until TIMESTAMP, -- Claimed by a dispatcher until then
+    last_error TEXT,
+    published_at TIMESTAMP, -- Every subscriber succeeded
+    failed_at TIMESTAMP, -- Gave up after the maximum attempts
+
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(available_at)
+    WHERE published_at IS NULL AND failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_published_at ON outbox(published_at)
+    WHERE published_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate ON outbox(aggregate_type, aggregate_id);
+
+-- Subscribers that already handled an event; retries skip them
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+    event_id BIGINT NOT NULL REFERENCES outbox(id) ON DELETE CASCADE,
+    subscriber VARCHAR(100) NOT NULL,
+    delivered_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (event_id, subscriber)
+);
@@ -0,0 +1,95 @@
+-- ============================================================================
+-- REVERT USER DELETION
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_users_purge_due;
+ALTER TABLE users DROP COLUMN IF EXISTS purged_at;
+
+-- Soft delete user
+CREATE OR REPLACE FUNCTION soft_delete_user(
+    user_id_param INT, 
+    deleted_by_param INT,
+    reason_param TEXT DEFAULT NULL
+)
+RETURNS void AS $$
+BEGIN
+    -- Update user
+    UPDATE users 
+    SET 
+        deleted_at = CURRENT_TIMESTAMP,
+        deleted_by = deleted_by_param,
+        account_status = 'closed',
+        status_reason = reason_param,
+        status_changed_at = CURRENT_TIMESTAMP,
+        status_changed_by = deleted_by_param
+    WHERE id = user_id_param AND deleted_at IS NULL;
+    
+    -- Revoke all sessions
+    UPDATE user_sessions 
+    SET 
+        revoked_at = CURRENT_TIMESTAMP,
+        revoked_by = deleted_by_param,
+        revoke_reason = 'User account deleted'
+    WHERE user_id = user_id_param AND revoked_at IS NULL;
+    
+    -- Deactivate push tokens
+    UPDATE user_push_tokens 
+    SET 
+        is_active = FALSE,
+        deactivated_at = CURRENT_TIMESTAMP,
+        deactivation_reason = 'User account deleted'
+    WHERE user_id = user_id_param AND is_active = TRUE;
+    
+    -- Revoke API keys
+    UPDATE api_keys
+    SET 
+        revoked_at = CURRENT_TIMESTAMP,
+        revoked_by = deleted_by_param,
+        revoke_reason = 'User account deleted'
+    WHERE user_id = user_id_param AND revoked_at IS NULL;
+    
+    -- Create security event
+    INSERT INTO security_events (
+        user_id,
+        event_type,
+        severity,
+        description,
+        ip_address
+    ) VALUES (
+        user_id_param,
+        'account_deleted',
+        'high',
+        'User account was soft deleted. Reason: ' || COALESCE(reason_param, 'Not specified'),
+        inet_client_addr()::VARCHAR
+    );
+END;
+$$ LANGUAGE plpgsql;
+
+-- Restore user
+CREATE OR REPLACE FUNCTION restore_user(user_id_param INT)
+RETURNS void AS $$
+BEGIN
+    UPDATE users 
+    SET 
+        deleted_at = NULL,
+        deleted_by = NULL,
+        account_status = 'active'
+    WHERE id = user_id_param AND deleted_at IS NOT NULL;
+    
+    -- Create security event
+    INSERT INTO security_events (
+        user_id,
+        event_type,
+        severity,
+        description,
+        ip_address
+    ) VALUES (
+        user_id_param,
+        'account_restored',
+        'medium',
+        'User account was restored',
+        inet_client_addr()::VARCHAR
+    );
+END;
+$$ LANGUAGE plpgsql;
+
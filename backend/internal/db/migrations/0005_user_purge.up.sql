@@ -0,0 +1,14 @@
+-- ============================================================================
+-- USER DELETION
+-- Soft delete, restore and purge are use cases in Go (usecase.UserDeletion),
+-- so the plpgsql versions are dropped. A purge erases the personal data of
+-- an account deleted longer than the retention period and frees its email.
+-- ============================================================================
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS purged_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_purge_due ON users(deleted_at)
+WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+
+DROP FUNCTION IF EXISTS soft_delete_user(INT, INT, TEXT);
+DROP FUNCTION IF EXISTS restore_user(INT);
@@ -0,0 +1,85 @@
+-- ============================================================================
+-- REVERT USER SEARCH HIGHLIGHT DELIMITERS
+-- Restores the <mark> delimiters of 0002
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION search_users(
+    search_query TEXT,
+    limit_param INT DEFAULT 20,
+    min_similarity REAL DEFAULT 0.3,
+    status_filter account_status_type DEFAULT NULL
+)
+RETURNS TABLE (
+    id INT,
+    email VARCHAR,
+    name VARCHAR,
+    role user_role,
+    account_status account_status_type,
+    match_type TEXT,
+    score REAL,
+    name_highlight TEXT,
+    email_highlight TEXT
+) AS $$
+DECLARE
+    normalized TEXT := user_search_text(trim(search_query));
+    prefix_query TEXT;
+    tsq tsquery;
+BEGIN
+    -- Every word becomes a prefix term: "nguy van" matches "Nguyễn Văn An"
+    SELECT string_agg(word || ':*', ' & ')
+    INTO prefix_query
+    FROM regexp_split_to_table(normalized, '[^[:alnum:]]+') AS word
+    WHERE word <> '';
+
+    IF prefix_query IS NULL THEN
+        RETURN;
+    END IF;
+    tsq := to_tsquery('user_search', prefix_query);
+
+    -- Threshold of the index-backed <% operator below
+    PERFORM set_config('pg_trgm.word_similarity_threshold', min_similarity::TEXT, TRUE);
+
+    RETURN QUERY
+    WITH matches AS (
+        SELECT
+            u.id AS user_id,
+            u.email AS user_email,
+            u.name AS user_name,
+            u.role AS user_role,
+            u.account_status AS user_status,
+            user_search_document(u.name, u.email) @@ tsq AS fulltext,
+            ts_rank_cd(user_search_document(u.name, u.email), tsq, 32) AS rank,
+            GREATEST(
+                word_similarity(normalized, user_search_text(u.name)),
+                word_similarity(normalized, user_search_text(u.email))
+            ) AS similarity
+        FROM users u
+        WHERE u.deleted_at IS NULL
+        AND (status_filter IS NULL OR u.account_status = status_filter)
+        AND (
+            user_search_document(u.name, u.email) @@ tsq
+            OR normalized <% user_search_text(u.name)
+            OR normalized <% user_search_text(u.email)
+        )
+    )
+    SELECT
+        m.user_id,
+        m.user_email,
+        m.user_name,
+        m.user_role,
+        m.user_status,
+        CASE WHEN m.fulltext THEN 'fulltext' ELSE 'fuzzy' END,
+        (CASE WHEN m.fulltext THEN m.rank ELSE m.similarity END)::REAL,
+        CASE WHEN m.fulltext
+            THEN ts_headline('user_search', m.user_name, tsq, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')
+            ELSE m.user_name
+        END,
+        CASE WHEN m.fulltext
+            THEN ts_headline('user_search', m.user_email, tsq, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')
+            ELSE m.user_email
+        END
+    FROM matches m
+    ORDER BY m.fulltext DESC, 7 DESC, m.user_id
+    LIMIT limit_param;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,477 @@
+package db
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// PARTITION MANAGER
+// ============================================================================
+// login_activity and user_activity_log are range-partitioned by month, one
+// child table per month named <table>_YYYY_MM. Inserts fail when no child
+// covers created_at, so the manager keeps partitions created MonthsAhead
+// months in advance. Partitions older than Retention months are archived:
+// exported to <ArchiveDir>/<partition>.csv.gz and dropped, or only detached
+// when no archive directory is set.
+//
+// Maintenance holds a Postgres advisory lock, so with several instances only
+// one of them creates and detaches partitions at a time.
+
+// PartitionedTables are the tables partitioned by month on created_at
+var PartitionedTables = []string{"login_activity", "user_activity_log"}
+
+// partitionLockKey identifies the advisory lock held during maintenance
+const partitionLockKey int64 = 4_815_162_343
+
+// partitionMonthLayout is the suffix of partition names
+const partitionMonthLayout = "2006_01"
+
+// Partition health states
+const (
+	PartitionOK       = "ok"
+	PartitionWarning  = "warning"  // Next month has no partition yet
+	PartitionCritical = "critical" // Current month has no partition: inserts fail
+)
+
+// PartitionOptions configures a PartitionManager
+type PartitionOptions struct {
+	MonthsAhead int    // Future months created in advance
+	Retention   int    // Full months kept attached before the current one (0 = keep all)
+	ArchiveDir  string // Where archived partitions are written (empty = only detach)
+}
+
+// PartitionHealth describes the partitions of one table
+type PartitionHealth struct {
+	Table       string `json:"table"`
+	Status      string `json:"status"`
+	Partitions  int    `json:"partitions"`
+	Oldest      string `json:"oldest,omitempty"` // YYYY-MM
+	Newest      string `json:"newest,omitempty"` // YYYY-MM
+	MonthsAhead int    `json:"months_ahead"`     // Consecutive future months covered
+}
+
+// PartitionReport is the health of all managed tables
+type PartitionReport struct {
+	Status    string            `json:"status"` // Worst table status
+	Tables    []PartitionHealth `json:"tables"`
+	LastRun   *time.Time        `json:"last_run,omitempty"`
+	LastError string            `json:"last_error,omitempty"`
+}
+
+// PartitionManager creates and archives monthly partitions
+type PartitionManager struct {
+	db     *sql.DB
+	tables []string
+	opts   PartitionOptions
+	clock  clock.Clock
+
+	mu      sync.Mutex
+	lastRun time.Time
+	lastErr error
+}
+
+// NewPartitionManager creates a manager for the given partitioned tables
+func NewPartitionManager(db *sql.DB, tables []string, opts PartitionOptions, clk clock.Clock) *PartitionManager {
+	return &PartitionManager{
+		db:     db,
+		tables: tables,
+		opts:   opts,
+		clock:  clock.OrSystem(clk),
+	}
+}
+
+// Start runs maintenance now and then every interval until ctx is done.
+// The first run is synchronous so the current month exists before serving.
+func (m *PartitionManager) Start(ctx context.Context, interval time.Duration) {
+	if err := m.Run(ctx); err != nil {
+		log.Printf("⚠️  Partition maintenance failed: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := m.Run(ctx); err != nil {
+					log.Printf("⚠️  Partition maintenance failed: %v", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Run creates missing partitions and archives expired ones once
+func (m *PartitionManager) Run(ctx context.Context) error {
+	err := m.withTryLock(ctx, func(conn *sql.Conn) error {
+		for _, table := range m.tables {
+			if err := m.maintain(ctx, conn, table); err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+		}
+		return nil
+	})
+
+	m.mu.Lock()
+	m.lastRun, m.lastErr = m.clock.Now(), err
+	m.mu.Unlock()
+
+	return err
+}
+
+// Health reports partition coverage of every managed table
+func (m *PartitionManager) Health(ctx context.Context) (*PartitionReport, error) {
+	now := m.clock.Now()
+	report := &PartitionReport{Status: PartitionOK}
+
+	for _, table := range m.tables {
+		months, err := listPartitions(ctx, m.db, table)
+		if err != nil {
+			return nil, err
+		}
+		health := partitionHealth(table, months, now)
+		if statusRank(health.Status) > statusRank(report.Status) {
+			report.Status = health.Status
+		}
+		report.Tables = append(report.Tables, health)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.lastRun.IsZero() {
+		lastRun := m.lastRun
+		report.LastRun = &lastRun
+	}
+	if m.lastErr != nil {
+		report.LastError = m.lastErr.Error()
+	}
+
+	return report, nil
+}
+
+// maintain creates and archives the partitions of one table
+func (m *PartitionManager) maintain(ctx context.Context, conn *sql.Conn, table string) error {
+	existing, err := listPartitions(ctx, conn, table)
+	if err != nil {
+		return err
+	}
+
+	create, archive := planPartitions(existing, m.clock.Now(), m.opts.MonthsAhead, m.opts.Retention)
+
+	for _, month := range create {
+		if err := createPartition(ctx, conn, table, month); err != nil {
+			return err
+		}
+		log.Printf("🗂️  Created partition %s", partitionName(table, month))
+	}
+
+	for _, month := range archive {
+		if err := m.archivePartition(ctx, conn, table, month); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// archivePartition exports (when configured), detaches and drops one partition.
+// The export runs first, so a failure leaves the partition attached and the
+// next run retries it.
+func (m *PartitionManager) archivePartition(ctx context.Context, conn *sql.Conn, table string, month time.Time) error {
+	name := partitionName(table, month)
+
+	var file string
+	if m.opts.ArchiveDir != "" {
+		file = filepath.Join(m.opts.ArchiveDir, name+".csv.gz")
+		if err := exportPartition(ctx, conn, name, file); err != nil {
+			return err
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(
+		`ALTER TABLE %s DETACH PARTITION %s`, pq.QuoteIdentifier(table), pq.QuoteIdentifier(name),
+	)); err != nil {
+		return fmt.Errorf("failed to detach partition %s: %w", name, err)
+	}
+
+	if file == "" {
+		log.Printf("📦 Detached partition %s", name)
+		return nil
+	}
+
+	if _, err := conn.ExecContext(ctx, `DROP TABLE `+pq.QuoteIdentifier(name)); err != nil {
+		return fmt.Errorf("failed to drop archived partition %s: %w", name, err)
+	}
+	log.Printf("📦 Archived partition %s to %s", name, file)
+	return nil
+}
+
+// withTryLock runs fn holding the maintenance advisory lock, or skips it
+// when another instance holds the lock
+func (m *PartitionManager) withTryLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, partitionLockKey).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to acquire partition lock: %w", err)
+	}
+	if !locked {
+		log.Println("🔒 Partition maintenance is running on another instance")
+		return nil
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, partitionLockKey); err != nil {
+			log.Printf("⚠️  Failed to release partition lock: %v", err)
+		}
+	}()
+
+	return fn(conn)
+}
+
+// ============================================================================
+// PARTITION HELPERS
+// ============================================================================
+
+// queryer is implemented by *sql.DB and *sql.Conn
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// listPartitions returns the months of the attached partitions of table,
+// ignoring children that do not follow the naming scheme
+func listPartitions(ctx context.Context, q queryer, table string) ([]time.Time, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = $1::regclass
+	`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var months []time.Time
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan partition name: %w", err)
+		}
+		if month, ok := parsePartitionMonth(table, name); ok {
+			months = append(months, month)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(months, func(i, j int) bool { return months[i].Before(months[j]) })
+	return months, nil
+}
+
+// createPartition creates the partition of table for month
+func createPartition(ctx context.Context, conn *sql.Conn, table string, month time.Time) error {
+	name := partitionName(table, month)
+	_, err := conn.ExecContext(ctx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')`,
+		pq.QuoteIdentifier(name), pq.QuoteIdentifier(table),
+		month.Format(time.DateOnly), month.AddDate(0, 1, 0).Format(time.DateOnly),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create partition %s: %w", name, err)
+	}
+	return nil
+}
+
+// exportPartition writes all rows of a partition to a gzipped CSV file with
+// a header row. NULLs are written as empty fields. The file is written under
+// a temporary name and renamed, so a partial export is never mistaken for a
+// complete one.
+func exportPartition(ctx context.Context, conn *sql.Conn, name, file string) (err error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	tmp := file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	rows, err := conn.QueryContext(ctx, `SELECT * FROM `+pq.QuoteIdentifier(name))
+	if err != nil {
+		return fmt.Errorf("failed to read partition %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(f)
+	w := csv.NewWriter(zw)
+	if err := w.Write(columns); err != nil {
+		return err
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	record := make([]string, len(columns))
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to scan partition row: %w", err)
+		}
+		for i, v := range values {
+			record[i] = v.String
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
+
+// planPartitions returns the months to create (current month through
+// monthsAhead months ahead) and the existing months to archive (older than
+// retention full months before the current one)
+func planPartitions(existing []time.Time, now time.Time, monthsAhead, retention int) (create, archive []time.Time) {
+	have := make(map[time.Time]bool, len(existing))
+	for _, month := range existing {
+		have[month] = true
+	}
+
+	current := monthStart(now)
+	for i := 0; i <= monthsAhead; i++ {
+		month := current.AddDate(0, i, 0)
+		if !have[month] {
+			create = append(create, month)
+		}
+	}
+
+	if retention > 0 {
+		cutoff := current.AddDate(0, -retention, 0)
+		for _, month := range existing {
+			if month.Before(cutoff) {
+				archive = append(archive, month)
+			}
+		}
+	}
+
+	return create, archive
+}
+
+// partitionHealth summarizes the sorted partition months of table
+func partitionHealth(table string, months []time.Time, now time.Time) PartitionHealth {
+	health := PartitionHealth{Table: table, Partitions: len(months)}
+	if len(months) > 0 {
+		health.Oldest = months[0].Format("2006-01")
+		health.Newest = months[len(months)-1].Format("2006-01")
+	}
+
+	have := make(map[time.Time]bool, len(months))
+	for _, month := range months {
+		have[month] = true
+	}
+
+	current := monthStart(now)
+	for have[current.AddDate(0, health.MonthsAhead+1, 0)] {
+		health.MonthsAhead++
+	}
+
+	switch {
+	case !have[current]:
+		health.Status = PartitionCritical
+	case health.MonthsAhead == 0:
+		health.Status = PartitionWarning
+	default:
+		health.Status = PartitionOK
+	}
+
+	return health
+}
+
+// statusRank orders health states from best to worst
+func statusRank(status string) int {
+	switch status {
+	case PartitionCritical:
+		return 2
+	case PartitionWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// partitionName is the child table of table for month
+func partitionName(table string, month time.Time) string {
+	return table + "_" + month.Format(partitionMonthLayout)
+}
+
+// parsePartitionMonth extracts the month from a partition name of table
+func parsePartitionMonth(table, name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, table+"_")
+	if !ok || len(suffix) != len(partitionMonthLayout) {
+		return time.Time{}, false
+	}
+	month, err := time.Parse(partitionMonthLayout, suffix)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+// monthStart is midnight UTC on the first day of the month of t
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
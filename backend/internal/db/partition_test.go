@@ -0,0 +1,73 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPlanPartitions(t *testing.T) {
+	now := time.Date(2026, time.May, 31, 23, 0, 0, 0, time.UTC)
+	existing := []time.Time{
+		month(2026, time.January), month(2026, time.February), month(2026, time.March),
+		month(2026, time.May), month(2026, time.June),
+	}
+
+	create, archive := planPartitions(existing, now, 3, 3)
+	if got, want := format(create), "2026_07,2026_08"; got != want {
+		t.Errorf("create = %s, want %s", got, want)
+	}
+	// Keep February..April before May, archive January
+	if got, want := format(archive), "2026_01"; got != want {
+		t.Errorf("archive = %s, want %s", got, want)
+	}
+
+	if _, archive := planPartitions(existing, now, 3, 0); len(archive) != 0 {
+		t.Errorf("retention 0 archived %s", format(archive))
+	}
+}
+
+func TestPartitionHealth(t *testing.T) {
+	now := time.Date(2026, time.April, 10, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		months []time.Time
+		status string
+		ahead  int
+	}{
+		{[]time.Time{month(2026, time.January), month(2026, time.March)}, PartitionCritical, 0},
+		{[]time.Time{month(2026, time.April)}, PartitionWarning, 0},
+		{[]time.Time{month(2026, time.April), month(2026, time.May), month(2026, time.July)}, PartitionOK, 1},
+	}
+	for _, c := range cases {
+		h := partitionHealth("login_activity", c.months, now)
+		if h.Status != c.status || h.MonthsAhead != c.ahead {
+			t.Errorf("%s: status %s ahead %d, want %s ahead %d", format(c.months), h.Status, h.MonthsAhead, c.status, c.ahead)
+		}
+	}
+}
+
+func TestParsePartitionMonth(t *testing.T) {
+	if m, ok := parsePartitionMonth("login_activity", "login_activity_2026_03"); !ok || !m.Equal(month(2026, time.March)) {
+		t.Errorf("parse = %v, %t", m, ok)
+	}
+	for _, name := range []string{"login_activity_default", "login_activity_2026_13", "user_activity_log_2026_03"} {
+		if _, ok := parsePartitionMonth("login_activity", name); ok {
+			t.Errorf("%s parsed as a login_activity partition", name)
+		}
+	}
+}
+
+func format(months []time.Time) string {
+	s := ""
+	for i, m := range months {
+		if i > 0 {
+			s += ","
+		}
+		s += m.Format(partitionMonthLayout)
+	}
+	return s
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/config"
@@ -15,8 +14,26 @@ import (
 
 var DB *sql.DB
 
-// InitDB initializes database connection using config
+// router routes reads to replicas; nil until Connect
+var router *Router
+
+// InitDB connects to the database and, with DB_AUTO_MIGRATE, applies pending migrations
 func InitDB() error {
+	if err := Connect(); err != nil {
+		return err
+	}
+
+	if config.Cfg.Database.AutoMigrate {
+		if err := RunMigrations(); err != nil {
+			return fmt.Errorf("❌ Failed to migrate database: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Connect opens the database connection pool using config
+func Connect() error {
 	// Get database URL from config
 	dbURL := config.Cfg.Database.URL
 	if dbURL == "" {
@@ -42,59 +59,48 @@ func InitDB() error {
 		return fmt.Errorf("❌ Cannot ping database: %w", err)
 	}
 
-	// Create tables from schema
-	if err := createTables(); err != nil {
-		return fmt.Errorf("❌ Failed to create tables: %w", err)
-	}
-
 	log.Println("✅ Database connected successfully!")
 	log.Printf("   Host: %s:%s", config.Cfg.Database.Host, config.Cfg.Database.Port)
 	log.Printf("   Database: %s", config.Cfg.Database.Name)
 	log.Printf("   Max Open Connections: %d", config.Cfg.Database.MaxOpenConns)
 	log.Printf("   Max Idle Connections: %d", config.Cfg.Database.MaxIdleConns)
 
-	return nil
+	return connectReplicas()
 }
 
-// createTables creates database tables from schema file
-func createTables() error {
-	// Try multiple possible schema file locations
-	schemaPaths := []string{
-		"internal/db/schema.sql",
-		"internal/database/schema.sql",
-		"db/schema.sql",
-		"schema.sql",
-	}
-
-	var schema []byte
-	var err error
-	var foundPath string
-
-	for _, path := range schemaPaths {
-		schema, err = os.ReadFile(path)
-		if err == nil {
-			foundPath = path
-			break
+// connectReplicas opens the configured read replicas and routes reads to them.
+// An unreachable replica starts out of rotation instead of failing startup.
+func connectReplicas() error {
+	var replicas []Replica
+	for i, url := range config.Cfg.Database.ReplicaURLs {
+		replicaDB, err := sql.Open("postgres", url)
+		if err != nil {
+			return fmt.Errorf("❌ Failed to open replica %d: %w", i+1, err)
 		}
-	}
+		replicaDB.SetMaxOpenConns(config.Cfg.Database.MaxOpenConns)
+		replicaDB.SetMaxIdleConns(config.Cfg.Database.MaxIdleConns)
+		replicaDB.SetConnMaxLifetime(config.Cfg.Database.ConnMaxLifetime)
+		replicaDB.SetConnMaxIdleTime(config.Cfg.Database.ConnMaxIdleTime)
 
-	if foundPath == "" {
-		log.Println("⚠️  No schema.sql file found, skipping table creation")
-		return nil
+		replicas = append(replicas, Replica{Name: fmt.Sprintf("replica-%d", i+1), DB: replicaDB})
 	}
 
-	// Execute schema
-	_, err = DB.Exec(string(schema))
-	if err != nil {
-		return fmt.Errorf("error executing schema from %s: %w", foundPath, err)
+	router = NewRouter(DB, replicas, config.Cfg.Database.ReplicaMaxLag)
+	if len(replicas) > 0 {
+		router.CheckReplicas(context.Background())
+		log.Printf("✅ Read replicas: %d configured", len(replicas))
 	}
-
-	log.Printf("✅ Tables created/updated from %s", foundPath)
 	return nil
 }
 
 // CloseDB gracefully closes database connection
 func CloseDB() error {
+	if router != nil {
+		if err := router.Close(); err != nil {
+			log.Printf("⚠️  Failed to close replicas: %v", err)
+		}
+		router = nil
+	}
 	if DB != nil {
 		if err := DB.Close(); err != nil {
 			return fmt.Errorf("failed to close database: %w", err)
@@ -144,53 +150,83 @@ func ExecuteSchemaFile(filepath string) error {
 	return nil
 }
 
-// RunMigrations runs database migrations
+// RunMigrations applies the pending embedded migrations
 func RunMigrations() error {
-	// Check if migrations directory exists
-	migrationPaths := []string{
-		"internal/db/migrations",
-		"internal/database/migrations",
-		"db/migrations",
-		"migrations",
-	}
-
-	var migrationsDir string
-	for _, path := range migrationPaths {
-		if _, err := os.Stat(path); err == nil {
-			migrationsDir = path
-			break
-		}
+	migrator, err := NewMigrator(DB, Migrations())
+	if err != nil {
+		return err
 	}
 
-	if migrationsDir == "" {
-		log.Println("⚠️  No migrations directory found, skipping migrations")
-		return nil
+	count, err := migrator.Up(context.Background())
+	if err != nil {
+		return err
 	}
 
-	// Read migration files
-	files, err := os.ReadDir(migrationsDir)
-	if err != nil {
-		return fmt.Errorf("failed to read migrations directory: %w", err)
+	if count == 0 {
+		log.Println("✅ Database schema is up to date")
+	} else {
+		log.Printf("✅ Applied %d migration(s)", count)
 	}
+	return nil
+}
 
-	// Execute each migration file in order
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
+// partitions is the partition manager started by StartPartitionMaintenance
+var partitions *PartitionManager
 
-		if !strings.HasSuffix(file.Name(), ".sql") {
-			continue
-		}
+// StartPartitionMaintenance keeps the monthly partitions of PartitionedTables
+// created ahead and archived per config, until ctx is done
+func StartPartitionMaintenance(ctx context.Context) {
+	cfg := config.Cfg.Database
+	if !cfg.PartitionMaintenance {
+		log.Println("⚠️  Partition maintenance is disabled")
+		return
+	}
 
-		filepath := fmt.Sprintf("%s/%s", migrationsDir, file.Name())
-		log.Printf("Running migration: %s", file.Name())
+	partitions = NewPartitionManager(DB, PartitionedTables, PartitionOptions{
+		MonthsAhead: cfg.PartitionMonthsAhead,
+		Retention:   cfg.PartitionRetention,
+		ArchiveDir:  cfg.PartitionArchiveDir,
+	}, nil)
+	partitions.Start(ctx, cfg.PartitionInterval)
+}
 
-		if err := ExecuteSchemaFile(filepath); err != nil {
-			return fmt.Errorf("failed to run migration %s: %w", file.Name(), err)
-		}
+// PartitionHealthCheck reports partition coverage, or nil when maintenance
+// is disabled
+func PartitionHealthCheck(ctx context.Context) (*PartitionReport, error) {
+	if partitions == nil {
+		return nil, nil
+	}
+	return partitions.Health(ctx)
+}
+
+// Reader returns the pool for reads: a healthy replica, or the primary
+// (see Router.Reader)
+func Reader(ctx context.Context) *sql.DB {
+	if router == nil {
+		return DB
 	}
+	return router.Reader(ctx)
+}
 
-	log.Println("✅ All migrations completed successfully")
-	return nil
+// Writer returns the primary pool and keeps the request's later reads on it
+func Writer(ctx context.Context) *sql.DB {
+	if router == nil {
+		return DB
+	}
+	return router.Writer(ctx)
+}
+
+// StartReplicaHealthChecks checks the replicas per config until ctx is done
+func StartReplicaHealthChecks(ctx context.Context) {
+	if router != nil {
+		router.StartHealthChecks(ctx, config.Cfg.Database.ReplicaCheckInterval)
+	}
+}
+
+// ReplicaHealthCheck reports the state of every replica
+func ReplicaHealthCheck() []ReplicaHealth {
+	if router == nil {
+		return nil
+	}
+	return router.Health()
 }
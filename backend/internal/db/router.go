@@ -0,0 +1,261 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============================================================================
+// READ REPLICA ROUTER
+// ============================================================================
+// Writes always go to the primary. Reads go round-robin to healthy replicas,
+// except when:
+//   - the context was marked with WithPrimary (caller needs fresh data)
+//   - the request already wrote (read-your-writes, see WithReadYourWrites)
+//   - no replica is healthy
+//
+// Replicas are pinged periodically; one failing the ping or lagging more
+// than maxLag is taken out of rotation until it recovers.
+
+// replicaCheckTimeout bounds one replica health check
+const replicaCheckTimeout = 3 * time.Second
+
+// Replica is a named read-only connection pool
+type Replica struct {
+	Name string // Shown in logs and health output (no credentials)
+	DB   *sql.DB
+}
+
+// ReplicaHealth is the state of one replica
+type ReplicaHealth struct {
+	Name      string     `json:"name"`
+	Healthy   bool       `json:"healthy"`
+	Lag       string     `json:"lag,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+// replica is a Replica with its health state
+type replica struct {
+	Replica
+	healthy atomic.Bool
+
+	mu        sync.Mutex
+	lag       time.Duration
+	lastErr   error
+	checkedAt time.Time
+}
+
+// Router routes queries between the primary and its replicas
+type Router struct {
+	primary  *sql.DB
+	replicas []*replica
+	maxLag   time.Duration // 0 = lag is not checked
+	next     atomic.Uint64
+}
+
+// NewRouter creates a router. Replicas start healthy; CheckReplicas or
+// StartHealthChecks takes failing ones out of rotation.
+func NewRouter(primary *sql.DB, replicas []Replica, maxLag time.Duration) *Router {
+	r := &Router{primary: primary, maxLag: maxLag}
+	for _, rep := range replicas {
+		rr := &replica{Replica: rep}
+		rr.healthy.Store(true)
+		r.replicas = append(r.replicas, rr)
+	}
+	return r
+}
+
+// Primary returns the primary pool
+func (r *Router) Primary() *sql.DB {
+	return r.primary
+}
+
+// Writer returns the primary pool and marks the request as having written,
+// so its later reads also go to the primary
+func (r *Router) Writer(ctx context.Context) *sql.DB {
+	if s, ok := ctx.Value(readYourWritesKey{}).(*readYourWrites); ok {
+		s.wrote.Store(true)
+	}
+	return r.primary
+}
+
+// Reader returns a healthy replica, or the primary when the context asks
+// for it, the request already wrote, or no replica is healthy
+func (r *Router) Reader(ctx context.Context) *sql.DB {
+	if len(r.replicas) == 0 || mustUsePrimary(ctx) {
+		return r.primary
+	}
+
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		rep := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		if rep.healthy.Load() {
+			return rep.DB
+		}
+	}
+	return r.primary
+}
+
+// StartHealthChecks checks the replicas every interval until ctx is done
+func (r *Router) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.CheckReplicas(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// CheckReplicas pings every replica and updates whether it gets reads
+func (r *Router) CheckReplicas(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, rep := range r.replicas {
+		wg.Add(1)
+		go func(rep *replica) {
+			defer wg.Done()
+			r.check(ctx, rep)
+		}(rep)
+	}
+	wg.Wait()
+}
+
+// check runs the health check of one replica and logs state changes
+func (r *Router) check(ctx context.Context, rep *replica) {
+	ctx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+	defer cancel()
+
+	lag, err := r.probe(ctx, rep.DB)
+
+	rep.mu.Lock()
+	rep.lag, rep.lastErr, rep.checkedAt = lag, err, time.Now()
+	rep.mu.Unlock()
+
+	healthy := err == nil
+	if rep.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		log.Printf("✅ Replica %s is back in rotation", rep.Name)
+	} else {
+		log.Printf("⚠️  Replica %s removed from rotation: %v", rep.Name, err)
+	}
+}
+
+// probe pings a replica and, with maxLag set, measures its replication lag
+func (r *Router) probe(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	if err := db.PingContext(ctx); err != nil {
+		return 0, err
+	}
+	if r.maxLag <= 0 {
+		return 0, nil
+	}
+
+	// A replica that replayed everything it received is caught up, however
+	// old its last replayed transaction is (idle primary)
+	var seconds float64
+	err := db.QueryRowContext(ctx, `
+		SELECT CASE
+			WHEN NOT pg_is_in_recovery() THEN 0
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END
+	`).Scan(&seconds)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read replication lag: %w", err)
+	}
+
+	lag := time.Duration(seconds * float64(time.Second))
+	if lag > r.maxLag {
+		return lag, fmt.Errorf("replication lag %s exceeds %s", lag.Round(time.Millisecond), r.maxLag)
+	}
+	return lag, nil
+}
+
+// Health reports the state of every replica
+func (r *Router) Health() []ReplicaHealth {
+	health := make([]ReplicaHealth, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		h := ReplicaHealth{Name: rep.Name, Healthy: rep.healthy.Load()}
+
+		rep.mu.Lock()
+		if !rep.checkedAt.IsZero() {
+			checkedAt := rep.checkedAt
+			h.CheckedAt = &checkedAt
+			if r.maxLag > 0 {
+				h.Lag = rep.lag.Round(time.Millisecond).String()
+			}
+		}
+		if rep.lastErr != nil {
+			h.LastError = rep.lastErr.Error()
+		}
+		rep.mu.Unlock()
+
+		health = append(health, h)
+	}
+	return health
+}
+
+// Close closes the replica pools (the primary is owned by the caller)
+func (r *Router) Close() error {
+	var errs []error
+	for _, rep := range r.replicas {
+		if err := rep.DB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("replica %s: %w", rep.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ============================================================================
+// ROUTING CONTEXT
+// ============================================================================
+
+// primaryKey marks a context whose reads must use the primary
+type primaryKey struct{}
+
+// readYourWritesKey holds the per-request write marker
+type readYourWritesKey struct{}
+
+// readYourWrites records whether a request wrote to the primary
+type readYourWrites struct {
+	wrote atomic.Bool
+}
+
+// WithPrimary forces reads made with the returned context to the primary
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// WithReadYourWrites starts read-your-writes tracking: once a write is made
+// with the returned context (or one derived from it), its reads go to the
+// primary. Call it once per request.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readYourWritesKey{}, &readYourWrites{})
+}
+
+// mustUsePrimary reports whether reads with ctx must go to the primary
+func mustUsePrimary(ctx context.Context) bool {
+	if forced, _ := ctx.Value(primaryKey{}).(bool); forced {
+		return true
+	}
+	s, ok := ctx.Value(readYourWritesKey{}).(*readYourWrites)
+	return ok && s.wrote.Load()
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// stubConnector opens connections that can only be pinged; while down,
+// connecting and pinging fail
+type stubConnector struct{ down atomic.Bool }
+
+func (s *stubConnector) Connect(context.Context) (driver.Conn, error) {
+	if s.down.Load() {
+		return nil, errors.New("connection refused")
+	}
+	return stubConn{s}, nil
+}
+func (s *stubConnector) Driver() driver.Driver { return nil }
+
+type stubConn struct{ s *stubConnector }
+
+func (stubConn) Prepare(string) (driver.Stmt, error) { return nil, errors.ErrUnsupported }
+func (stubConn) Close() error                        { return nil }
+func (stubConn) Begin() (driver.Tx, error)           { return nil, errors.ErrUnsupported }
+
+func (c stubConn) Ping(context.Context) error {
+	if c.s.down.Load() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func TestRouter(t *testing.T) {
+	primary := sql.OpenDB(&stubConnector{})
+	a, b := &stubConnector{}, &stubConnector{}
+	replicaA, replicaB := sql.OpenDB(a), sql.OpenDB(b)
+	router := NewRouter(primary, []Replica{{Name: "a", DB: replicaA}, {Name: "b", DB: replicaB}}, 0)
+	defer router.Close()
+
+	ctx := context.Background()
+
+	// Reads alternate between replicas
+	seen := map[*sql.DB]int{}
+	for i := 0; i < 4; i++ {
+		seen[router.Reader(ctx)]++
+	}
+	if seen[replicaA] != 2 || seen[replicaB] != 2 {
+		t.Errorf("reads = a:%d b:%d primary:%d, want 2/2/0", seen[replicaA], seen[replicaB], seen[primary])
+	}
+
+	// A failing replica leaves rotation and comes back once healthy
+	b.down.Store(true)
+	router.CheckReplicas(ctx)
+	for i := 0; i < 3; i++ {
+		if db := router.Reader(ctx); db != replicaA {
+			t.Fatal("read routed to a down replica")
+		}
+	}
+	a.down.Store(true)
+	router.CheckReplicas(ctx)
+	if router.Reader(ctx) != primary {
+		t.Error("reads without healthy replicas should use the primary")
+	}
+	if h := router.Health(); h[0].Healthy || h[0].LastError == "" {
+		t.Errorf("health = %+v", h[0])
+	}
+	a.down.Store(false)
+	router.CheckReplicas(ctx)
+	if router.Reader(ctx) != replicaA {
+		t.Error("recovered replica not back in rotation")
+	}
+
+	// Forced primary
+	if router.Reader(WithPrimary(ctx)) != primary {
+		t.Error("WithPrimary read used a replica")
+	}
+
+	// Read-your-writes only after a write in the same request
+	request := WithReadYourWrites(ctx)
+	if router.Reader(request) == primary {
+		t.Error("read before any write used the primary")
+	}
+	if router.Writer(request) != primary {
+		t.Error("writer is not the primary")
+	}
+	if router.Reader(request) != primary {
+		t.Error("read after a write used a replica")
+	}
+	if router.Reader(WithReadYourWrites(ctx)) == primary {
+		t.Error("stickiness leaked into another request")
+	}
+}
@@ -54,6 +54,10 @@ type User struct {
 	Name  string   `json:"name" db:"name"`
 	Role  UserRole `json:"role" db:"role"`
 
+	// Incremented on every update (optimistic concurrency, see the ETag
+	// of GET /users/:id)
+	Version int `json:"version" db:"version"`
+
 	// Account status
 	AccountStatus   AccountStatus `json:"account_status" db:"account_status"`
 	StatusChangedAt *time.Time    `json:"status_changed_at,omitempty" db:"status_changed_at"`
@@ -74,6 +78,9 @@ type User struct {
 	EmailVerified bool `json:"email_verified" db:"email_verified"`
 	PhoneVerified bool `json:"phone_verified" db:"phone_verified"`
 
+	// Set when the personal data of a deleted account has been erased
+	PurgedAt *time.Time `json:"-" db:"purged_at"`
+
 	// Audit fields
 	AuditFields
 }
@@ -87,6 +94,11 @@ func (u *User) IsActive() bool {
 	return u.AccountStatus == AccountActive && !u.IsDeleted()
 }
 
+// IsPurged checks if the personal data of the account has been erased
+func (u *User) IsPurged() bool {
+	return u.PurgedAt != nil
+}
+
 // IsVerified checks if user has verified their email
 func (u *User) IsVerified() bool {
 	return u.EmailVerified
@@ -150,5 +162,6 @@ type AccountStatusChange struct {
 	NewStatus AccountStatus `json:"new_status" db:"new_status"`
 	Reason    *string       `json:"reason,omitempty" db:"reason"`
 	ChangedBy int           `json:"changed_by" db:"changed_by"`
+	IPAddress *string       `json:"ip_address,omitempty" db:"ip_address"`
 	CreatedAt time.Time     `json:"created_at" db:"created_at"`
 }
@@ -0,0 +1,69 @@
+package user
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// ============================================================================
+// API KEYS
+// ============================================================================
+
+// APIKey represents an API key issued to a user for programmatic access
+type APIKey struct {
+	ID          int      `json:"id" db:"id"`
+	UserID      int      `json:"user_id" db:"user_id"`
+	KeyHash     string   `json:"-" db:"key_hash"`
+	KeyPrefix   string   `json:"key_prefix" db:"key_prefix"`
+	Name        string   `json:"name" db:"name"`
+	Description *string  `json:"description,omitempty" db:"description"`
+	Scopes      []string `json:"scopes" db:"scopes"`
+
+	// Per-key quota (nil = tier default)
+	RateLimitMaxRequests   *int `json:"rate_limit_max_requests,omitempty" db:"rate_limit_max_requests"`
+	RateLimitWindowSeconds *int `json:"rate_limit_window_seconds,omitempty" db:"rate_limit_window_seconds"`
+	RateLimitBlockSeconds  *int `json:"rate_limit_block_seconds,omitempty" db:"rate_limit_block_seconds"`
+
+	// Usage
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	UsageCount int        `json:"usage_count" db:"usage_count"`
+
+	// Lifecycle
+	ExpiresAt    *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedBy    *int       `json:"-" db:"revoked_by"`
+	RevokeReason *string    `json:"-" db:"revoke_reason"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// IsExpired checks if the key is expired
+func (k *APIKey) IsExpired() bool {
+	if k.ExpiresAt == nil {
+		return false
+	}
+	return time.Now().After(*k.ExpiresAt)
+}
+
+// IsRevoked checks if the key is revoked
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// IsValid checks if the key can be used
+func (k *APIKey) IsValid() bool {
+	return !k.IsExpired() && !k.IsRevoked()
+}
+
+// HasQuota checks if the key carries its own rate limit quota
+func (k *APIKey) HasQuota() bool {
+	return k.RateLimitMaxRequests != nil
+}
+
+// HashAPIKey returns the key_hash stored for a raw API key (hex SHA-256).
+// Only the hash is stored, so the keys cannot be read back from the database.
+func HashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
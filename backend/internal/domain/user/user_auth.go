@@ -66,7 +66,12 @@ type PasswordResetToken struct {
 
 // IsExpired checks if token is expired
 func (t *PasswordResetToken) IsExpired() bool {
-	return time.Now().After(t.ExpiresAt)
+	return t.IsExpiredAt(time.Now())
+}
+
+// IsExpiredAt checks if token is expired at now
+func (t *PasswordResetToken) IsExpiredAt(now time.Time) bool {
+	return now.After(t.ExpiresAt)
 }
 
 // IsUsed checks if token has been used
@@ -76,7 +81,12 @@ func (t *PasswordResetToken) IsUsed() bool {
 
 // IsValid checks if token is valid
 func (t *PasswordResetToken) IsValid() bool {
-	return !t.IsExpired() && !t.IsUsed()
+	return t.IsValidAt(time.Now())
+}
+
+// IsValidAt checks if token is valid at now
+func (t *PasswordResetToken) IsValidAt(now time.Time) bool {
+	return !t.IsExpiredAt(now) && !t.IsUsed()
 }
 
 // MarkAsUsed marks token as used
@@ -108,7 +118,12 @@ type EmailVerificationToken struct {
 
 // IsExpired checks if token is expired
 func (t *EmailVerificationToken) IsExpired() bool {
-	return time.Now().After(t.ExpiresAt)
+	return t.IsExpiredAt(time.Now())
+}
+
+// IsExpiredAt checks if token is expired at now
+func (t *EmailVerificationToken) IsExpiredAt(now time.Time) bool {
+	return now.After(t.ExpiresAt)
 }
 
 // IsUsed checks if token has been used
@@ -118,7 +133,12 @@ func (t *EmailVerificationToken) IsUsed() bool {
 
 // IsValid checks if token is valid
 func (t *EmailVerificationToken) IsValid() bool {
-	return !t.IsExpired() && !t.IsUsed()
+	return t.IsValidAt(time.Now())
+}
+
+// IsValidAt checks if token is valid at now
+func (t *EmailVerificationToken) IsValidAt(now time.Time) bool {
+	return !t.IsExpiredAt(now) && !t.IsUsed()
 }
 
 // MarkAsUsed marks token as used
@@ -25,6 +25,9 @@ type UserUpdateRequest struct {
 	Allergies             *string `json:"allergies,omitempty"`
 	EmergencyContactName  *string `json:"emergency_contact_name,omitempty"`
 	EmergencyContactPhone *string `json:"emergency_contact_phone,omitempty"`
+
+	// Version the client read, for clients that cannot send If-Match
+	Version *int `json:"version,omitempty"`
 }
 
 // UserResponse represents user response (without sensitive data)
@@ -44,6 +47,7 @@ type UserResponse struct {
 	PhoneVerified         bool          `json:"phone_verified"`
 	TwoFactorEnabled      bool          `json:"two_factor_enabled"`
 	CreatedAt             time.Time     `json:"created_at"`
+	Version               int           `json:"version"`
 	LastLoginAt           *time.Time    `json:"last_login_at,omitempty"`
 }
 
@@ -64,6 +68,7 @@ func (u *User) ToResponse(securityInfo *UserSecurityInfo, credential *UserCreden
 		EmailVerified:         u.EmailVerified,
 		PhoneVerified:         u.PhoneVerified,
 		CreatedAt:             u.CreatedAt,
+		Version:               u.Version,
 	}
 
 	if securityInfo != nil {
@@ -77,13 +82,14 @@ func (u *User) ToResponse(securityInfo *UserSecurityInfo, credential *UserCreden
 	return resp
 }
 
-// UserListResponse represents paginated user list
+// UserListResponse represents a page of the user list.
+// NextCursor is empty on the last page.
 type UserListResponse struct {
 	Users      []*UserResponse `json:"users"`
 	Total      int64           `json:"total"`
-	Page       int             `json:"page"`
 	PageSize   int             `json:"page_size"`
-	TotalPages int             `json:"total_pages"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	HasMore    bool            `json:"has_more"`
 }
 
 // ============================================================================
@@ -246,15 +252,50 @@ type Disable2FARequest struct {
 
 // UserFilter represents user query filters
 type UserFilter struct {
-	Email         *string        `json:"email,omitempty"`
-	Role          *UserRole      `json:"role,omitempty"`
-	AccountStatus *AccountStatus `json:"account_status,omitempty"`
-	EmailVerified *bool          `json:"email_verified,omitempty"`
-	Search        *string        `json:"search,omitempty"`
-	Page          int            `json:"page"`
-	PageSize      int            `json:"page_size"`
-	SortBy        string         `json:"sort_by,omitempty"`
-	SortOrder     string         `json:"sort_order,omitempty"`
+	Email          *string        `json:"email,omitempty"`
+	Role           *UserRole      `json:"role,omitempty"`
+	AccountStatus  *AccountStatus `json:"account_status,omitempty"`
+	EmailVerified  *bool          `json:"email_verified,omitempty"`
+	Search         *string        `json:"search,omitempty"`
+	CreatedAfter   *time.Time     `json:"created_after,omitempty"`
+	CreatedBefore  *time.Time     `json:"created_before,omitempty"`
+	Tags           []string       `json:"tags,omitempty"` // Users with any of these tags
+	IncludeDeleted bool           `json:"include_deleted,omitempty"`
+
+	// Keyset pagination
+	Cursor    string `json:"cursor,omitempty"`
+	PageSize  int    `json:"page_size"`
+	SortBy    string `json:"sort_by,omitempty"`
+	SortOrder string `json:"sort_order,omitempty"`
+}
+
+// UserSearchQuery represents an admin user search. Zero Limit and
+// MinSimilarity use the repository defaults.
+type UserSearchQuery struct {
+	Query         string
+	Limit         int
+	MinSimilarity float64        // Word similarity a fuzzy match needs (0..1]
+	AccountStatus *AccountStatus // nil = any status
+}
+
+// Search match types
+const (
+	SearchMatchFulltext = "fulltext" // Every word prefixes a word of the name or email
+	SearchMatchFuzzy    = "fuzzy"    // Trigram similarity (typos)
+)
+
+// UserSearchResult represents one ranked search match. The highlights are
+// HTML-escaped with the matched words wrapped in <mark>.
+type UserSearchResult struct {
+	ID             int           `json:"id"`
+	Email          string        `json:"email"`
+	Name           string        `json:"name"`
+	Role           UserRole      `json:"role"`
+	AccountStatus  AccountStatus `json:"account_status"`
+	MatchType      string        `json:"match_type"`
+	Score          float64       `json:"score"` // 0..1, full-text matches rank first
+	NameHighlight  string        `json:"name_highlight"`
+	EmailHighlight string        `json:"email_highlight"`
 }
 
 // ============================================================================
@@ -0,0 +1,104 @@
+package user
+
+import (
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// DOMAIN EVENTS
+// ============================================================================
+// Events are written to the outbox in the transaction of the change they
+// describe; subscribers send the emails, pushes and audit rows. Payloads
+// are stored as JSON, so renaming a field breaks events still queued.
+
+// AggregateUser is the aggregate type of user events
+const AggregateUser = "user"
+
+// Event types
+const (
+	EventUserRegistered         = "user.registered"
+	EventUserLoggedIn           = "user.logged_in"
+	EventAccountLocked          = "user.account_locked"
+	EventAccountStatusChanged   = "user.account_status_changed"
+	EventPasswordResetRequested = "user.password_reset_requested"
+	EventUserDeleted            = "user.deleted"
+	EventUserRestored           = "user.restored"
+)
+
+// UserRegisteredEvent is emitted when an account is created
+type UserRegisteredEvent struct {
+	UserID    int    `json:"user_id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	IPAddress string `json:"ip_address"`
+}
+
+// UserLoggedInEvent is emitted for every successful login
+type UserLoggedInEvent struct {
+	UserID     int     `json:"user_id"`
+	Email      string  `json:"email"`
+	SessionID  int     `json:"session_id"`
+	IPAddress  string  `json:"ip_address"`
+	DeviceName *string `json:"device_name,omitempty"`
+	Platform   *string `json:"platform,omitempty"`
+}
+
+// AccountLockedEvent is emitted when failed logins lock an account
+type AccountLockedEvent struct {
+	UserID         int       `json:"user_id"`
+	Email          string    `json:"email"`
+	IPAddress      string    `json:"ip_address"` // Of the attempt that locked it
+	FailedAttempts int       `json:"failed_attempts"`
+	LockedUntil    time.Time `json:"locked_until"`
+}
+
+// AccountStatusChangedEvent is emitted when an admin changes an account status
+type AccountStatusChangedEvent struct {
+	UserID    int           `json:"user_id"`
+	Email     string        `json:"email"`
+	From      AccountStatus `json:"from"`
+	To        AccountStatus `json:"to"`
+	Reason    string        `json:"reason,omitempty"`
+	ChangedBy int           `json:"changed_by"`
+	IPAddress string        `json:"ip_address"`
+}
+
+// PasswordResetRequestedEvent is emitted when a reset token is issued.
+// It carries the ID of the password_reset_tokens row, never the token:
+// outbox payloads are kept after delivery.
+type PasswordResetRequestedEvent struct {
+	UserID    int       `json:"user_id"`
+	Email     string    `json:"email"`
+	TokenID   int       `json:"token_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	IPAddress string    `json:"ip_address"`
+}
+
+// UserDeletedEvent is emitted when an account is soft deleted
+type UserDeletedEvent struct {
+	UserID    int           `json:"user_id"`
+	Email     string        `json:"email"`
+	From      AccountStatus `json:"from"` // Status before the account was closed
+	Reason    string        `json:"reason,omitempty"`
+	DeletedBy int           `json:"deleted_by"`
+	IPAddress string        `json:"ip_address"`
+}
+
+// UserRestoredEvent is emitted when a soft-deleted account is restored
+type UserRestoredEvent struct {
+	UserID     int    `json:"user_id"`
+	Email      string `json:"email"`
+	RestoredBy int    `json:"restored_by"`
+	IPAddress  string `json:"ip_address"`
+}
+
+// EventKey builds the idempotency key of an event from the parts that
+// identify its occurrence, e.g. EventKey(EventUserLoggedIn, sessionID)
+func EventKey(eventType string, parts ...int64) string {
+	key := eventType
+	for _, part := range parts {
+		key += ":" + strconv.FormatInt(part, 10)
+	}
+	return key
+}
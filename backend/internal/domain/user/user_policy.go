@@ -8,12 +8,14 @@ import "errors"
 
 var (
 	// Account status errors
-	ErrUserNotActive = errors.New("user account is not active")
-	ErrUserPending   = errors.New("user account is pending activation")
-	ErrUserSuspended = errors.New("user account is suspended")
-	ErrUserBanned    = errors.New("user account is banned")
-	ErrUserClosed    = errors.New("user account is closed")
-	ErrUserDeleted   = errors.New("user account has been deleted")
+	ErrUserNotActive  = errors.New("user account is not active")
+	ErrUserPending    = errors.New("user account is pending activation")
+	ErrUserSuspended  = errors.New("user account is suspended")
+	ErrUserBanned     = errors.New("user account is banned")
+	ErrUserClosed     = errors.New("user account is closed")
+	ErrUserDeleted    = errors.New("user account has been deleted")
+	ErrUserNotDeleted = errors.New("user account is not deleted")
+	ErrUserPurged     = errors.New("user account has been purged")
 
 	// Permission errors
 	ErrPermissionDenied       = errors.New("permission denied")
@@ -31,6 +33,18 @@ var (
 	ErrAccountLocked           = errors.New("account is locked")
 	ErrUserInactive            = errors.New("user account is inactive")
 	ErrEmailNotVerified        = errors.New("email is not verified")
+
+	// Lookup errors (returned by repositories)
+	ErrUserNotFound         = errors.New("user not found")
+	ErrEmailAlreadyExists   = errors.New("email already exists")
+	ErrCredentialNotFound   = errors.New("user credential not found")
+	ErrSecurityInfoNotFound = errors.New("user security info not found")
+	ErrSessionNotFound      = errors.New("user session not found")
+	ErrAPIKeyNotFound       = errors.New("API key not found")
+	ErrResetTokenNotFound   = errors.New("password reset token not found")
+
+	// Update errors
+	ErrNoFieldsToUpdate = errors.New("no fields to update")
 )
 
 // ============================================================================
@@ -75,7 +89,7 @@ func CanPerformAction(user *User) error {
 
 	// Additional check: email verification required for actions
 	if !user.EmailVerified {
-		return errors.New("email verification required")
+		return ErrEmailNotVerified
 	}
 
 	return nil
@@ -344,6 +358,32 @@ func CanDeleteUser(actor *User, target *User) error {
 	return ErrPermissionDenied
 }
 
+// CanRestoreUser checks if actor can restore a soft-deleted target
+// Business rule:
+// - Admin only
+// - Purged accounts cannot be restored, their personal data is gone
+func CanRestoreUser(actor *User, target *User) error {
+	if actor == nil || target == nil {
+		return errors.New("actor or target is nil")
+	}
+
+	if err := CanPerformAction(actor); err != nil {
+		return err
+	}
+
+	if !target.IsDeleted() {
+		return ErrUserNotDeleted
+	}
+	if target.IsPurged() {
+		return ErrUserPurged
+	}
+
+	if !actor.IsAdmin() {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
 // ============================================================================
 // VIEW POLICY
 // ============================================================================
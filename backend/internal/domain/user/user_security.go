@@ -1,6 +1,10 @@
 package user
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
 
 // ============================================================================
 // USER SESSION
@@ -10,6 +14,7 @@ import "time"
 type UserSession struct {
 	ID           int    `json:"id" db:"id"`
 	UserID       int    `json:"user_id" db:"user_id"`
+	SessionToken string `json:"-" db:"-"`             // Raw bearer token, only set by Create
 	RefreshToken string `json:"-" db:"refresh_token"` // Hashed
 
 	// Device info
@@ -34,7 +39,12 @@ type UserSession struct {
 
 // IsExpired checks if session is expired
 func (s *UserSession) IsExpired() bool {
-	return time.Now().After(s.ExpiresAt)
+	return s.IsExpiredAt(time.Now())
+}
+
+// IsExpiredAt checks if session is expired at now
+func (s *UserSession) IsExpiredAt(now time.Time) bool {
+	return now.After(s.ExpiresAt)
 }
 
 // IsRevoked checks if session is revoked
@@ -44,7 +54,19 @@ func (s *UserSession) IsRevoked() bool {
 
 // IsValid checks if session is valid
 func (s *UserSession) IsValid() bool {
-	return !s.IsExpired() && !s.IsRevoked()
+	return s.IsValidAt(time.Now())
+}
+
+// IsValidAt checks if session is valid at now
+func (s *UserSession) IsValidAt(now time.Time) bool {
+	return !s.IsExpiredAt(now) && !s.IsRevoked()
+}
+
+// HashSessionToken returns the session_token stored for a raw bearer token
+// (hex SHA-256), so sessions cannot be hijacked from a database dump
+func HashSessionToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
 }
 
 // UpdateLastUsed updates the last used timestamp
@@ -115,16 +137,25 @@ type UserSecurityInfo struct {
 
 // IsLocked checks if user account is locked
 func (s *UserSecurityInfo) IsLocked() bool {
+	return s.IsLockedAt(time.Now())
+}
+
+// IsLockedAt checks if user account is locked at now
+func (s *UserSecurityInfo) IsLockedAt(now time.Time) bool {
 	if s.LockedUntil == nil {
 		return false
 	}
-	return time.Now().Before(*s.LockedUntil)
+	return now.Before(*s.LockedUntil)
 }
 
 // IncrementFailedAttempts increments failed login attempts
 func (s *UserSecurityInfo) IncrementFailedAttempts() {
+	s.IncrementFailedAttemptsAt(time.Now())
+}
+
+// IncrementFailedAttemptsAt increments failed login attempts made at now
+func (s *UserSecurityInfo) IncrementFailedAttemptsAt(now time.Time) {
 	s.FailedLoginAttempts++
-	now := time.Now()
 	s.LastFailedLoginAt = &now
 
 	// Lock account after 5 failed attempts for 30 minutes
@@ -143,7 +174,11 @@ func (s *UserSecurityInfo) ResetFailedAttempts() {
 
 // UpdateLastLogin updates last login timestamp
 func (s *UserSecurityInfo) UpdateLastLogin() {
-	now := time.Now()
+	s.UpdateLastLoginAt(time.Now())
+}
+
+// UpdateLastLoginAt sets the last login timestamp to now
+func (s *UserSecurityInfo) UpdateLastLoginAt(now time.Time) {
 	s.LastLoginAt = &now
 }
 
@@ -0,0 +1,82 @@
+package user
+
+import (
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestUserSessionExpiry(t *testing.T) {
+	session := &UserSession{ExpiresAt: now.Add(time.Hour)}
+
+	tests := []struct {
+		name    string
+		at      time.Time
+		expired bool
+	}{
+		{"before expiry", now, false},
+		{"at expiry", now.Add(time.Hour), false},
+		{"after expiry", now.Add(time.Hour + time.Nanosecond), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := session.IsExpiredAt(tt.at); got != tt.expired {
+				t.Errorf("IsExpiredAt = %t, want %t", got, tt.expired)
+			}
+			if got := session.IsValidAt(tt.at); got == tt.expired {
+				t.Errorf("IsValidAt = %t, want %t", got, !tt.expired)
+			}
+		})
+	}
+
+	session.Revoke(nil)
+	if session.IsValidAt(now) {
+		t.Error("revoked session is still valid")
+	}
+}
+
+func TestPasswordResetTokenExpiry(t *testing.T) {
+	token := &PasswordResetToken{ExpiresAt: now.Add(15 * time.Minute)}
+
+	if !token.IsValidAt(now.Add(15 * time.Minute)) {
+		t.Error("token invalid at its expiry time")
+	}
+	if !token.IsExpiredAt(now.Add(15*time.Minute + time.Second)) {
+		t.Error("token not expired after its expiry time")
+	}
+
+	token.MarkAsUsed(nil)
+	if token.IsValidAt(now) {
+		t.Error("used token is still valid")
+	}
+}
+
+func TestUserSecurityInfoLockout(t *testing.T) {
+	info := &UserSecurityInfo{}
+
+	for i := 1; i < 5; i++ {
+		info.IncrementFailedAttemptsAt(now)
+		if info.IsLockedAt(now) {
+			t.Fatalf("locked after %d failed attempts", i)
+		}
+	}
+
+	info.IncrementFailedAttemptsAt(now)
+	wantUntil := now.Add(30 * time.Minute)
+	if info.LockedUntil == nil || !info.LockedUntil.Equal(wantUntil) {
+		t.Fatalf("LockedUntil = %v, want %v", info.LockedUntil, wantUntil)
+	}
+	if !info.IsLockedAt(wantUntil.Add(-time.Second)) {
+		t.Error("unlocked before LockedUntil")
+	}
+	if info.IsLockedAt(wantUntil) {
+		t.Error("still locked at LockedUntil")
+	}
+
+	info.ResetFailedAttempts()
+	if info.IsLockedAt(now) || info.FailedLoginAttempts != 0 {
+		t.Errorf("reset left FailedLoginAttempts = %d, locked = %t", info.FailedLoginAttempts, info.IsLockedAt(now))
+	}
+}
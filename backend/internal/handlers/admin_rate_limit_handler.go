@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -36,6 +39,7 @@ func (h *LimiterHandler) ResetRateLimit(c *fiber.Ctx) error {
 			"error": "Failed to reset rate limit",
 		})
 	}
+	h.audit(c, ratelimit.AuditReset, identifier, action, nil)
 
 	return c.JSON(fiber.Map{
 		"message": "Rate limit reset successfully",
@@ -70,6 +74,13 @@ func (h *LimiterHandler) BlockUser(c *fiber.Ctx) error {
 		})
 	}
 
+	// Record the expiry Block applied, including for indefinite blocks
+	if duration == 0 {
+		duration = ratelimit.IndefiniteBlockDuration
+	}
+	blockedUntil := h.Limiter.Now().Add(duration)
+	h.audit(c, ratelimit.AuditManualBlock, identifier, action, &blockedUntil)
+
 	return c.JSON(fiber.Map{
 		"message": "User blocked successfully",
 	})
@@ -85,6 +96,7 @@ func (h *LimiterHandler) UnblockUser(c *fiber.Ctx) error {
 			"error": "Failed to unblock user",
 		})
 	}
+	h.audit(c, ratelimit.AuditUnblock, identifier, action, nil)
 
 	return c.JSON(fiber.Map{
 		"message": "User unblocked successfully",
@@ -109,3 +121,383 @@ func (h *LimiterHandler) ListRules(c *fiber.Ctx) error {
 		"rules": rules,
 	})
 }
+
+// ============================================================================
+// INSPECTION (blocked, top attackers, search)
+// ============================================================================
+
+func (h *LimiterHandler) ListBlocked(c *fiber.Ctx) error {
+	blocked, err := h.Limiter.BlockedIdentifiers(c.Context(), c.QueryInt("limit", 100))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to list blocked identifiers",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"blocked": blocked,
+	})
+}
+
+func (h *LimiterHandler) TopIdentifiers(c *fiber.Ctx) error {
+	action := c.Params("action")
+	if _, exists := h.Limiter.GetRule(action); !exists {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Unknown action",
+		})
+	}
+
+	top, err := h.Limiter.TopIdentifiers(c.Context(), action, c.QueryInt("limit", 10))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to get top identifiers",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"action": action,
+		"top":    top,
+	})
+}
+
+func (h *LimiterHandler) SearchIdentifiers(c *fiber.Ctx) error {
+	prefix := c.Query("prefix")
+	if prefix == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "prefix is required",
+		})
+	}
+
+	identifiers, err := h.Limiter.SearchIdentifiers(c.Context(), prefix, c.QueryInt("limit", 50))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to search identifiers",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"identifiers": identifiers,
+	})
+}
+
+// ============================================================================
+// AUDIT TRAIL (blocks and admin interventions)
+// ============================================================================
+
+func (h *LimiterHandler) ListAudit(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 50)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	since, err := parseTimeQuery(c, "since")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid since format, expected RFC 3339",
+		})
+	}
+
+	until, err := parseTimeQuery(c, "until")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid until format, expected RFC 3339",
+		})
+	}
+
+	filter := ratelimit.AuditFilter{
+		Action:     c.Query("action"),
+		Identifier: c.Query("identifier"),
+		Since:      since,
+		Until:      until,
+		Page:       page,
+		Limit:      limit,
+	}
+
+	events, total, err := h.Audit.ListAudit(c.Context(), filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to list audit events",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"events": events,
+		"pagination": fiber.Map{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + limit - 1) / limit,
+		},
+	})
+}
+
+// parseTimeQuery parses an optional RFC 3339 query parameter (e.g. 2025-01-01T00:00:00Z)
+func parseTimeQuery(c *fiber.Ctx, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// audit records an admin intervention with the acting admin (best effort)
+func (h *LimiterHandler) audit(c *fiber.Ctx, typ ratelimit.AuditEventType, identifier, action string, blockedUntil *time.Time) {
+	if h.Audit == nil {
+		return
+	}
+
+	event := &ratelimit.AuditEvent{
+		EventType:    typ,
+		Identifier:   identifier,
+		Action:       action,
+		BlockedUntil: blockedUntil,
+	}
+	if admin := middleware.GetUserFromContext(c); admin != nil {
+		event.ActorID = &admin.ID
+	}
+
+	h.Audit.RecordAudit(c.Context(), event)
+}
+
+// ============================================================================
+// REPEAT OFFENDERS (escalation & denylist)
+// ============================================================================
+
+func (h *LimiterHandler) GetOffender(c *fiber.Ctx) error {
+	offender, err := h.Limiter.GetOffender(c.Context(), c.Params("identifier"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to get offender status",
+		})
+	}
+
+	return c.JSON(offender)
+}
+
+func (h *LimiterHandler) PardonOffender(c *fiber.Ctx) error {
+	if err := h.Limiter.Pardon(c.Context(), c.Params("identifier")); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to pardon offender",
+		})
+	}
+	// Pardons span every action, like the denylist
+	h.audit(c, ratelimit.AuditPardon, c.Params("identifier"), ratelimit.DenylistAction, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Offense history and denylist entry cleared",
+	})
+}
+
+// ============================================================================
+// IP ALLOW / DENY LISTS (CIDR)
+// ============================================================================
+
+func (h *LimiterHandler) ListIPRules(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"ip_rules": h.IPList.Rules(),
+	})
+}
+
+func (h *LimiterHandler) SaveIPRule(c *fiber.Ctx) error {
+	var req struct {
+		CIDR      string     `json:"cidr"`      // e.g., "203.0.113.0/24" or "2001:db8::/48"
+		ListType  string     `json:"list_type"` // "allow" or "deny"
+		Reason    *string    `json:"reason"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request",
+		})
+	}
+
+	rule := &ratelimit.IPRule{
+		CIDR:      req.CIDR,
+		ListType:  ratelimit.IPListType(req.ListType),
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if admin := middleware.GetUserFromContext(c); admin != nil {
+		rule.CreatedBy = &admin.ID
+	}
+
+	if err := h.IPList.Save(c.Context(), rule); err != nil {
+		if errors.Is(err, ratelimit.ErrInvalidIPRule) {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Invalid IP rule. cidr must be an IP or CIDR and list_type allow or deny",
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to save IP rule",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "IP rule saved successfully",
+		"ip_rule": rule,
+	})
+}
+
+func (h *LimiterHandler) DeleteIPRule(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid IP rule ID",
+		})
+	}
+
+	if err := h.IPList.Delete(c.Context(), id); err != nil {
+		if errors.Is(err, ratelimit.ErrIPRuleNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "IP rule not found",
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to delete IP rule",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "IP rule deleted successfully",
+	})
+}
+
+// ============================================================================
+// QUOTA OVERRIDES (per user / per API key)
+// ============================================================================
+
+func (h *LimiterHandler) ListOverrides(c *fiber.Ctx) error {
+	overrides, err := h.Overrides.ListOverrides(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to list overrides",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"overrides": overrides,
+	})
+}
+
+func (h *LimiterHandler) SaveOverride(c *fiber.Ctx) error {
+	typ, ok := parseOverrideType(c.Params("type"))
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid identifier type. Must be: user_id, api_key, ip, or email",
+		})
+	}
+
+	var req struct {
+		MaxAttempts int     `json:"max_attempts"`
+		Window      string  `json:"window"`    // e.g., "1m"
+		BlockFor    string  `json:"block_for"` // e.g., "5m"
+		Reason      *string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request",
+		})
+	}
+
+	window, err := time.ParseDuration(req.Window)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid window format",
+		})
+	}
+
+	blockFor, err := time.ParseDuration(req.BlockFor)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid block_for format",
+		})
+	}
+
+	override := &ratelimit.QuotaOverride{
+		IdentifierType:       typ,
+		Identifier:           c.Params("identifier"),
+		Action:               c.Params("action"),
+		MaxAttempts:          req.MaxAttempts,
+		WindowSizeSeconds:    int(window.Seconds()),
+		BlockDurationSeconds: int(blockFor.Seconds()),
+		Reason:               req.Reason,
+	}
+	if admin := middleware.GetUserFromContext(c); admin != nil {
+		override.CreatedBy = &admin.ID
+	}
+
+	if err := h.Overrides.SaveOverride(c.Context(), override); err != nil {
+		switch {
+		case errors.Is(err, ratelimit.ErrInvalidOverride),
+			errors.Is(err, ratelimit.ErrUnsupportedKeyOverride):
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case errors.Is(err, ratelimit.ErrOverrideNotFound):
+			return c.Status(404).JSON(fiber.Map{
+				"error": "API key not found",
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to save override",
+		})
+	}
+
+	// Apply locally right away, other instances pick it up on next sync
+	h.Limiter.SetOverride(override)
+
+	return c.JSON(fiber.Map{
+		"message":  "Override saved successfully",
+		"override": override,
+	})
+}
+
+func (h *LimiterHandler) DeleteOverride(c *fiber.Ctx) error {
+	typ, ok := parseOverrideType(c.Params("type"))
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid identifier type. Must be: user_id, api_key, ip, or email",
+		})
+	}
+
+	identifier := c.Params("identifier")
+	action := c.Params("action")
+
+	if err := h.Overrides.DeleteOverride(c.Context(), typ, identifier, action); err != nil {
+		if errors.Is(err, ratelimit.ErrOverrideNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Override not found",
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to delete override",
+		})
+	}
+
+	h.Limiter.RemoveOverride(ratelimit.FormatIdentifier(typ, identifier), action)
+
+	return c.JSON(fiber.Map{
+		"message": "Override deleted successfully",
+	})
+}
+
+// parseOverrideType validates the identifier type of an override route
+func parseOverrideType(raw string) (ratelimit.IdentifierType, bool) {
+	typ := ratelimit.IdentifierType(raw)
+	switch typ {
+	case ratelimit.IdentifierUserID, ratelimit.IdentifierAPIKey,
+		ratelimit.IdentifierIP, ratelimit.IdentifierEmail:
+		return typ, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============================================================================
+// ETAGS (Optimistic concurrency)
+// ============================================================================
+// Versioned resources (see postgres.UpdateVersioned) send their row version
+// as a strong ETag ("3"). Clients echo it in If-Match on writes; a stale
+// version is answered with 409 and the current representation.
+
+// errInvalidIfMatch is returned for an If-Match header this API did not issue
+var errInvalidIfMatch = errors.New(`If-Match must be a single ETag returned by this API, or "*"`)
+
+// versionETag formats a row version as a strong ETag
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// setVersionETag sets the ETag header of a versioned resource
+func setVersionETag(c *fiber.Ctx, version int) {
+	c.Set(fiber.HeaderETag, versionETag(version))
+}
+
+// ifMatchVersion returns the version required by the If-Match header,
+// or 0 when the header is absent or "*" (any version)
+func ifMatchVersion(c *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	// Weak ETags never match If-Match (RFC 9110, section 13.1.1)
+	unquoted, ok := strings.CutPrefix(header, `"`)
+	if !ok {
+		return 0, errInvalidIfMatch
+	}
+	unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	if !ok {
+		return 0, errInvalidIfMatch
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// expectedVersion returns the version a write must match: the If-Match
+// header, else the version in the body (0 = unconditional)
+func expectedVersion(c *fiber.Ctx, bodyVersion *int) (int, error) {
+	version, err := ifMatchVersion(c)
+	if err != nil || version > 0 {
+		return version, err
+	}
+	if bodyVersion != nil {
+		if *bodyVersion < 1 {
+			return 0, errors.New("version must be a positive integer")
+		}
+		return *bodyVersion, nil
+	}
+	return 0, nil
+}
@@ -22,17 +22,27 @@ func (h *LimiterHandler) Check(c *fiber.Ctx) error {
 		redisHealth = "error: " + err.Error()
 	}
 
+	// Check partitions (nil when maintenance is disabled)
+	var partitionHealth any = fiber.Map{"status": "disabled"}
+	if report, err := db.PartitionHealthCheck(ctx); err != nil {
+		partitionHealth = fiber.Map{"status": "error: " + err.Error()}
+	} else if report != nil {
+		partitionHealth = report
+	}
+
 	// Get rate limiter stats
 	stats, _ := h.Limiter.GetStats(ctx)
 
 	return c.JSON(fiber.Map{
 		"status": "ok",
 		"database": fiber.Map{
-			"status": dbHealth,
+			"status":   dbHealth,
+			"replicas": db.ReplicaHealthCheck(),
 		},
 		"redis": fiber.Map{
 			"status": redisHealth,
 		},
+		"partitions":   partitionHealth,
 		"rate_limiter": stats,
 	})
 }
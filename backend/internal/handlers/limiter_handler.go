@@ -3,9 +3,12 @@ package handlers
 import "github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
 
 type LimiterHandler struct {
-	Limiter *ratelimit.RedisLimiter
+	Limiter   *ratelimit.RedisLimiter
+	Overrides ratelimit.OverrideStore
+	IPList    *ratelimit.IPList
+	Audit     *ratelimit.AuditLog
 }
 
-func NewHandler(limiter *ratelimit.RedisLimiter) *LimiterHandler {
-	return &LimiterHandler{Limiter: limiter}
+func NewHandler(limiter *ratelimit.RedisLimiter, overrides ratelimit.OverrideStore, ipList *ratelimit.IPList, audit *ratelimit.AuditLog) *LimiterHandler {
+	return &LimiterHandler{Limiter: limiter, Overrides: overrides, IPList: ipList, Audit: audit}
 }
@@ -1,13 +1,21 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"log"
 	"strconv"
 	"strings"
 
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/application/usecase"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/outbox"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/persistence/postgres"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/persistence/query"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/db"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/middleware"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/redis"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -15,127 +23,162 @@ import (
 // USER HANDLERS
 // ============================================================================
 
-// HandleListUsers returns list of users (Admin/Leader only)
+// HandleListUsers returns a page of users (Admin/Leader only)
+//
+// Filters: role, status, verified, created_after, created_before (RFC 3339),
+// tags (comma-separated, any of), search (name/email), include_deleted.
+// Pagination: sort (created_at, updated_at, name, email), order (asc/desc),
+// limit and the next_cursor of the previous page as cursor.
 func HandleListUsers(c *fiber.Ctx) error {
 	currentUser := middleware.GetUserFromContext(c)
+	ctx := c.UserContext()
 
-	// Parse pagination
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	if page < 1 {
-		page = 1
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
-	if limit < 1 || limit > 100 {
-		limit = 20
+
+	// Only admin can see deleted users
+	if filter.IncludeDeleted && !currentUser.IsAdmin() {
+		filter.IncludeDeleted = false
 	}
 
-	// Parse filters
-	role := c.Query("role")     // Filter by role
-	status := c.Query("status") // Filter by status
-	search := c.Query("search") // Search by name/email
-	includeDeleted := c.Query("include_deleted") == "true"
+	users, next, total, err := postgres.NewUserRepository(db.Reader(ctx)).List(ctx, filter)
+	if errors.Is(err, query.ErrInvalidSort) || errors.Is(err, query.ErrInvalidCursor) {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch users",
+		})
+	}
 
-	// Only admin can see deleted users
-	if includeDeleted && !currentUser.IsAdmin() {
-		includeDeleted = false
+	resp := &user.UserListResponse{
+		Users:      make([]*user.UserResponse, 0, len(users)),
+		Total:      total,
+		PageSize:   len(users),
+		NextCursor: next,
+		HasMore:    next != "",
+	}
+	for _, u := range users {
+		resp.Users = append(resp.Users, u.ToResponse(nil, nil))
 	}
 
-	// Build query
-	query := `
-		SELECT 
-			id, email, name, role, account_status,
-			phone, avatar_url, email_verified, phone_verified,
-			created_at, updated_at, deleted_at
-		FROM users
-		WHERE 1=1
-	`
-	args := []interface{}{}
-	argCount := 1
+	return c.JSON(resp)
+}
 
-	// Filter by role
-	if role != "" {
-		query += ` AND role = $` + strconv.Itoa(argCount)
-		args = append(args, role)
-		argCount++
+// parseUserFilter reads the list filters from the query string
+func parseUserFilter(c *fiber.Ctx) (user.UserFilter, error) {
+	filter := user.UserFilter{
+		Cursor:         c.Query("cursor"),
+		PageSize:       c.QueryInt("limit", 20),
+		SortBy:         c.Query("sort"),
+		SortOrder:      c.Query("order"),
+		Tags:           splitQueryList(c.Query("tags")),
+		IncludeDeleted: c.QueryBool("include_deleted", false),
 	}
 
-	// Filter by status
-	if status != "" {
-		query += ` AND account_status = $` + strconv.Itoa(argCount)
-		args = append(args, status)
-		argCount++
+	if role := user.UserRole(c.Query("role")); role != "" {
+		if role != user.UserRoleAdmin && role != user.UserRoleLeader && role != user.UserRoleUser {
+			return filter, errors.New("invalid role. Must be: admin, leader, or user")
+		}
+		filter.Role = &role
 	}
 
-	// Search by name or email
-	if search != "" {
-		query += ` AND (name ILIKE $` + strconv.Itoa(argCount) +
-			` OR email ILIKE $` + strconv.Itoa(argCount) + `)`
-		args = append(args, "%"+search+"%")
-		argCount++
+	if status := user.AccountStatus(c.Query("status")); status != "" {
+		switch status {
+		case user.AccountPending, user.AccountActive, user.AccountSuspended, user.AccountBanned, user.AccountClosed:
+			filter.AccountStatus = &status
+		default:
+			return filter, errors.New("invalid status")
+		}
 	}
 
-	// Include/exclude deleted
-	if !includeDeleted {
-		query += ` AND deleted_at IS NULL`
+	if verified := c.Query("verified"); verified != "" {
+		v, err := strconv.ParseBool(verified)
+		if err != nil {
+			return filter, errors.New("verified must be true or false")
+		}
+		filter.EmailVerified = &v
 	}
 
-	// Count total
-	countQuery := `SELECT COUNT(*) FROM (` + query + `) AS total`
-	var total int
-	err := db.DB.QueryRow(countQuery, args...).Scan(&total)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Failed to count users",
-		})
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		filter.Search = &search
 	}
 
-	// Add pagination
-	offset := (page - 1) * limit
-	query += ` ORDER BY created_at DESC LIMIT $` + strconv.Itoa(argCount) +
-		` OFFSET $` + strconv.Itoa(argCount+1)
-	args = append(args, limit, offset)
+	var err error
+	if filter.CreatedAfter, err = parseTimeQuery(c, "created_after"); err != nil {
+		return filter, errors.New("created_after must be an RFC 3339 time")
+	}
+	if filter.CreatedBefore, err = parseTimeQuery(c, "created_before"); err != nil {
+		return filter, errors.New("created_before must be an RFC 3339 time")
+	}
 
-	// Execute query
-	rows, err := db.DB.Query(query, args...)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Failed to fetch users",
-		})
+	return filter, nil
+}
+
+// splitQueryList splits a comma-separated query value, skipping empty items
+func splitQueryList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
-	defer rows.Close()
+	return items
+}
 
-	// Parse results
-	users := []user.User{}
-	for rows.Next() {
-		var u user.User
-		err := rows.Scan(
-			&u.ID, &u.Email, &u.Name, &u.Role, &u.AccountStatus,
-			&u.Phone, &u.AvatarURL, &u.EmailVerified, &u.PhoneVerified,
-			&u.CreatedAt, &u.UpdatedAt, &u.DeletedAt,
-		)
-		if err != nil {
-			continue
+// HandleSearchUsers searches users by name or email (Admin only)
+//
+// Matching ignores case and diacritics ("nguyen" finds "Nguyễn"); words
+// are prefixes, and misspelled queries fall back to trigram similarity.
+// Query: q (required), limit (max 100), status.
+func HandleSearchUsers(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	search := user.UserSearchQuery{
+		Query: strings.TrimSpace(c.Query("q")),
+		Limit: c.QueryInt("limit", 20),
+	}
+	if len([]rune(search.Query)) < 2 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "q must be at least 2 characters",
+		})
+	}
+	if status := user.AccountStatus(c.Query("status")); status != "" {
+		switch status {
+		case user.AccountPending, user.AccountActive, user.AccountSuspended, user.AccountBanned, user.AccountClosed:
+			search.AccountStatus = &status
+		default:
+			return c.Status(400).JSON(fiber.Map{
+				"error": "invalid status",
+			})
 		}
-		users = append(users, u)
 	}
 
-	// Calculate pagination
-	totalPages := (total + limit - 1) / limit
+	results, err := postgres.NewUserRepository(db.Reader(ctx)).Search(ctx, search)
+	if err != nil {
+		log.Printf("⚠️  User search failed: %v", err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to search users",
+		})
+	}
 
 	return c.JSON(fiber.Map{
-		"users": users,
-		"pagination": fiber.Map{
-			"page":        page,
-			"limit":       limit,
-			"total":       total,
-			"total_pages": totalPages,
-		},
+		"query":   search.Query,
+		"results": results,
+		"count":   len(results),
 	})
 }
 
 // HandleGetUser returns a single user by ID
 func HandleGetUser(c *fiber.Ctx) error {
 	currentUser := middleware.GetUserFromContext(c)
+	ctx := c.UserContext()
 	userID, err := c.ParamsInt("id")
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -154,7 +197,7 @@ func HandleGetUser(c *fiber.Ctx) error {
 	var u user.User
 	query := `
 		SELECT 
-			id, email, name, role, account_status, status_changed_at,
+			id, email, name, role, version, account_status, status_changed_at,
 			phone, avatar_url, blood_type, allergies,
 			emergency_contact_name, emergency_contact_phone,
 			email_verified, phone_verified,
@@ -168,8 +211,10 @@ func HandleGetUser(c *fiber.Ctx) error {
 		query += ` AND deleted_at IS NULL`
 	}
 
-	err = db.DB.QueryRow(query, userID).Scan(
-		&u.ID, &u.Email, &u.Name, &u.Role, &u.AccountStatus, &u.StatusChangedAt,
+	// The version becomes the ETag that If-Match is checked against, so read
+	// it from the primary; a lagging replica would hand out stale ETags
+	err = db.Writer(ctx).QueryRowContext(ctx, query, userID).Scan(
+		&u.ID, &u.Email, &u.Name, &u.Role, &u.Version, &u.AccountStatus, &u.StatusChangedAt,
 		&u.Phone, &u.AvatarURL, &u.BloodType, &u.Allergies,
 		&u.EmergencyContactName, &u.EmergencyContactPhone,
 		&u.EmailVerified, &u.PhoneVerified,
@@ -187,14 +232,20 @@ func HandleGetUser(c *fiber.Ctx) error {
 		})
 	}
 
+	setVersionETag(c, u.Version)
 	return c.JSON(fiber.Map{
 		"user": u,
 	})
 }
 
 // HandleUpdateUser updates user information
+//
+// Send the ETag of GET /users/:id as If-Match (or its version in the body):
+// when someone else updated the user in between, nothing is written and
+// 409 returns the current user to merge with.
 func HandleUpdateUser(c *fiber.Ctx) error {
 	currentUser := middleware.GetUserFromContext(c)
+	ctx := c.UserContext()
 	userID, err := c.ParamsInt("id")
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -209,18 +260,7 @@ func HandleUpdateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Parse request
-	type UpdateUserRequest struct {
-		Name                  *string `json:"name"`
-		Phone                 *string `json:"phone"`
-		AvatarURL             *string `json:"avatar_url"`
-		BloodType             *string `json:"blood_type"`
-		Allergies             *string `json:"allergies"`
-		EmergencyContactName  *string `json:"emergency_contact_name"`
-		EmergencyContactPhone *string `json:"emergency_contact_phone"`
-	}
-
-	var req UpdateUserRequest
+	var req user.UserUpdateRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": "Invalid request body",
@@ -234,88 +274,69 @@ func HandleUpdateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Build update query
-	updates := []string{}
-	args := []interface{}{}
-	argCount := 1
-
-	if req.Name != nil {
-		updates = append(updates, "name = $"+strconv.Itoa(argCount))
-		args = append(args, *req.Name)
-		argCount++
-	}
-	if req.Phone != nil {
-		updates = append(updates, "phone = $"+strconv.Itoa(argCount))
-		args = append(args, *req.Phone)
-		argCount++
-	}
-	if req.AvatarURL != nil {
-		updates = append(updates, "avatar_url = $"+strconv.Itoa(argCount))
-		args = append(args, *req.AvatarURL)
-		argCount++
-	}
-	if req.BloodType != nil {
-		updates = append(updates, "blood_type = $"+strconv.Itoa(argCount))
-		args = append(args, *req.BloodType)
-		argCount++
-	}
-	if req.Allergies != nil {
-		updates = append(updates, "allergies = $"+strconv.Itoa(argCount))
-		args = append(args, *req.Allergies)
-		argCount++
-	}
-	if req.EmergencyContactName != nil {
-		updates = append(updates, "emergency_contact_name = $"+strconv.Itoa(argCount))
-		args = append(args, *req.EmergencyContactName)
-		argCount++
-	}
-	if req.EmergencyContactPhone != nil {
-		updates = append(updates, "emergency_contact_phone = $"+strconv.Itoa(argCount))
-		args = append(args, *req.EmergencyContactPhone)
-		argCount++
+	version, err := expectedVersion(c, req.Version)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	if len(updates) == 0 {
+	repo := postgres.NewUserRepository(db.Writer(ctx))
+	newVersion, err := repo.Update(ctx, userID, version, req, currentUser.ID)
+	switch {
+	case errors.Is(err, user.ErrNoFieldsToUpdate):
 		return c.Status(400).JSON(fiber.Map{
 			"error": "No fields to update",
 		})
-	}
-
-	// Add updated_at and updated_by
-	updates = append(updates, "updated_at = NOW()")
-	updates = append(updates, "updated_by = $"+strconv.Itoa(argCount))
-	args = append(args, currentUser.ID)
-	argCount++
-
-	// Add WHERE clause
-	args = append(args, userID)
-
-	// Execute update
-	query := `UPDATE users SET ` + strings.Join(updates, ", ") +
-		` WHERE id = $` + strconv.Itoa(argCount) + ` AND deleted_at IS NULL`
-
-	result, err := db.DB.Exec(query, args...)
-	if err != nil {
+	case errors.Is(err, user.ErrUserNotFound):
+		return c.Status(404).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case errors.Is(err, postgres.ErrVersionConflict):
+		return respondUserConflict(c, repo, userID)
+	case err != nil:
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to update user",
 		})
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	invalidateUserCache(userID)
+
+	setVersionETag(c, newVersion)
+	return c.JSON(fiber.Map{
+		"message": "User updated successfully",
+		"version": newVersion,
+	})
+}
+
+// respondUserConflict answers a stale update with 409 and the current user
+func respondUserConflict(c *fiber.Ctx, repo *postgres.UserRepository, userID int) error {
+	ctx := c.UserContext()
+	current, err := repo.FindByID(ctx, userID, false)
+	if errors.Is(err, user.ErrUserNotFound) {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "User not found",
 		})
 	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch user",
+		})
+	}
 
-	return c.JSON(fiber.Map{
-		"message": "User updated successfully",
+	setVersionETag(c, current.Version)
+	return c.Status(409).JSON(fiber.Map{
+		"error": "User was modified by someone else; apply your changes to this version and retry",
+		"user":  current,
 	})
 }
 
-// HandleDeleteUser soft deletes a user
+// HandleDeleteUser soft deletes a user (Leader/Admin, see user.CanDeleteUser).
+// Sessions and push tokens of the user are revoked with it; the optional
+// reason query parameter is kept in the status history.
 func HandleDeleteUser(c *fiber.Ctx) error {
 	currentUser := middleware.GetUserFromContext(c)
+	ctx := c.UserContext()
 	userID, err := c.ParamsInt("id")
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -323,32 +344,19 @@ func HandleDeleteUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check permission
-	if !currentUser.CanDeleteUser(userID) {
-		return c.Status(403).JSON(fiber.Map{
-			"error": "Forbidden - you can only delete your own account or you need admin permissions",
-		})
-	}
-
-	// Soft delete
-	query := `
-		UPDATE users 
-		SET deleted_at = NOW(), deleted_by = $1
-		WHERE id = $2 AND deleted_at IS NULL
-	`
-
-	result, err := db.DB.Exec(query, currentUser.ID, userID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Failed to delete user",
-		})
+	var reason *string
+	if r := strings.TrimSpace(c.Query("reason")); r != "" {
+		reason = &r
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	err = newUserDeletion(ctx).Delete(ctx, currentUser, userID, reason, middleware.ClientIP(c).String())
+	switch {
+	case errors.Is(err, user.ErrUserNotFound), errors.Is(err, user.ErrUserDeleted):
 		return c.Status(404).JSON(fiber.Map{
 			"error": "User not found or already deleted",
 		})
+	case err != nil:
+		return respondDeletionError(c, err, "Failed to delete user")
 	}
 
 	return c.JSON(fiber.Map{
@@ -363,6 +371,7 @@ func HandleDeleteUser(c *fiber.Ctx) error {
 // HandleChangeUserRole changes user role (Admin only)
 func HandleChangeUserRole(c *fiber.Ctx) error {
 	currentUser := middleware.GetUserFromContext(c)
+	ctx := c.UserContext()
 	userID, err := c.ParamsInt("id")
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -412,7 +421,7 @@ func HandleChangeUserRole(c *fiber.Ctx) error {
 		WHERE id = $3 AND deleted_at IS NULL
 	`
 
-	result, err := db.DB.Exec(query, req.Role, currentUser.ID, userID)
+	result, err := db.Writer(ctx).ExecContext(ctx, query, req.Role, currentUser.ID, userID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to change role",
@@ -426,6 +435,8 @@ func HandleChangeUserRole(c *fiber.Ctx) error {
 		})
 	}
 
+	invalidateUserCache(userID)
+
 	return c.JSON(fiber.Map{
 		"message":  "Role changed successfully",
 		"new_role": req.Role,
@@ -435,6 +446,7 @@ func HandleChangeUserRole(c *fiber.Ctx) error {
 // HandleChangeAccountStatus changes account status (Admin only)
 func HandleChangeAccountStatus(c *fiber.Ctx) error {
 	currentUser := middleware.GetUserFromContext(c)
+	ctx := c.UserContext()
 	userID, err := c.ParamsInt("id")
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -489,49 +501,47 @@ func HandleChangeAccountStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get current status
-	var currentStatus user.AccountStatus
-	err = db.DB.QueryRow(`SELECT account_status FROM users WHERE id = $1`, userID).
-		Scan(&currentStatus)
-	if err != nil {
+	// The status change, its history row and the event commit together
+	ipAddress := middleware.ClientIP(c).String()
+	writer := db.Writer(ctx)
+	repo := postgres.NewUserRepository(writer)
+	events := postgres.NewOutboxRepository(writer)
+	err = postgres.NewTxManager(writer).WithinTx(ctx, func(ctx context.Context) error {
+		updated, previous, err := repo.ChangeStatus(ctx, userID, req.Status, req.Reason, currentUser.ID, ipAddress)
+		if err != nil {
+			return err
+		}
+
+		change := &user.AccountStatusChangedEvent{
+			UserID:    userID,
+			Email:     updated.Email,
+			From:      previous,
+			To:        req.Status,
+			ChangedBy: currentUser.ID,
+			IPAddress: ipAddress,
+		}
+		if req.Reason != nil {
+			change.Reason = *req.Reason
+		}
+		event, err := outbox.NewEvent(user.EventAccountStatusChanged, user.AggregateUser, strconv.Itoa(userID),
+			user.EventKey(user.EventAccountStatusChanged, int64(userID), int64(updated.Version)), change)
+		if err != nil {
+			return err
+		}
+		return events.Add(ctx, event)
+	})
+	if errors.Is(err, user.ErrUserNotFound) {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "User not found",
 		})
 	}
-
-	// Update status
-	query := `
-		UPDATE users 
-		SET account_status = $1, 
-			status_changed_at = NOW(),
-			status_changed_by = $2,
-			status_reason = $3,
-			updated_at = NOW(),
-			updated_by = $2
-		WHERE id = $4 AND deleted_at IS NULL
-	`
-
-	result, err := db.DB.Exec(query, req.Status, currentUser.ID, req.Reason, userID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "Failed to change status",
 		})
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "User not found",
-		})
-	}
-
-	// Log status change
-	logQuery := `
-		INSERT INTO account_status_changes 
-		(user_id, old_status, new_status, reason, changed_by, created_at)
-		VALUES ($1, $2, $3, $4, $5, NOW())
-	`
-	db.DB.Exec(logQuery, userID, currentStatus, req.Status, req.Reason, currentUser.ID)
+	invalidateUserCache(userID)
 
 	return c.JSON(fiber.Map{
 		"message":    "Account status changed successfully",
@@ -542,6 +552,7 @@ func HandleChangeAccountStatus(c *fiber.Ctx) error {
 // HandleRestoreUser restores a soft-deleted user (Admin only)
 func HandleRestoreUser(c *fiber.Ctx) error {
 	currentUser := middleware.GetUserFromContext(c)
+	ctx := c.UserContext()
 	userID, err := c.ParamsInt("id")
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -549,35 +560,67 @@ func HandleRestoreUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Only admin can restore
-	if !currentUser.IsAdmin() {
-		return c.Status(403).JSON(fiber.Map{
-			"error": "Forbidden - only admins can restore users",
-		})
-	}
-
-	// Restore user
-	query := `
-		UPDATE users 
-		SET deleted_at = NULL, deleted_by = NULL, updated_at = NOW(), updated_by = $1
-		WHERE id = $2 AND deleted_at IS NOT NULL
-	`
-
-	result, err := db.DB.Exec(query, currentUser.ID, userID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Failed to restore user",
-		})
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	err = newUserDeletion(ctx).Restore(ctx, currentUser, userID, middleware.ClientIP(c).String())
+	switch {
+	case errors.Is(err, user.ErrUserNotFound), errors.Is(err, user.ErrUserNotDeleted):
 		return c.Status(404).JSON(fiber.Map{
 			"error": "User not found or not deleted",
 		})
+	case errors.Is(err, user.ErrUserPurged):
+		return c.Status(410).JSON(fiber.Map{
+			"error": "User data has been purged and cannot be restored",
+		})
+	case err != nil:
+		return respondDeletionError(c, err, "Failed to restore user")
 	}
 
 	return c.JSON(fiber.Map{
 		"message": "User restored successfully",
 	})
 }
+
+// newUserDeletion builds the deletion use case on the primary
+func newUserDeletion(ctx context.Context) *usecase.UserDeletionUseCase {
+	writer := db.Writer(ctx)
+	return usecase.NewUserDeletionUseCase(
+		postgres.NewUserRepository(writer),
+		postgres.NewSessionRepository(writer),
+		postgres.NewPushTokenRepository(writer),
+		postgres.NewAPIKeyRepository(writer),
+		postgres.NewTxManager(writer),
+		postgres.NewOutboxRepository(writer),
+		redis.UserCache{},
+		nil,
+	)
+}
+
+// respondDeletionError answers policy errors of delete and restore with
+// 403 and anything else with 500
+func respondDeletionError(c *fiber.Ctx, err error, message string) error {
+	forbidden := []error{
+		user.ErrCannotModifySelf, user.ErrCannotModifyAdmin, user.ErrPermissionDenied,
+		// The acting account itself cannot perform actions
+		user.ErrUserPending, user.ErrUserSuspended, user.ErrUserBanned, user.ErrUserClosed,
+		user.ErrEmailNotVerified,
+	}
+	for _, target := range forbidden {
+		if errors.Is(err, target) {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "Forbidden - " + err.Error(),
+			})
+		}
+	}
+
+	log.Printf("⚠️  %s: %v", message, err)
+	return c.Status(500).JSON(fiber.Map{
+		"error": message,
+	})
+}
+
+// invalidateUserCache drops cached data built from a changed user
+// The database write already succeeded, so failures are only logged.
+func invalidateUserCache(userID int) {
+	if err := redis.InvalidateUser(userID); err != nil && !errors.Is(err, redis.ErrNotInitialized) {
+		log.Printf("⚠️  Failed to invalidate cache for user %d: %v", userID, err)
+	}
+}
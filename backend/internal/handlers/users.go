@@ -36,22 +36,6 @@ func VerifyEmail(c *fiber.Ctx) error {
 	})
 }
 
-func ListUsers(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{"users": []interface{}{}})
-}
-
-func GetUser(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{"user": fiber.Map{}})
-}
-
-func UpdateUser(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{"message": "User updated"})
-}
-
-func DeleteUser(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{"message": "User deleted"})
-}
-
 func Upload(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "File uploaded"})
 }
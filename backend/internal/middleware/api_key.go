@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	models "github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============================================================================
+// API KEY AUTHENTICATION
+// ============================================================================
+
+// APIKeyHeader carries the raw API key of programmatic clients
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyStore looks up usable API keys
+type APIKeyStore interface {
+	// FindActiveByHash returns models.ErrAPIKeyNotFound unless an
+	// unrevoked, unexpired key has the hash (see models.HashAPIKey)
+	FindActiveByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+}
+
+// APIKeyAuth authenticates requests sending an X-API-Key header and stores
+// the key in context, so rate limits charge the key's quota (see
+// ResolvePrincipal). Unknown, revoked and expired keys are rejected with
+// 401; requests without the header pass through. Register it before any
+// rate limit middleware.
+func APIKeyAuth(store APIKeyStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		raw := c.Get(APIKeyHeader)
+		if raw == "" {
+			return c.Next()
+		}
+
+		key, err := store.FindActiveByHash(c.UserContext(), models.HashAPIKey(raw))
+		if errors.Is(err, models.ErrAPIKeyNotFound) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Unauthorized - invalid API key",
+			})
+		}
+		if err != nil {
+			log.Printf("⚠️  API key lookup failed: %v", err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "Unable to verify API key",
+			})
+		}
+
+		SetAPIKeyInContext(c, key)
+		return c.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============================================================================
+// CLIENT IP & IP ACCESS CONTROL
+// ============================================================================
+
+// ResolveClientIP stores the real client IP (behind trusted proxies) and its
+// rate limit bucket in context. Register it before any rate limit middleware.
+func ResolveClientIP(resolver *ratelimit.ClientIPResolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var values []string
+		for _, value := range c.Request().Header.PeekAll(resolver.Header()) {
+			values = append(values, string(value))
+		}
+
+		ip := resolver.Resolve(c.Context().RemoteIP(), values...)
+		c.Locals(ClientIPKey, ip)
+		c.Locals(ClientIPBucketKey, resolver.Bucket(ip))
+
+		return c.Next()
+	}
+}
+
+// ClientIP returns the resolved client IP (falls back to the socket address)
+func ClientIP(c *fiber.Ctx) net.IP {
+	if ip, ok := c.Locals(ClientIPKey).(net.IP); ok {
+		return ip
+	}
+	return net.ParseIP(c.IP())
+}
+
+// ClientIPBucket returns the rate limit identifier value of the client IP
+// IPv6 clients are bucketed by prefix (e.g. "2001:db8:1:2::/64")
+func ClientIPBucket(c *fiber.Ctx) string {
+	if bucket, ok := c.Locals(ClientIPBucketKey).(string); ok && bucket != "" {
+		return bucket
+	}
+	return normalizeIdentifier(ratelimit.BucketIP(ClientIP(c), ratelimit.DefaultIPv6PrefixLength))
+}
+
+// IPAccessControl rejects denylisted networks with 403 and marks allowlisted
+// networks so rate limit middleware lets them through
+func IPAccessControl(list *ratelimit.IPList) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		rule, ok := list.Match(ClientIP(c))
+		if !ok {
+			return c.Next()
+		}
+
+		if rule.ListType == ratelimit.IPListDeny {
+			return ProblemResponse(c, ProblemDetails{
+				Type:     ProblemTypeIPDenied,
+				Title:    "Access denied",
+				Status:   fiber.StatusForbidden,
+				Detail:   "Requests from your network are not allowed.",
+				Instance: c.OriginalURL(),
+			})
+		}
+
+		c.Locals(RateLimitBypassKey, true)
+		return c.Next()
+	}
+}
+
+// isRateLimitBypassed checks if the request comes from an allowlisted network
+func isRateLimitBypassed(c *fiber.Ctx) bool {
+	bypass, _ := c.Locals(RateLimitBypassKey).(bool)
+	return bypass
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============================================================================
+// CONCURRENCY LIMIT MIDDLEWARE
+// ============================================================================
+
+// concurrencyRetryAfter is the Retry-After hint when all slots are taken
+// (slots free up when a request finishes, not at a known time)
+const concurrencyRetryAfter = 1
+
+// ConcurrencyLimit caps in-flight requests of the principal for action.
+// A slot is acquired before the handler and released when it returns.
+func ConcurrencyLimit(limiter *ratelimit.ConcurrencyLimiter, action string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Allowlisted networks are not rate limited
+		if isRateLimitBypassed(c) {
+			return c.Next()
+		}
+
+		principal := ResolvePrincipal(c)
+
+		lease, status, err := limiter.Acquire(c.Context(), principal.Identifier(), action)
+		if err != nil {
+			// On Redis error, allow request (fail open)
+			log.Printf("⚠️  Concurrency limit %s: %v", action, err)
+			return c.Next()
+		}
+
+		if !status.Acquired {
+			c.Set(ratelimit.HeaderRetryAfter, strconv.Itoa(concurrencyRetryAfter))
+			return ProblemResponse(c, ProblemDetails{
+				Type:       ProblemTypeConcurrencyLimitExceeded,
+				Title:      "Too many concurrent requests",
+				Status:     fiber.StatusTooManyRequests,
+				Detail:     fmt.Sprintf("At most %d %s requests may run at once. Please wait for one to finish.", status.MaxConcurrent, action),
+				Instance:   c.OriginalURL(),
+				RetryAfter: concurrencyRetryAfter,
+				Policy:     action,
+				Tier:       principal.Tier,
+			})
+		}
+
+		// Long requests keep their slot; crashed instances lose it after the lease TTL
+		stop := limiter.KeepAlive(lease)
+		defer func() {
+			stop()
+
+			// The request context may already be done, release independently
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := limiter.Release(ctx, lease); err != nil {
+				log.Printf("⚠️  Failed to release %s lease: %v", action, err)
+			}
+		}()
+
+		return c.Next()
+	}
+}
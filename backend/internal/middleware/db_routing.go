@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/db"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============================================================================
+// DATABASE ROUTING
+// ============================================================================
+
+// ReadYourWrites starts read-your-writes tracking for the request, so reads
+// through db.Reader(c.UserContext()) go to the primary once the request has
+// written through db.Writer
+func ReadYourWrites() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(db.WithReadYourWrites(c.UserContext()))
+		return c.Next()
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,9 +17,14 @@ import (
 // ============================================================================
 
 const (
-	UserContextKey = "user"
-	UserIDKey      = "user_id"
-	UserRoleKey    = "user_role"
+	UserContextKey   = "user"
+	UserIDKey        = "user_id"
+	UserRoleKey      = "user_role"
+	APIKeyContextKey = "api_key"
+
+	ClientIPKey        = "client_ip"
+	ClientIPBucketKey  = "client_ip_bucket"
+	RateLimitBypassKey = "rate_limit_bypass"
 )
 
 // ============================================================================
@@ -59,6 +65,55 @@ func GetUserRoleFromContext(c *fiber.Ctx) (models.UserRole, error) {
 	return role, nil
 }
 
+// SetAPIKeyInContext stores the authenticated API key in context
+func SetAPIKeyInContext(c *fiber.Ctx, key *models.APIKey) {
+	c.Locals(APIKeyContextKey, key)
+}
+
+// GetAPIKeyFromContext retrieves the authenticated API key from context
+func GetAPIKeyFromContext(c *fiber.Ctx) *models.APIKey {
+	key, ok := c.Locals(APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		return nil
+	}
+	return key
+}
+
+// ResolvePrincipal returns who a request is charged to for rate limiting:
+// the API key if present, else the authenticated user (tier by role),
+// else the client IP for anonymous callers
+func ResolvePrincipal(c *fiber.Ctx) ratelimit.Principal {
+	if key := GetAPIKeyFromContext(c); key != nil {
+		return ratelimit.Principal{
+			Type:  ratelimit.IdentifierAPIKey,
+			Value: strconv.Itoa(key.ID),
+			Tier:  ratelimit.TierAPIKey,
+		}
+	}
+
+	if user := GetUserFromContext(c); user != nil {
+		tier := ratelimit.TierUser
+		switch {
+		case user.IsAdmin():
+			tier = ratelimit.TierAdmin
+		case user.IsLeader():
+			tier = ratelimit.TierLeader
+		}
+
+		return ratelimit.Principal{
+			Type:  ratelimit.IdentifierUserID,
+			Value: strconv.Itoa(user.ID),
+			Tier:  tier,
+		}
+	}
+
+	return ratelimit.Principal{
+		Type:  ratelimit.IdentifierIP,
+		Value: ClientIPBucket(c),
+		Tier:  ratelimit.TierAnonymous,
+	}
+}
+
 // ============================================================================
 // ROLE-BASED ACCESS CONTROL MIDDLEWARE
 // ============================================================================
@@ -245,8 +300,12 @@ func RedisRateLimitMiddleware(limiter *ratelimit.RedisLimiter, action string) fi
 		// ✅ FIX 1: Use c.Context() instead of context.Background()
 		ctx := c.Context()
 
-		// Get identifier (IP address by default)
-		identifier := normalizeIdentifier(c.IP())
+		if isRateLimitBypassed(c) {
+			return c.Next()
+		}
+
+		// Get identifier (client IP bucket by default)
+		identifier := ClientIPBucket(c)
 
 		// Record attempt and get status
 		status, err := limiter.RecordAttempt(ctx, identifier, action)
@@ -256,19 +315,58 @@ func RedisRateLimitMiddleware(limiter *ratelimit.RedisLimiter, action string) fi
 			return c.Next()
 		}
 
-		// Set rate limit headers
-		setRateLimitHeaders(c, status)
-
 		// If blocked, return 429
 		if status.Blocked {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":         "Rate limit exceeded",
-				"message":       getRateLimitMessage(action, status),
-				"retry_after":   getRetryAfter(status),
-				"blocked_until": status.BlockedUntil,
-			})
+			return rateLimitExceeded(c, limiter.Now(), action, "", status)
 		}
 
+		// Set rate limit headers
+		setRateLimitHeaders(c, limiter.Now(), status)
+
+		return c.Next()
+	}
+}
+
+// RateLimitPolicies creates rate limiting middleware that charges several
+// stacked policies (e.g. per-second burst + hourly quota) for one route.
+// Policies are recorded in order and the first blocked policy rejects the request.
+//
+// The identifier and rule are resolved from the principal (API key, user or IP):
+// a per-identifier override wins, then the tiered rule ("api:leader"),
+// then the policy's base rule.
+func RateLimitPolicies(limiter *ratelimit.RedisLimiter, policies ...ratelimit.Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Allowlisted networks are not rate limited
+		if isRateLimitBypassed(c) {
+			return c.Next()
+		}
+
+		ctx := c.Context()
+
+		principal := ResolvePrincipal(c)
+		identifier := principal.Identifier()
+
+		// Every charged policy is listed in the RateLimit headers
+		statuses := make([]*ratelimit.RateLimitStatus, 0, len(policies))
+
+		for _, policy := range policies {
+			action := resolvePolicyAction(limiter, identifier, policy.Action, principal.Tier)
+
+			status, err := limiter.RecordAttemptWithCost(ctx, identifier, action, policy.EffectiveCost())
+			if err != nil {
+				// On Redis error, skip this policy (fail open)
+				continue
+			}
+
+			statuses = append(statuses, status)
+
+			if status.Blocked {
+				return rateLimitExceeded(c, limiter.Now(), policy.Action, principal.Tier, status, statuses...)
+			}
+		}
+
+		setRateLimitHeaders(c, limiter.Now(), statuses...)
+
 		return c.Next()
 	}
 }
@@ -276,6 +374,10 @@ func RedisRateLimitMiddleware(limiter *ratelimit.RedisLimiter, action string) fi
 // RedisRateLimitByUserID creates rate limiting middleware using user ID
 func RedisRateLimitByUserID(limiter *ratelimit.RedisLimiter, action string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		if isRateLimitBypassed(c) {
+			return c.Next()
+		}
+
 		// Get user ID from context (set by JWT middleware)
 		userID, err := GetUserIDFromContext(c)
 		if err != nil {
@@ -295,18 +397,13 @@ func RedisRateLimitByUserID(limiter *ratelimit.RedisLimiter, action string) fibe
 			return c.Next() // Fail open
 		}
 
-		// Set headers
-		setRateLimitHeaders(c, status)
-
 		if status.Blocked {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":         "Rate limit exceeded",
-				"message":       getRateLimitMessage(action, status),
-				"retry_after":   getRetryAfter(status),
-				"blocked_until": status.BlockedUntil,
-			})
+			return rateLimitExceeded(c, limiter.Now(), action, "", status)
 		}
 
+		// Set headers
+		setRateLimitHeaders(c, limiter.Now(), status)
+
 		return c.Next()
 	}
 }
@@ -315,6 +412,10 @@ func RedisRateLimitByUserID(limiter *ratelimit.RedisLimiter, action string) fibe
 // ✅ FIX 2: Only parse email field, not entire body
 func RedisRateLimitByEmail(limiter *ratelimit.RedisLimiter, action string, emailField string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		if isRateLimitBypassed(c) {
+			return c.Next()
+		}
+
 		// ✅ FIX 2: Parse only the email field to avoid body consumption
 		type EmailPayload struct {
 			Email string `json:"email"`
@@ -344,18 +445,13 @@ func RedisRateLimitByEmail(limiter *ratelimit.RedisLimiter, action string, email
 			return c.Next() // Fail open
 		}
 
-		// Set headers
-		setRateLimitHeaders(c, status)
-
 		if status.Blocked {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":         "Rate limit exceeded",
-				"message":       getRateLimitMessage(action, status),
-				"retry_after":   getRetryAfter(status),
-				"blocked_until": status.BlockedUntil,
-			})
+			return rateLimitExceeded(c, limiter.Now(), action, "", status)
 		}
 
+		// Set headers
+		setRateLimitHeaders(c, limiter.Now(), status)
+
 		return c.Next()
 	}
 }
@@ -367,15 +463,14 @@ func CombinedRedisRateLimit(limiter *ratelimit.RedisLimiter, action string) fibe
 		ctx := c.Context()
 
 		// Check IP-based rate limit first
-		ip := normalizeIdentifier(c.IP())
+		if isRateLimitBypassed(c) {
+			return c.Next()
+		}
+
+		ip := ClientIPBucket(c)
 		status, err := limiter.RecordAttempt(ctx, ip, action)
 		if err == nil && status.Blocked {
-			setRateLimitHeaders(c, status)
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error":       "Rate limit exceeded (IP)",
-				"message":     getRateLimitMessage(action, status),
-				"retry_after": getRetryAfter(status),
-			})
+			return rateLimitExceeded(c, limiter.Now(), action, "", status)
 		}
 
 		// Check user-based rate limit if authenticated
@@ -384,12 +479,7 @@ func CombinedRedisRateLimit(limiter *ratelimit.RedisLimiter, action string) fibe
 			identifier := fmt.Sprintf("user:%d", userID)
 			status, err := limiter.RecordAttempt(ctx, identifier, action)
 			if err == nil && status.Blocked {
-				setRateLimitHeaders(c, status)
-				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-					"error":       "Rate limit exceeded (User)",
-					"message":     getRateLimitMessage(action, status),
-					"retry_after": getRetryAfter(status),
-				})
+				return rateLimitExceeded(c, limiter.Now(), action, "", status)
 			}
 		}
 
@@ -401,6 +491,21 @@ func CombinedRedisRateLimit(limiter *ratelimit.RedisLimiter, action string) fibe
 // HELPER FUNCTIONS (FIXED)
 // ============================================================================
 
+// resolvePolicyAction picks the rule action for a principal: overrides are
+// keyed by the base action, otherwise a tiered rule is used when configured
+func resolvePolicyAction(limiter *ratelimit.RedisLimiter, identifier, action string, tier ratelimit.Tier) string {
+	if limiter.HasOverride(identifier, action) {
+		return action
+	}
+
+	tiered := ratelimit.TieredAction(action, tier)
+	if _, ok := limiter.GetRule(tiered); ok {
+		return tiered
+	}
+
+	return action
+}
+
 // ✅ FIX 4: Normalize identifier (trim spaces, lowercase)
 func normalizeIdentifier(identifier string) string {
 	return strings.ToLower(strings.TrimSpace(identifier))
@@ -411,26 +516,17 @@ func normalizeEmail(email string) string {
 	return strings.ToLower(strings.TrimSpace(email))
 }
 
-// setRateLimitHeaders sets standard rate limit response headers
-func setRateLimitHeaders(c *fiber.Ctx, status *ratelimit.RateLimitStatus) {
-	c.Set("X-RateLimit-Limit", fmt.Sprintf("%d", status.MaxAttempts))
-	c.Set("X-RateLimit-Remaining", fmt.Sprintf("%d", status.RemainingTries))
-
-	if !status.WindowEnd.IsZero() {
-		c.Set("X-RateLimit-Reset", fmt.Sprintf("%d", status.WindowEnd.Unix()))
-	}
-
-	if status.Blocked && status.BlockedUntil != nil {
-		retryAfter := int(time.Until(*status.BlockedUntil).Seconds())
-		if retryAfter < 0 {
-			retryAfter = 0
-		}
-		c.Set("Retry-After", fmt.Sprintf("%d", retryAfter))
+// setRateLimitHeaders sets the RateLimit-Policy/RateLimit headers for every
+// charged policy, plus legacy X-RateLimit-* headers when enabled
+func setRateLimitHeaders(c *fiber.Ctx, now time.Time, statuses ...*ratelimit.RateLimitStatus) {
+	for name, value := range ratelimit.BuildHeaders(now, legacyRateLimitHeaders(), statuses...) {
+		c.Set(name, value)
 	}
 }
 
-// getRateLimitMessage returns user-friendly message based on action
-func getRateLimitMessage(action string, status *ratelimit.RateLimitStatus) string {
+// getRateLimitMessage returns user-friendly message based on action.
+// now is the limiter clock's time, so the retry hint matches Retry-After.
+func getRateLimitMessage(action string, status *ratelimit.RateLimitStatus, now time.Time) string {
 	messages := map[string]string{
 		"login":          "Too many login attempts. Please try again later.",
 		"register":       "Too many registration attempts. Please try again later.",
@@ -438,6 +534,8 @@ func getRateLimitMessage(action string, status *ratelimit.RateLimitStatus) strin
 		"email_verify":   "Too many email verification attempts. Please try again later.",
 		"api":            "Too many API requests. Please slow down.",
 		"upload":         "Too many file uploads. Please try again later.",
+		"api_burst":      "Too many API requests in a short time. Please slow down.",
+		"upload_burst":   "Too many file uploads in a short time. Please slow down.",
 	}
 
 	msg, ok := messages[action]
@@ -447,7 +545,7 @@ func getRateLimitMessage(action string, status *ratelimit.RateLimitStatus) strin
 
 	// Add retry time if blocked
 	if status.Blocked && status.BlockedUntil != nil {
-		retrySeconds := int(time.Until(*status.BlockedUntil).Seconds())
+		retrySeconds := int(status.BlockedUntil.Sub(now).Seconds())
 		if retrySeconds > 0 {
 			if retrySeconds < 60 {
 				msg += fmt.Sprintf(" You can try again in %d seconds.", retrySeconds)
@@ -461,20 +559,6 @@ func getRateLimitMessage(action string, status *ratelimit.RateLimitStatus) strin
 	return msg
 }
 
-// getRetryAfter returns retry after duration in seconds
-func getRetryAfter(status *ratelimit.RateLimitStatus) int {
-	if status.BlockedUntil == nil {
-		return 0
-	}
-
-	retryAfter := int(time.Until(*status.BlockedUntil).Seconds())
-	if retryAfter < 0 {
-		return 0
-	}
-
-	return retryAfter
-}
-
 // ============================================================================
 // PERMISSION CHECKER HELPERS
 // ============================================================================
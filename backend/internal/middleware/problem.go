@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============================================================================
+// PROBLEM DETAILS (RFC 9457)
+// ============================================================================
+
+const (
+	ProblemContentType = "application/problem+json"
+
+	ProblemTypeRateLimitExceeded = "/problems/rate-limit-exceeded"
+	ProblemTypeIPDenied          = "/problems/ip-denied"
+
+	ProblemTypeConcurrencyLimitExceeded = "/problems/concurrency-limit-exceeded"
+)
+
+// ProblemDetails is an RFC 9457 problem document
+// Rate limit responses add retry_after, policy, tier and blocked_until
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	RetryAfter   int            `json:"retry_after,omitempty"`
+	Policy       string         `json:"policy,omitempty"`
+	Tier         ratelimit.Tier `json:"tier,omitempty"`
+	BlockedUntil *time.Time     `json:"blocked_until,omitempty"`
+}
+
+// ProblemResponse sends a problem+json response
+func ProblemResponse(c *fiber.Ctx, problem ProblemDetails) error {
+	return c.Status(problem.Status).JSON(problem, ProblemContentType)
+}
+
+// rateLimitExceeded rejects the request with 429 for the blocked policy.
+// statuses are every policy charged for the request and go into the headers.
+func rateLimitExceeded(c *fiber.Ctx, now time.Time, action string, tier ratelimit.Tier, status *ratelimit.RateLimitStatus, statuses ...*ratelimit.RateLimitStatus) error {
+	if len(statuses) == 0 {
+		statuses = []*ratelimit.RateLimitStatus{status}
+	}
+	setRateLimitHeaders(c, now, statuses...)
+
+	// Retry-After is always sent on 429, even when the block just expired
+	retryAfter := status.RetryAfterSecondsAt(now)
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Set(ratelimit.HeaderRetryAfter, strconv.Itoa(retryAfter))
+
+	return ProblemResponse(c, ProblemDetails{
+		Type:         ProblemTypeRateLimitExceeded,
+		Title:        "Rate limit exceeded",
+		Status:       fiber.StatusTooManyRequests,
+		Detail:       getRateLimitMessage(action, status, now),
+		Instance:     c.OriginalURL(),
+		RetryAfter:   retryAfter,
+		Policy:       status.Action,
+		Tier:         tier,
+		BlockedUntil: status.BlockedUntil,
+	})
+}
+
+// legacyRateLimitHeaders reports whether X-RateLimit-* headers are enabled
+func legacyRateLimitHeaders() bool {
+	if config.Cfg == nil {
+		return true
+	}
+	return config.Cfg.RateLimit.LegacyHeaders
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	models "github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============================================================================
+// SESSION AUTHENTICATION
+// ============================================================================
+
+// bearerPrefix starts the Authorization header of logged-in requests
+const bearerPrefix = "Bearer "
+
+// SessionStore looks up the users of active sessions
+type SessionStore interface {
+	// FindUserBySessionToken returns models.ErrSessionNotFound unless an
+	// unrevoked, unexpired session of a user that is not deleted has the
+	// raw token (see models.HashSessionToken)
+	FindUserBySessionToken(ctx context.Context, sessionToken string) (*models.User, error)
+}
+
+// SessionAuth authenticates requests sending "Authorization: Bearer
+// <access token>" and stores the user in context, so rate limits charge the
+// user's tier (see ResolvePrincipal) and the Require* middleware can check
+// it. Unknown, revoked and expired sessions are rejected with 401; requests
+// without the header pass through. Register it before any rate limit
+// middleware.
+func SessionAuth(store SessionStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		if header == "" {
+			return c.Next()
+		}
+
+		token, ok := strings.CutPrefix(header, bearerPrefix)
+		if !ok || token == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Unauthorized - invalid authorization header",
+			})
+		}
+
+		user, err := store.FindUserBySessionToken(c.UserContext(), token)
+		if errors.Is(err, models.ErrSessionNotFound) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Unauthorized - invalid or expired session",
+			})
+		}
+		if err != nil {
+			log.Printf("⚠️  Session lookup failed: %v", err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "Unable to verify session",
+			})
+		}
+
+		SetUserInContext(c, user)
+		return c.Next()
+	}
+}
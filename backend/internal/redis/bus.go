@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// INVALIDATION BUS
+// ============================================================================
+// Instances running a local LRU tier tell each other which keys changed so
+// stale in-process copies are dropped instead of served until they expire.
+
+// DefaultInvalidationChannel is the pub/sub channel used by the default loader
+const DefaultInvalidationChannel = "cache:invalidate"
+
+// Bus broadcasts messages to every subscribed instance
+type Bus interface {
+	Publish(ctx context.Context, msg string) error
+
+	// Subscribe calls handler for every message until the returned close
+	// function is called; the subscription is active when Subscribe returns
+	Subscribe(ctx context.Context, handler func(msg string)) (close func() error, err error)
+}
+
+// RedisBus implements Bus with Redis pub/sub
+type RedisBus struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+// NewRedisBus creates a bus on channel
+func NewRedisBus(client redis.UniversalClient, channel string) *RedisBus {
+	return &RedisBus{client: client, channel: channel}
+}
+
+func (b *RedisBus) Publish(ctx context.Context, msg string) error {
+	return b.client.Publish(ctx, b.channel, msg).Err()
+}
+
+func (b *RedisBus) Subscribe(ctx context.Context, handler func(msg string)) (func() error, error) {
+	sub := b.client.Subscribe(ctx, b.channel)
+
+	// Wait for the subscription confirmation so no message is missed
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", b.channel, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range sub.Channel() {
+			handler(msg.Payload)
+		}
+	}()
+
+	return func() error {
+		err := sub.Close()
+		<-done
+		if err != nil {
+			log.Printf("⚠️  Failed to close subscription to %s: %v", b.channel, err)
+		}
+		return err
+	}, nil
+}
@@ -0,0 +1,172 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// CACHE
+// ============================================================================
+// Cache is the injectable cache API: every call takes the request context so
+// cancellation and deadlines reach Redis. RedisCache is the production
+// implementation, MemoryCache stands in for it in tests.
+//
+// Keys are namespaced with WithPrefix:
+//
+//	users := cache.WithPrefix(PrefixUser) // "user:42" for key "42"
+//
+// Typed JSON values go through Typed:
+//
+//	profiles := Typed[user.User](cache.WithPrefix("user:profile"))
+//	u, err := profiles.Get(ctx, "42")
+
+// ErrWrongType is returned when a key holds a value of another type
+var ErrWrongType = errors.New("key holds a value of another type")
+
+// Cache is a namespaced key-value cache (strings, hashes, lists, sets)
+// Missing keys return ErrNotFound from Get, HGet, LPop and RPop.
+type Cache interface {
+	// Strings
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	IncrBy(ctx context.Context, key string, value int64) (int64, error)
+
+	// Keys
+	Delete(ctx context.Context, keys ...string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	TTL(ctx context.Context, key string) (time.Duration, error) // -1 = no expiry, -2 = missing
+	Keys(ctx context.Context, pattern string) ([]string, error) // SCAN, never KEYS
+	DeletePattern(ctx context.Context, pattern string) error
+
+	// Hashes
+	HSet(ctx context.Context, key, field string, value interface{}) error
+	HGet(ctx context.Context, key, field string) (string, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HDel(ctx context.Context, key string, fields ...string) error
+
+	// Lists
+	LPush(ctx context.Context, key string, values ...interface{}) error
+	RPush(ctx context.Context, key string, values ...interface{}) error
+	LPop(ctx context.Context, key string) (string, error)
+	RPop(ctx context.Context, key string) (string, error)
+	LRange(ctx context.Context, key string, start, stop int64) ([]string, error)
+	LLen(ctx context.Context, key string) (int64, error)
+
+	// Sets
+	SAdd(ctx context.Context, key string, members ...interface{}) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SIsMember(ctx context.Context, key string, member interface{}) (bool, error)
+	SRem(ctx context.Context, key string, members ...interface{}) error
+
+	// WithPrefix returns a view of the cache whose keys live under prefix
+	WithPrefix(prefix string) Cache
+}
+
+// joinPrefix nests prefix under parent ("user" + "profile" → "user:profile:")
+func joinPrefix(parent, prefix string) string {
+	if prefix == "" {
+		return parent
+	}
+	return parent + strings.TrimSuffix(prefix, ":") + ":"
+}
+
+// ============================================================================
+// TYPED JSON VALUES
+// ============================================================================
+
+// TypedCache stores values of type T as JSON
+type TypedCache[T any] struct {
+	cache Cache
+}
+
+// Typed returns a typed JSON view of c
+func Typed[T any](c Cache) *TypedCache[T] {
+	return &TypedCache[T]{cache: c}
+}
+
+// Get returns the value stored at key (ErrNotFound if missing)
+func (t *TypedCache[T]) Get(ctx context.Context, key string) (T, error) {
+	var value T
+	err := getJSON(ctx, t.cache, key, &value)
+	return value, err
+}
+
+// Set stores value at key as JSON
+func (t *TypedCache[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	return setJSON(ctx, t.cache, key, value, ttl)
+}
+
+// Delete removes keys
+func (t *TypedCache[T]) Delete(ctx context.Context, keys ...string) error {
+	return t.cache.Delete(ctx, keys...)
+}
+
+// GetOrSet returns the cached value, or stores and returns the result of fetch
+// Failing to cache is logged, not returned. Hot keys should use Load with a
+// Loader instead, which protects the source against stampedes.
+func (t *TypedCache[T]) GetOrSet(ctx context.Context, key string, ttl time.Duration, fetch func(ctx context.Context) (T, error)) (T, error) {
+	value, err := t.Get(ctx, key)
+	if err == nil || !errors.Is(err, ErrNotFound) {
+		return value, err
+	}
+
+	value, err = fetch(ctx)
+	if err != nil {
+		return value, fmt.Errorf("failed to fetch data: %w", err)
+	}
+
+	if err := t.Set(ctx, key, value, ttl); err != nil {
+		log.Printf("⚠️  Failed to cache data for key %s: %v", key, err)
+	}
+
+	return value, nil
+}
+
+// getJSON unmarshals the JSON value at key into dest
+func getJSON(ctx context.Context, c Cache, key string, dest interface{}) error {
+	val, err := c.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	data := []byte(val)
+	if env, ok := decodeEnvelope(val); ok {
+		data = env.Value // Written by a Loader
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnmarshalJSON, err)
+	}
+
+	return nil
+}
+
+// setJSON marshals value and stores it at key
+func setJSON(ctx context.Context, c Cache, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMarshalJSON, err)
+	}
+
+	return c.Set(ctx, key, data, ttl)
+}
+
+// escapeGlob escapes glob characters so a prefix matches literally in SCAN
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,143 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type profile struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// backends builds each Cache implementation with a function advancing its time;
+// tests run against all of them so the in-memory fake keeps Redis semantics
+var backends = map[string]func(t *testing.T) (Cache, func(time.Duration)){
+	"memory": func(t *testing.T) (Cache, func(time.Duration)) {
+		clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+		return NewMemoryCache(clk), clk.Advance
+	},
+	"redis": func(t *testing.T) (Cache, func(time.Duration)) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		return NewRedisCache(client, ""), server.FastForward
+	},
+}
+
+func TestCache(t *testing.T) {
+	for name, newCache := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cache, advance := newCache(t)
+
+			// Prefixed views are isolated from each other
+			users := cache.WithPrefix("user")
+			posts := cache.WithPrefix("post")
+			if err := users.Set(ctx, "1", "alice", time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := posts.Get(ctx, "1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("post:1 = %v, want ErrNotFound", err)
+			}
+			if v, _ := cache.Get(ctx, "user:1"); v != "alice" {
+				t.Errorf("user:1 = %q, want alice", v)
+			}
+
+			// Keys are relative to the prefix
+			_ = posts.Set(ctx, "2", 2, 0)
+			keys, err := users.Keys(ctx, "*")
+			if err != nil || len(keys) != 1 || keys[0] != "1" {
+				t.Errorf("users.Keys = %v, %v, want [1]", keys, err)
+			}
+
+			// Expiry
+			if ttl, _ := posts.TTL(ctx, "2"); ttl != -1 {
+				t.Errorf("TTL without expiry = %v, want -1", ttl)
+			}
+			advance(time.Minute)
+			if ok, _ := users.Exists(ctx, "1"); ok {
+				t.Error("user:1 still exists after its TTL")
+			}
+			if ttl, _ := users.TTL(ctx, "1"); ttl != -2 {
+				t.Errorf("TTL of missing key = %v, want -2", ttl)
+			}
+
+			// Counters and wrong types
+			if n, err := cache.IncrBy(ctx, "hits", 5); err != nil || n != 5 {
+				t.Errorf("IncrBy = %d, %v, want 5", n, err)
+			}
+			if err := cache.LPush(ctx, "hits", "x"); err == nil {
+				t.Error("LPush on a string key succeeded")
+			}
+
+			// Typed JSON values
+			profiles := Typed[profile](cache.WithPrefix("profile"))
+			calls := 0
+			fetch := func(ctx context.Context) (profile, error) {
+				calls++
+				return profile{ID: 7, Name: "bob"}, nil
+			}
+			for i := 0; i < 2; i++ {
+				p, err := profiles.GetOrSet(ctx, "7", time.Minute, fetch)
+				if err != nil || p.Name != "bob" {
+					t.Fatalf("GetOrSet = %+v, %v", p, err)
+				}
+			}
+			if calls != 1 {
+				t.Errorf("fetch called %d times, want 1", calls)
+			}
+
+			if err := cache.DeletePattern(ctx, "profile:*"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := profiles.Get(ctx, "7"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("profile after DeletePattern = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestTags(t *testing.T) {
+	for name, newCache := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cache, advance := newCache(t)
+			tagger := cache.(Tagger)
+
+			_ = cache.Set(ctx, KitKey(1), "kit", time.Minute)
+			_ = cache.Set(ctx, UserKitsKey(42), "[1]", time.Hour)
+			_ = cache.Set(ctx, TeamKey(7), "team", time.Minute)
+			if err := tagger.Tag(ctx, KitKey(1), time.Minute, KitTag(1)); err != nil {
+				t.Fatal(err)
+			}
+			_ = tagger.Tag(ctx, UserKitsKey(42), time.Hour, UserTag(42), KitTag(1))
+			_ = tagger.Tag(ctx, TeamKey(7), time.Minute, TeamTag(7))
+
+			// The tag outlives its short-lived members
+			advance(2 * time.Minute)
+
+			keys, err := tagger.InvalidateTags(ctx, KitTag(1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(keys) != 1 || keys[0] != UserKitsKey(42) {
+				t.Errorf("deleted %v, want [%s] (the kit itself expired)", keys, UserKitsKey(42))
+			}
+			if ok, _ := cache.Exists(ctx, UserKitsKey(42)); ok {
+				t.Error("tagged list survived invalidation")
+			}
+
+			// Invalidating again is a no-op
+			if keys, _ := tagger.InvalidateTags(ctx, KitTag(1), UserTag(42)); len(keys) != 0 {
+				t.Errorf("second invalidation deleted %v", keys)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// CLIENT FACTORY (single node, Sentinel, Cluster)
+// ============================================================================
+// Every Redis user (cache, rate limiter) goes through NewUniversalClient so
+// the deployment topology is only a matter of configuration.
+
+const (
+	minIdleConns = 5
+	maxRetries   = 3
+)
+
+// NewUniversalClient creates a Redis client for the configured topology:
+// a cluster client for ClusterAddrs, a failover client for SentinelMaster,
+// otherwise a single-node client for URL
+func NewUniversalClient(cfg config.RedisConfig) (redis.UniversalClient, error) {
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cfg.IsCluster():
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        cfg.ClusterAddrs,
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			PoolSize:     cfg.PoolSize,
+			MinIdleConns: minIdleConns,
+			MaxRetries:   maxRetries,
+			TLSConfig:    tlsConfig,
+		}), nil
+
+	case cfg.IsSentinel():
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.SentinelMaster,
+			SentinelAddrs:    cfg.SentinelAddrs,
+			SentinelPassword: cfg.SentinelPassword,
+			Username:         cfg.Username,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			PoolSize:         cfg.PoolSize,
+			MinIdleConns:     minIdleConns,
+			MaxRetries:       maxRetries,
+			TLSConfig:        tlsConfig,
+		}), nil
+	}
+
+	opt, err := redis.ParseURL(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+
+	if cfg.Username != "" {
+		opt.Username = cfg.Username
+	}
+	if cfg.PoolSize > 0 {
+		opt.PoolSize = cfg.PoolSize
+	}
+	opt.MinIdleConns = minIdleConns
+	opt.MaxRetries = maxRetries
+	if tlsConfig != nil {
+		opt.TLSConfig = tlsConfig
+	}
+
+	return redis.NewClient(opt), nil
+}
+
+// newTLSConfig builds the TLS configuration (nil when TLS is disabled)
+func newTLSConfig(cfg config.RedisConfig) (*tls.Config, error) {
+	if !cfg.TLSEnabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.TLSServerName,
+	}
+
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redis CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in redis CA file %s", cfg.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,450 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+)
+
+// ============================================================================
+// LOADER (read-through cache with stampede protection)
+// ============================================================================
+// A miss on a hot key must not turn into thousands of identical queries.
+// Loader layers four defences, cheapest first:
+//
+//  1. an optional in-process LRU tier, invalidated across instances via a Bus
+//  2. singleflight: concurrent misses on a key in this process share one fetch
+//  3. an optional distributed lock: one instance fetches, the others wait for
+//     its result (or keep serving the stale value while it refreshes)
+//  4. probabilistic early refresh (XFetch): a hit close to expiry occasionally
+//     refreshes the entry so it never expires under load
+//
+// Values are stored as JSON inside an envelope carrying the fetch duration and
+// expiry that early refresh needs; GetJSON unwraps it transparently.
+
+const (
+	defaultLockTTL  = 10 * time.Second
+	defaultLockWait = 2 * time.Second
+	defaultLocalTTL = 30 * time.Second
+
+	lockSuffix       = ":lock"
+	lockPollInterval = 50 * time.Millisecond
+)
+
+// errFetchPanicked is returned to callers sharing a fetch that panicked
+var errFetchPanicked = errors.New("cache fetch panicked")
+
+// LoaderOptions configures the optional layers of a Loader
+// The zero value gives singleflight only.
+type LoaderOptions struct {
+	// Lock coalesces misses across instances (the cache must implement Locker)
+	Lock     bool
+	LockTTL  time.Duration // How long a fetch may hold the lock (default 10s)
+	LockWait time.Duration // How long others wait before fetching anyway (default 2s)
+
+	// EarlyRefreshBeta > 0 enables early refresh; 1 is the usual value,
+	// higher values refresh earlier
+	EarlyRefreshBeta float64
+
+	// LocalSize > 0 enables the in-process LRU tier
+	LocalSize int
+	LocalTTL  time.Duration // Upper bound on a local copy's age (default 30s)
+	Bus       Bus           // Drops local copies on other instances (nil = this instance only)
+
+	Clock clock.Clock // nil = clock.System
+}
+
+// Loader is a read-through JSON cache with stampede protection
+type Loader struct {
+	cache  Cache
+	locker Locker
+	opts   LoaderOptions
+	clock  clock.Clock
+	random func() float64 // uniform in [0, 1)
+
+	flights flightGroup
+	local   *localCache
+
+	id       string // Tags our own invalidations so we don't drop fresh copies
+	closeBus func() error
+}
+
+// NewLoader creates a loader on cache
+func NewLoader(cache Cache, opts LoaderOptions) (*Loader, error) {
+	if opts.LockTTL <= 0 {
+		opts.LockTTL = defaultLockTTL
+	}
+	if opts.LockWait <= 0 {
+		opts.LockWait = defaultLockWait
+	}
+	if opts.LocalTTL <= 0 {
+		opts.LocalTTL = defaultLocalTTL
+	}
+
+	l := &Loader{
+		cache:  cache,
+		opts:   opts,
+		clock:  clock.OrSystem(opts.Clock),
+		random: rand.Float64,
+	}
+
+	if opts.Lock {
+		locker, ok := cache.(Locker)
+		if !ok {
+			return nil, fmt.Errorf("cache %T does not support locking", cache)
+		}
+		l.locker = locker
+	}
+
+	if opts.LocalSize > 0 {
+		l.local = newLocalCache(opts.LocalSize, opts.LocalTTL, l.clock)
+
+		if opts.Bus != nil {
+			id, err := newLockToken()
+			if err != nil {
+				return nil, err
+			}
+			l.id = id
+
+			closeBus, err := opts.Bus.Subscribe(context.Background(), l.onInvalidate)
+			if err != nil {
+				return nil, err
+			}
+			l.closeBus = closeBus
+		}
+	}
+
+	return l, nil
+}
+
+// Close stops listening for invalidations
+func (l *Loader) Close() error {
+	if l.closeBus != nil {
+		return l.closeBus()
+	}
+	return nil
+}
+
+// Load unmarshals the value at key into dest, calling fetch on a miss and
+// caching its result for ttl under tags (see InvalidateTags)
+func (l *Loader) Load(ctx context.Context, key string, ttl time.Duration, dest interface{}, fetch func(ctx context.Context) (interface{}, error), tags ...string) error {
+	data, err := l.load(ctx, key, ttl, fetch, tags)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnmarshalJSON, err)
+	}
+	return nil
+}
+
+// Load is the typed form of Loader.Load
+func Load[T any](ctx context.Context, l *Loader, key string, ttl time.Duration, fetch func(ctx context.Context) (T, error), tags ...string) (T, error) {
+	var value T
+	err := l.Load(ctx, key, ttl, &value, func(ctx context.Context) (interface{}, error) {
+		return fetch(ctx)
+	}, tags...)
+	return value, err
+}
+
+// Invalidate deletes keys from Redis and from every instance's local tier
+func (l *Loader) Invalidate(ctx context.Context, keys ...string) error {
+	if err := l.cache.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	l.evict(ctx, keys...)
+	return nil
+}
+
+// InvalidateTags deletes every entry cached under tags, in Redis and in
+// every instance's local tier
+func (l *Loader) InvalidateTags(ctx context.Context, tags ...string) error {
+	tagger, ok := l.cache.(Tagger)
+	if !ok {
+		return fmt.Errorf("cache %T does not support tags", l.cache)
+	}
+
+	keys, err := tagger.InvalidateTags(ctx, tags...)
+	if err != nil {
+		return err
+	}
+	l.evict(ctx, keys...)
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Load path
+// ----------------------------------------------------------------------------
+
+// load returns the raw JSON value at key
+func (l *Loader) load(ctx context.Context, key string, ttl time.Duration, fetch func(ctx context.Context) (interface{}, error), tags []string) ([]byte, error) {
+	if l.local != nil {
+		if data, ok := l.local.get(key); ok {
+			return data, nil
+		}
+	}
+
+	// Concurrent callers share the first caller's fetch (and its context)
+	return l.flights.do(key, func() ([]byte, error) {
+		return l.loadShared(ctx, key, ttl, fetch, tags)
+	})
+}
+
+// loadShared reads Redis and fetches on a miss or an early refresh
+func (l *Loader) loadShared(ctx context.Context, key string, ttl time.Duration, fetch func(ctx context.Context) (interface{}, error), tags []string) ([]byte, error) {
+	var stale []byte
+
+	cached, err := l.cache.Get(ctx, key)
+	switch {
+	case err == nil:
+		env, ok := decodeEnvelope(cached)
+		if !ok {
+			// Written by SetJSON: no metadata, never refreshed early
+			l.storeLocal(key, []byte(cached), 0)
+			return []byte(cached), nil
+		}
+		if !l.shouldRefresh(env) {
+			l.storeLocal(key, env.Value, env.remaining(l.clock.Now()))
+			return env.Value, nil
+		}
+		stale = env.Value
+
+	case !errors.Is(err, ErrNotFound):
+		return nil, err
+	}
+
+	if l.locker != nil {
+		release, ok, err := l.locker.TryLock(ctx, key+lockSuffix, l.opts.LockTTL)
+		switch {
+		case err != nil:
+			log.Printf("⚠️  Cache lock failed for key %s, fetching without it: %v", key, err)
+		case ok:
+			defer release()
+		case stale != nil:
+			// Another instance is refreshing; the current value is still valid
+			return stale, nil
+		default:
+			if data, ok := l.waitFor(ctx, key); ok {
+				return data, nil
+			}
+			log.Printf("⚠️  Timed out waiting for cache key %s, fetching it", key)
+		}
+	}
+
+	return l.refresh(ctx, key, ttl, fetch, tags)
+}
+
+// refresh fetches the value and stores it for ttl under tags
+func (l *Loader) refresh(ctx context.Context, key string, ttl time.Duration, fetch func(ctx context.Context) (interface{}, error), tags []string) ([]byte, error) {
+	start := l.clock.Now()
+	value, err := fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch data: %w", err)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMarshalJSON, err)
+	}
+
+	now := l.clock.Now()
+	env := envelope{Value: data, Delta: now.Sub(start).Milliseconds()}
+	if ttl > 0 {
+		env.ExpiresAt = now.Add(ttl).UnixMilli()
+	}
+
+	raw, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMarshalJSON, err)
+	}
+
+	if err := l.cache.Set(ctx, key, raw, ttl); err != nil {
+		log.Printf("⚠️  Failed to cache data for key %s: %v", key, err)
+	} else {
+		l.tag(ctx, key, ttl, tags)
+		l.evict(ctx, key)
+	}
+	l.storeLocal(key, data, ttl)
+
+	return data, nil
+}
+
+// waitFor polls Redis for a value another instance is fetching
+func (l *Loader) waitFor(ctx context.Context, key string) ([]byte, bool) {
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	timeout := time.NewTimer(l.opts.LockWait)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-timeout.C:
+			return nil, false
+		case <-ticker.C:
+		}
+
+		cached, err := l.cache.Get(ctx, key)
+		if err != nil {
+			continue
+		}
+		if env, ok := decodeEnvelope(cached); ok {
+			return env.Value, true
+		}
+		return []byte(cached), true
+	}
+}
+
+// shouldRefresh decides whether a hit refreshes early (XFetch):
+// now + delta·beta·(−ln rand) ≥ expiry, so slow-to-fetch entries and entries
+// close to expiry refresh more often
+func (l *Loader) shouldRefresh(env envelope) bool {
+	if l.opts.EarlyRefreshBeta <= 0 || env.ExpiresAt == 0 {
+		return false
+	}
+
+	delta := float64(env.Delta) * l.opts.EarlyRefreshBeta * -math.Log(1-l.random())
+	return l.clock.Now().UnixMilli()+int64(delta) >= env.ExpiresAt
+}
+
+// tag registers key under tags (failures only cost an early expiry)
+func (l *Loader) tag(ctx context.Context, key string, ttl time.Duration, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	tagger, ok := l.cache.(Tagger)
+	if !ok {
+		log.Printf("⚠️  Cache %T does not support tags, key %s is untagged", l.cache, key)
+		return
+	}
+	if err := tagger.Tag(ctx, key, ttl, tags...); err != nil {
+		log.Printf("⚠️  Failed to tag cache key %s: %v", key, err)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Local tier
+// ----------------------------------------------------------------------------
+
+// storeLocal keeps data in the local tier for at most ttl (0 = tier TTL)
+func (l *Loader) storeLocal(key string, data []byte, ttl time.Duration) {
+	if l.local != nil {
+		l.local.set(key, data, ttl)
+	}
+}
+
+// evict drops keys from the local tier here and on other instances
+func (l *Loader) evict(ctx context.Context, keys ...string) {
+	if l.local == nil {
+		return
+	}
+	l.local.delete(keys...)
+
+	if l.opts.Bus == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := l.opts.Bus.Publish(ctx, l.id+" "+key); err != nil {
+			log.Printf("⚠️  Failed to publish cache invalidation for key %s: %v", key, err)
+		}
+	}
+}
+
+// onInvalidate drops a key another instance changed
+func (l *Loader) onInvalidate(msg string) {
+	id, key, ok := strings.Cut(msg, " ")
+	if !ok || id == l.id {
+		return
+	}
+	l.local.delete(key)
+}
+
+// ----------------------------------------------------------------------------
+// Envelope
+// ----------------------------------------------------------------------------
+
+// envelope wraps a cached value with the metadata early refresh needs
+type envelope struct {
+	Value     json.RawMessage `json:"$v"`
+	Delta     int64           `json:"$d"` // Fetch duration in milliseconds
+	ExpiresAt int64           `json:"$x"` // Unix milliseconds (0 = never)
+}
+
+// decodeEnvelope parses data as an envelope (false for plain JSON values)
+func decodeEnvelope(data string) (envelope, bool) {
+	if !strings.HasPrefix(data, `{"$v":`) {
+		return envelope{}, false
+	}
+
+	var env envelope
+	if err := json.Unmarshal([]byte(data), &env); err != nil || len(env.Value) == 0 {
+		return envelope{}, false
+	}
+	return env, true
+}
+
+// remaining returns how long the entry has left (0 = no expiry)
+func (e envelope) remaining(now time.Time) time.Duration {
+	if e.ExpiresAt == 0 {
+		return 0
+	}
+	return time.UnixMilli(e.ExpiresAt).Sub(now)
+}
+
+// ----------------------------------------------------------------------------
+// Singleflight
+// ----------------------------------------------------------------------------
+
+// flightCall is one in-progress fetch
+type flightCall struct {
+	wg   sync.WaitGroup
+	data []byte
+	err  error
+}
+
+// flightGroup deduplicates concurrent calls per key
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// do runs fn once per key at a time; callers arriving meanwhile get its result
+func (g *flightGroup) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.data, call.err
+	}
+
+	call := &flightCall{err: errFetchPanicked}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		call.wg.Done()
+	}()
+
+	call.data, call.err = fn()
+	return call.data, call.err
+}
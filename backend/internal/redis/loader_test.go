@@ -0,0 +1,207 @@
+package redis
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type shelter struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestLoader(t *testing.T, cache Cache, opts LoaderOptions) *Loader {
+	t.Helper()
+	l, err := NewLoader(cache, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestLoaderDeduplicatesConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLoader(t, NewMemoryCache(nil), LoaderOptions{})
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) ([]shelter, error) {
+		fetches.Add(1)
+		<-release
+		return []shelter{{ID: 1, Name: "Gym"}}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			list, err := Load(ctx, l, "shelters", time.Minute, fetch)
+			if err != nil || len(list) != 1 {
+				t.Errorf("Load = %v, %v", list, err)
+			}
+		}()
+	}
+
+	// Callers either join the flight or find the value it cached
+	for fetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetch called %d times, want 1", n)
+	}
+}
+
+func TestLoaderEarlyRefresh(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := newTestLoader(t, NewMemoryCache(clk), LoaderOptions{EarlyRefreshBeta: 1, Clock: clk})
+
+	fetches := 0
+	fetch := func(ctx context.Context) (shelter, error) {
+		fetches++
+		clk.Advance(time.Second) // The query takes 1s
+		return shelter{ID: fetches}, nil
+	}
+
+	if _, err := Load(ctx, l, "shelter:1", 10*time.Second, fetch); err != nil {
+		t.Fatal(err)
+	}
+	clk.Advance(7 * time.Second) // 3s left
+
+	// −ln(1−r) = 1: 1s of headroom is not enough to refresh
+	l.random = func() float64 { return 1 - math.Exp(-1) }
+	if s, _ := Load(ctx, l, "shelter:1", 10*time.Second, fetch); s.ID != 1 || fetches != 1 {
+		t.Fatalf("refreshed too early: id=%d fetches=%d", s.ID, fetches)
+	}
+
+	// −ln(1−r) = 4: 4s of headroom reaches the expiry
+	l.random = func() float64 { return 1 - math.Exp(-4) }
+	if s, _ := Load(ctx, l, "shelter:1", 10*time.Second, fetch); s.ID != 2 || fetches != 2 {
+		t.Fatalf("did not refresh early: id=%d fetches=%d", s.ID, fetches)
+	}
+}
+
+func TestLoaderLock(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(nil)
+	l := newTestLoader(t, cache, LoaderOptions{Lock: true, LockWait: 10 * time.Millisecond, EarlyRefreshBeta: 1e9})
+
+	fetches := 0
+	fetch := func(ctx context.Context) (shelter, error) {
+		fetches++
+		return shelter{ID: fetches}, nil
+	}
+
+	// Another instance holds the lock while refreshing: serve the stale value
+	if _, err := Load(ctx, l, "shelter:1", time.Minute, fetch); err != nil {
+		t.Fatal(err)
+	}
+	unlock, ok, _ := cache.TryLock(ctx, "shelter:1"+lockSuffix, time.Minute)
+	if !ok {
+		t.Fatal("lock not acquired")
+	}
+	l.random = func() float64 { return 0.5 }
+	if s, _ := Load(ctx, l, "shelter:1", time.Minute, fetch); s.ID != 1 || fetches != 1 {
+		t.Errorf("lock holder ignored: id=%d fetches=%d", s.ID, fetches)
+	}
+
+	unlock()
+
+	// Nothing to serve and the holder is too slow: fetch after LockWait
+	unlock, ok, _ = cache.TryLock(ctx, "shelter:2"+lockSuffix, time.Minute)
+	if !ok {
+		t.Fatal("lock not acquired")
+	}
+	defer unlock()
+	if _, err := Load(ctx, l, "shelter:2", time.Minute, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 2 {
+		t.Errorf("fetch called %d times, want 2", fetches)
+	}
+}
+
+func TestLoaderLocalTierInvalidation(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+
+	newInstance := func() *Loader {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		return newTestLoader(t, NewRedisCache(client, "test"), LoaderOptions{
+			LocalSize: 10,
+			Bus:       NewRedisBus(client, DefaultInvalidationChannel),
+		})
+	}
+	a, b := newInstance(), newInstance()
+
+	version := 1
+	fetch := func(ctx context.Context) (shelter, error) {
+		return shelter{ID: version}, nil
+	}
+
+	if s, _ := Load(ctx, b, "shelter:1", time.Minute, fetch); s.ID != 1 {
+		t.Fatalf("id = %d, want 1", s.ID)
+	}
+
+	// Served from b's local tier even though Redis is gone
+	server.FlushAll()
+	if s, err := Load(ctx, b, "shelter:1", time.Minute, fetch); err != nil || s.ID != 1 {
+		t.Fatalf("local tier miss: %+v, %v", s, err)
+	}
+
+	// a changes the key: b drops its copy
+	version = 2
+	if err := a.Invalidate(ctx, "shelter:1"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := b.local.get("shelter:1"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("invalidation not received")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if s, _ := Load(ctx, b, "shelter:1", time.Minute, fetch); s.ID != 2 {
+		t.Errorf("id = %d, want 2", s.ID)
+	}
+}
+
+func TestLocalCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := newLocalCache(2, time.Minute, clk)
+
+	c.set("a", []byte("1"), 0)
+	c.set("b", []byte("2"), 0)
+	c.get("a")
+	c.set("c", []byte("3"), 0)
+
+	if _, ok := c.get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Error("a was used recently and should be kept")
+	}
+
+	clk.Advance(time.Minute)
+	if _, ok := c.get("c"); ok {
+		t.Error("c should have expired")
+	}
+}
@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+)
+
+// ============================================================================
+// IN-PROCESS LRU TIER
+// ============================================================================
+// localCache keeps the hottest loader entries in memory in front of Redis.
+// Entries live at most ttl; other instances drop their copies through the
+// loader's invalidation bus.
+
+// localEntry is one cached value (the raw JSON stored in Redis)
+type localEntry struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+// localCache is a size-bounded LRU with per-entry expiry
+type localCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	clock   clock.Clock
+	order   *list.List // front = most recently used
+	entries map[string]*list.Element
+}
+
+// newLocalCache creates an LRU holding at most size entries for ttl each
+func newLocalCache(size int, ttl time.Duration, clk clock.Clock) *localCache {
+	return &localCache{
+		size:    size,
+		ttl:     ttl,
+		clock:   clock.OrSystem(clk),
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the live value at key
+func (c *localCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := el.Value.(*localEntry)
+	if !c.clock.Now().Before(entry.expiresAt) {
+		c.removeElement(el)
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	return entry.data, true
+}
+
+// set stores data at key, evicting the least recently used entry when full
+// ttl caps the entry lifetime below the tier's own ttl (0 = tier ttl)
+func (c *localCache) set(key string, data []byte, ttl time.Duration) {
+	if ttl <= 0 || ttl > c.ttl {
+		ttl = c.ttl
+	}
+	expiresAt := c.clock.Now().Add(ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*localEntry)
+		entry.data = data
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&localEntry{key: key, data: data, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// delete drops keys
+func (c *localCache) delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := c.entries[key]; ok {
+			c.removeElement(el)
+		}
+	}
+}
+
+// removeElement unlinks el (caller holds the lock)
+func (c *localCache) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*localEntry).key)
+}
@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// DISTRIBUTED LOCK
+// ============================================================================
+// A lock is a key set with NX holding a random token; only the holder of the
+// token may release it, and the TTL frees it if the holder dies.
+
+// Locker acquires short-lived exclusive locks on keys
+type Locker interface {
+	// TryLock acquires the lock at key without waiting
+	// ok is false when someone else holds it; release is only set when ok.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (release func(), ok bool, err error)
+}
+
+// luaUnlock deletes the lock only if it still holds our token
+const luaUnlock = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`
+
+var unlockScript = redis.NewScript(luaUnlock)
+
+// newLockToken returns a random token identifying one lock holder
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// TryLock implements Locker with SET NX PX
+func (c *RedisCache) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	full := c.key(key)
+	ok, err := c.client.SetNX(ctx, full, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	release := func() {
+		// Release even if the caller's context is already cancelled
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		unlockScript.Run(ctx, c.client, []string{full}, token)
+	}
+	return release, true, nil
+}
+
+// TryLock implements Locker in memory
+func (c *MemoryCache) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	if c.entry(key) != nil {
+		return nil, false, nil
+	}
+	c.store.entries[c.prefix+key] = &memoryEntry{str: &token, expiresAt: c.store.clock.Now().Add(ttl)}
+
+	release := func() {
+		c.store.mu.Lock()
+		defer c.store.mu.Unlock()
+
+		if e := c.entry(key); e != nil && e.str != nil && *e.str == token {
+			delete(c.store.entries, c.prefix+key)
+		}
+	}
+	return release, true, nil
+}
@@ -0,0 +1,612 @@
+package redis
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+)
+
+// ============================================================================
+// IN-MEMORY CACHE (tests and local development)
+// ============================================================================
+// MemoryCache follows Redis semantics closely enough to stand in for
+// RedisCache in tests: values are stored as strings, expired keys vanish on
+// access, and using a key as the wrong type returns ErrWrongType.
+
+// memoryEntry is one key of any type
+type memoryEntry struct {
+	str       *string
+	hash      map[string]string
+	list      []string
+	set       map[string]struct{}
+	expiresAt time.Time // zero = no expiry
+}
+
+// memoryStore is shared by all prefixed views of a MemoryCache
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	clock   clock.Clock
+}
+
+// MemoryCache implements Cache in memory
+type MemoryCache struct {
+	store  *memoryStore
+	prefix string
+}
+
+// NewMemoryCache creates an empty in-memory cache
+// clk drives key expiry (nil = clock.System)
+func NewMemoryCache(clk clock.Clock) *MemoryCache {
+	return &MemoryCache{store: &memoryStore{
+		entries: make(map[string]*memoryEntry),
+		clock:   clock.OrSystem(clk),
+	}}
+}
+
+// WithPrefix returns a view of the cache whose keys live under prefix
+func (c *MemoryCache) WithPrefix(prefix string) Cache {
+	return &MemoryCache{store: c.store, prefix: joinPrefix(c.prefix, prefix)}
+}
+
+// entry returns the live entry at key (caller holds the lock)
+func (c *MemoryCache) entry(key string) *memoryEntry {
+	full := c.prefix + key
+	e, ok := c.store.entries[full]
+	if !ok {
+		return nil
+	}
+	if !e.expiresAt.IsZero() && !c.store.clock.Now().Before(e.expiresAt) {
+		delete(c.store.entries, full)
+		return nil
+	}
+	return e
+}
+
+// entryOf returns the entry at key if it has the type checked by is,
+// creating it with init when missing (caller holds the lock)
+func (c *MemoryCache) entryOf(key string, is func(*memoryEntry) bool, init func() *memoryEntry) (*memoryEntry, error) {
+	e := c.entry(key)
+	if e == nil {
+		if init == nil {
+			return nil, nil
+		}
+		e = init()
+		c.store.entries[c.prefix+key] = e
+		return e, nil
+	}
+	if !is(e) {
+		return nil, ErrWrongType
+	}
+	return e, nil
+}
+
+func isString(e *memoryEntry) bool { return e.str != nil }
+func isHash(e *memoryEntry) bool   { return e.hash != nil }
+func isList(e *memoryEntry) bool   { return e.list != nil }
+func isSet(e *memoryEntry) bool    { return e.set != nil }
+
+// dropIfEmpty deletes collections left empty, like Redis does
+func (c *MemoryCache) dropIfEmpty(key string, e *memoryEntry) {
+	if len(e.hash) == 0 && len(e.list) == 0 && len(e.set) == 0 && e.str == nil {
+		delete(c.store.entries, c.prefix+key)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Strings
+// ----------------------------------------------------------------------------
+
+func (c *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isString, nil)
+	if err != nil {
+		return "", err
+	}
+	if e == nil {
+		return "", ErrNotFound
+	}
+	return *e.str, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	str, err := formatValue(value)
+	if err != nil {
+		return err
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e := &memoryEntry{str: &str}
+	if ttl > 0 {
+		e.expiresAt = c.store.clock.Now().Add(ttl)
+	}
+	c.store.entries[c.prefix+key] = e
+
+	return nil
+}
+
+func (c *MemoryCache) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isString, func() *memoryEntry {
+		zero := "0"
+		return &memoryEntry{str: &zero}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.ParseInt(*e.str, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("value is not an integer: %w", err)
+	}
+
+	n += value
+	str := strconv.FormatInt(n, 10)
+	e.str = &str
+
+	return n, nil
+}
+
+// ----------------------------------------------------------------------------
+// Keys
+// ----------------------------------------------------------------------------
+
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.store.entries, c.prefix+key)
+	}
+	return nil
+}
+
+func (c *MemoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	return c.entry(key) != nil, nil
+}
+
+func (c *MemoryCache) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e := c.entry(key)
+	if e == nil {
+		return nil
+	}
+	if ttl <= 0 {
+		delete(c.store.entries, c.prefix+key)
+		return nil
+	}
+
+	e.expiresAt = c.store.clock.Now().Add(ttl)
+	return nil
+}
+
+func (c *MemoryCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e := c.entry(key)
+	switch {
+	case e == nil:
+		return -2, nil
+	case e.expiresAt.IsZero():
+		return -1, nil
+	}
+
+	// Redis reports whole seconds
+	return e.expiresAt.Sub(c.store.clock.Now()).Truncate(time.Second), nil
+}
+
+// Keys lists keys matching the glob pattern, sorted
+func (c *MemoryCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	re, err := globToRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	keys := []string{}
+	for full := range c.store.entries {
+		key, ok := strings.CutPrefix(full, c.prefix)
+		if !ok || c.entry(key) == nil || !re.MatchString(key) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+func (c *MemoryCache) DeletePattern(ctx context.Context, pattern string) error {
+	keys, err := c.Keys(ctx, pattern)
+	if err != nil {
+		return err
+	}
+	return c.Delete(ctx, keys...)
+}
+
+// ----------------------------------------------------------------------------
+// Hashes
+// ----------------------------------------------------------------------------
+
+func (c *MemoryCache) HSet(ctx context.Context, key, field string, value interface{}) error {
+	str, err := formatValue(value)
+	if err != nil {
+		return err
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isHash, func() *memoryEntry {
+		return &memoryEntry{hash: make(map[string]string)}
+	})
+	if err != nil {
+		return err
+	}
+
+	e.hash[field] = str
+	return nil
+}
+
+func (c *MemoryCache) HGet(ctx context.Context, key, field string) (string, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isHash, nil)
+	if err != nil {
+		return "", err
+	}
+	if e == nil {
+		return "", ErrNotFound
+	}
+
+	val, ok := e.hash[field]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return val, nil
+}
+
+func (c *MemoryCache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isHash, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make(map[string]string)
+	if e != nil {
+		for field, val := range e.hash {
+			all[field] = val
+		}
+	}
+	return all, nil
+}
+
+func (c *MemoryCache) HDel(ctx context.Context, key string, fields ...string) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isHash, nil)
+	if err != nil || e == nil {
+		return err
+	}
+
+	for _, field := range fields {
+		delete(e.hash, field)
+	}
+	c.dropIfEmpty(key, e)
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Lists
+// ----------------------------------------------------------------------------
+
+func (c *MemoryCache) LPush(ctx context.Context, key string, values ...interface{}) error {
+	return c.push(key, values, true)
+}
+
+func (c *MemoryCache) RPush(ctx context.Context, key string, values ...interface{}) error {
+	return c.push(key, values, false)
+}
+
+// push adds values to the head (LPUSH order) or the tail of the list
+func (c *MemoryCache) push(key string, values []interface{}, head bool) error {
+	strs, err := formatValues(values)
+	if err != nil {
+		return err
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isList, func() *memoryEntry {
+		return &memoryEntry{list: []string{}}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, s := range strs {
+		if head {
+			e.list = append([]string{s}, e.list...)
+		} else {
+			e.list = append(e.list, s)
+		}
+	}
+	return nil
+}
+
+func (c *MemoryCache) LPop(ctx context.Context, key string) (string, error) {
+	return c.pop(key, true)
+}
+
+func (c *MemoryCache) RPop(ctx context.Context, key string) (string, error) {
+	return c.pop(key, false)
+}
+
+// pop removes a value from the head or the tail of the list
+func (c *MemoryCache) pop(key string, head bool) (string, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isList, nil)
+	if err != nil {
+		return "", err
+	}
+	if e == nil || len(e.list) == 0 {
+		return "", ErrNotFound
+	}
+
+	var val string
+	if head {
+		val, e.list = e.list[0], e.list[1:]
+	} else {
+		val, e.list = e.list[len(e.list)-1], e.list[:len(e.list)-1]
+	}
+	c.dropIfEmpty(key, e)
+
+	return val, nil
+}
+
+// LRange returns list elements between start and stop (inclusive, negative = from the end)
+func (c *MemoryCache) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isList, nil)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return []string{}, nil
+	}
+
+	n := int64(len(e.list))
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop {
+		return []string{}, nil
+	}
+
+	return append([]string{}, e.list[start:stop+1]...), nil
+}
+
+func (c *MemoryCache) LLen(ctx context.Context, key string) (int64, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isList, nil)
+	if err != nil || e == nil {
+		return 0, err
+	}
+	return int64(len(e.list)), nil
+}
+
+// ----------------------------------------------------------------------------
+// Sets
+// ----------------------------------------------------------------------------
+
+func (c *MemoryCache) SAdd(ctx context.Context, key string, members ...interface{}) error {
+	strs, err := formatValues(members)
+	if err != nil {
+		return err
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isSet, func() *memoryEntry {
+		return &memoryEntry{set: make(map[string]struct{})}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, s := range strs {
+		e.set[s] = struct{}{}
+	}
+	return nil
+}
+
+// SMembers returns the members of the set, sorted
+func (c *MemoryCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isSet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []string{}
+	if e != nil {
+		for member := range e.set {
+			members = append(members, member)
+		}
+	}
+	sort.Strings(members)
+
+	return members, nil
+}
+
+func (c *MemoryCache) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	str, err := formatValue(member)
+	if err != nil {
+		return false, err
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isSet, nil)
+	if err != nil || e == nil {
+		return false, err
+	}
+
+	_, ok := e.set[str]
+	return ok, nil
+}
+
+func (c *MemoryCache) SRem(ctx context.Context, key string, members ...interface{}) error {
+	strs, err := formatValues(members)
+	if err != nil {
+		return err
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	e, err := c.entryOf(key, isSet, nil)
+	if err != nil || e == nil {
+		return err
+	}
+
+	for _, s := range strs {
+		delete(e.set, s)
+	}
+	c.dropIfEmpty(key, e)
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Helpers
+// ----------------------------------------------------------------------------
+
+// formatValue converts value to the string Redis would store
+func formatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return strconv.FormatInt(v.Nanoseconds(), 10), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		return string(b), err
+	}
+	return "", fmt.Errorf("can't marshal %T (implement encoding.BinaryMarshaler)", value)
+}
+
+// formatValues converts every value with formatValue
+func formatValues(values []interface{}) ([]string, error) {
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		s, err := formatValue(value)
+		if err != nil {
+			return nil, err
+		}
+		strs = append(strs, s)
+	}
+	return strs, nil
+}
+
+// globToRegexp compiles a Redis glob pattern (*, ?, [...], \ escapes)
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "^") {
+				class = "^" + regexp.QuoteMeta(class[1:])
+			} else {
+				class = regexp.QuoteMeta(class)
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\-`, "-") + "]")
+			i += end
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -2,10 +2,10 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/config"
@@ -13,13 +13,14 @@ import (
 )
 
 var (
-	Client *redis.Client
+	Client redis.UniversalClient
 	ctx    = context.Background()
 
 	// Common errors
-	ErrNotFound      = errors.New("key not found in cache")
-	ErrMarshalJSON   = errors.New("failed to marshal data to JSON")
-	ErrUnmarshalJSON = errors.New("failed to unmarshal JSON data")
+	ErrNotFound       = errors.New("key not found in cache")
+	ErrMarshalJSON    = errors.New("failed to marshal data to JSON")
+	ErrUnmarshalJSON  = errors.New("failed to unmarshal JSON data")
+	ErrNotInitialized = errors.New("Redis client not initialized")
 )
 
 // CacheConfig holds cache-specific configuration
@@ -35,25 +36,20 @@ var cacheConfig = CacheConfig{
 
 // InitRedis initializes Redis connection with configuration
 func InitRedis() error {
-	// Check if Redis URL is configured
-	if config.Cfg.Redis.URL == "" {
+	// Check if Redis is configured
+	cfg := config.Cfg.Redis
+	if cfg.URL == "" && !cfg.IsSentinel() && !cfg.IsCluster() {
 		log.Println("⚠️  REDIS_URL not set, Redis features will be disabled")
 		return fmt.Errorf("Redis URL not configured")
 	}
 
-	// Parse Redis URL
-	opt, err := redis.ParseURL(config.Cfg.Redis.URL)
+	// Create Redis client (single node, Sentinel or Cluster)
+	client, err := NewUniversalClient(cfg)
 	if err != nil {
-		return fmt.Errorf("❌ Invalid REDIS_URL: %w", err)
+		return fmt.Errorf("❌ Invalid Redis configuration: %w", err)
 	}
-
-	// Override with config if provided
-	if config.Cfg.Redis.PoolSize > 0 {
-		opt.PoolSize = config.Cfg.Redis.PoolSize
-	}
-
-	// Create Redis client
-	Client = redis.NewClient(opt)
+	Client = client
+	SetDefault(NewRedisCache(client, ""))
 
 	// Test connection with timeout
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -63,17 +59,52 @@ func InitRedis() error {
 		return fmt.Errorf("❌ Cannot connect to Redis: %w", err)
 	}
 
+	// Stampede protection for GetOrSetJSON
+	loader, err := newDefaultLoader(client, Default())
+	if err != nil {
+		return fmt.Errorf("❌ Cannot set up cache loader: %w", err)
+	}
+	SetDefaultLoader(loader)
+
 	// Log connection info
 	log.Println("✅ Redis connected successfully!")
-	log.Printf("   Host: %s:%s", config.Cfg.Redis.Host, config.Cfg.Redis.Port)
-	log.Printf("   DB: %d", config.Cfg.Redis.DB)
-	log.Printf("   Pool Size: %d", opt.PoolSize)
+	switch {
+	case cfg.IsCluster():
+		log.Printf("   Cluster: %d seed nodes", len(cfg.ClusterAddrs))
+	case cfg.IsSentinel():
+		log.Printf("   Sentinel master: %s", cfg.SentinelMaster)
+	default:
+		log.Printf("   Host: %s:%s", cfg.Host, cfg.Port)
+	}
+	log.Printf("   DB: %d", cfg.DB)
+	log.Printf("   Pool Size: %d", cfg.PoolSize)
 
 	return nil
 }
 
+// newDefaultLoader creates the GetOrSetJSON loader from config.Cfg.Cache
+func newDefaultLoader(client redis.UniversalClient, cache Cache) (*Loader, error) {
+	cfg := config.Cfg.Cache
+
+	opts := LoaderOptions{
+		Lock:             cfg.LockEnabled,
+		LockTTL:          cfg.LockTTL,
+		LockWait:         cfg.LockWait,
+		EarlyRefreshBeta: cfg.EarlyRefreshBeta,
+		LocalSize:        cfg.LocalSize,
+		LocalTTL:         cfg.LocalTTL,
+	}
+	if cfg.LocalSize > 0 {
+		opts.Bus = NewRedisBus(client, cfg.InvalidationChannel)
+	}
+
+	return NewLoader(cache, opts)
+}
+
 // CloseRedis gracefully closes Redis connection
 func CloseRedis() error {
+	SetDefaultLoader(nil) // Stops the invalidation subscription
+
 	if Client != nil {
 		if err := Client.Close(); err != nil {
 			return fmt.Errorf("failed to close Redis connection: %w", err)
@@ -96,87 +127,151 @@ func IsConnected() bool {
 }
 
 // ============================================================================
-// Basic Cache Operations
+// Global Cache (thin wrappers over Default)
 // ============================================================================
+// New code should take a Cache and pass the request context; these helpers
+// keep the old package-level API working on top of the default cache.
 
-// Get retrieves a value from cache as string
-func Get(key string) (string, error) {
-	if Client == nil {
-		return "", fmt.Errorf("Redis client not initialized")
+var (
+	defaultMu     sync.RWMutex
+	defaultCache  Cache
+	defaultLoader *Loader
+)
+
+// Default returns the cache used by the global helpers (nil before InitRedis)
+func Default() Cache {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	return defaultCache
+}
+
+// SetDefault replaces the cache used by the global helpers (e.g. a MemoryCache in tests)
+// GetOrSetJSON gets a singleflight-only loader on it until SetDefaultLoader.
+func SetDefault(c Cache) {
+	var loader *Loader
+	if c != nil {
+		loader, _ = NewLoader(c, LoaderOptions{}) // Cannot fail without lock or bus
 	}
 
-	val, err := Client.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return "", ErrNotFound
+	defaultMu.Lock()
+	defaultCache = c
+	defaultMu.Unlock()
+
+	SetDefaultLoader(loader)
+}
+
+// DefaultLoader returns the loader used by GetOrSetJSON (nil before InitRedis)
+func DefaultLoader() *Loader {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	return defaultLoader
+}
+
+// SetDefaultLoader replaces the loader used by GetOrSetJSON, closing the previous one
+func SetDefaultLoader(l *Loader) {
+	defaultMu.Lock()
+	previous := defaultLoader
+	defaultLoader = l
+	defaultMu.Unlock()
+
+	if previous != nil && previous != l {
+		previous.Close()
 	}
-	if err != nil {
-		return "", fmt.Errorf("failed to get key %s: %w", key, err)
+}
+
+// cache returns the default cache or ErrNotInitialized
+func cache() (Cache, error) {
+	c := Default()
+	if c == nil {
+		return nil, ErrNotInitialized
 	}
+	return c, nil
+}
 
-	return val, nil
+// loader returns the default loader or ErrNotInitialized
+func loader() (*Loader, error) {
+	l := DefaultLoader()
+	if l == nil {
+		return nil, ErrNotInitialized
+	}
+	return l, nil
+}
+
+// ============================================================================
+// Basic Cache Operations
+// ============================================================================
+
+// Get retrieves a value from cache as string
+func Get(key string) (string, error) {
+	c, err := cache()
+	if err != nil {
+		return "", err
+	}
+	return c.Get(ctx, key)
 }
 
 // Set stores a string value in cache
 func Set(key string, value interface{}, expiration time.Duration) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return err
 	}
-
-	return Client.Set(ctx, key, value, expiration).Err()
+	if err := c.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+	evictLocal(key)
+	return nil
 }
 
 // Delete removes a key from cache
 func Delete(key string) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
-	}
-
-	return Client.Del(ctx, key).Err()
+	return DeleteMultiple(key)
 }
 
-// DeleteMultiple removes multiple keys from cache
+// DeleteMultiple removes multiple keys from cache (and from every local tier)
 func DeleteMultiple(keys ...string) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
+	l, err := loader()
+	if err != nil {
+		return err
 	}
+	return l.Invalidate(ctx, keys...)
+}
 
-	if len(keys) == 0 {
-		return nil
+// evictLocal drops keys rewritten through the global helpers from the
+// default loader's local tier
+func evictLocal(keys ...string) {
+	if l := DefaultLoader(); l != nil {
+		l.evict(ctx, keys...)
 	}
-
-	return Client.Del(ctx, keys...).Err()
 }
 
 // Exists checks if a key exists in cache
 func Exists(key string) (bool, error) {
-	if Client == nil {
-		return false, fmt.Errorf("Redis client not initialized")
-	}
-
-	result, err := Client.Exists(ctx, key).Result()
+	c, err := cache()
 	if err != nil {
 		return false, err
 	}
-
-	return result > 0, nil
+	return c.Exists(ctx, key)
 }
 
 // Expire sets expiration time for a key
 func Expire(key string, expiration time.Duration) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return err
 	}
-
-	return Client.Expire(ctx, key, expiration).Err()
+	return c.Expire(ctx, key, expiration)
 }
 
 // TTL returns the remaining time to live of a key
 func TTL(key string) (time.Duration, error) {
-	if Client == nil {
-		return 0, fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return 0, err
 	}
-
-	return Client.TTL(ctx, key).Result()
+	return c.TTL(ctx, key)
 }
 
 // ============================================================================
@@ -185,69 +280,56 @@ func TTL(key string) (time.Duration, error) {
 
 // GetJSON retrieves and unmarshals JSON data from cache
 func GetJSON(key string, dest interface{}) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
-	}
-
-	val, err := Client.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return ErrNotFound
-	}
+	c, err := cache()
 	if err != nil {
-		return fmt.Errorf("failed to get key %s: %w", key, err)
-	}
-
-	if err := json.Unmarshal([]byte(val), dest); err != nil {
-		return fmt.Errorf("%w: %v", ErrUnmarshalJSON, err)
+		return err
 	}
-
-	return nil
+	return getJSON(ctx, c, key, dest)
 }
 
 // SetJSON marshals and stores JSON data in cache
-func SetJSON(key string, value interface{}, expiration time.Duration) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
-	}
-
-	jsonData, err := json.Marshal(value)
+// Tags register the entry for InvalidateTags.
+func SetJSON(key string, value interface{}, expiration time.Duration, tags ...string) error {
+	c, err := cache()
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrMarshalJSON, err)
+		return err
 	}
+	if err := setJSON(ctx, c, key, value, expiration); err != nil {
+		return err
+	}
+	evictLocal(key)
 
-	return Client.Set(ctx, key, jsonData, expiration).Err()
-}
-
-// GetOrSetJSON gets value from cache, or sets it if not found
-func GetOrSetJSON(key string, dest interface{}, expiration time.Duration, fetchFn func() (interface{}, error)) error {
-	// Try to get from cache
-	err := GetJSON(key, dest)
-	if err == nil {
-		return nil // Found in cache
+	if len(tags) == 0 {
+		return nil
 	}
-	if err != ErrNotFound {
-		return err // Real error
+	tagger, ok := c.(Tagger)
+	if !ok {
+		return fmt.Errorf("cache %T does not support tags", c)
 	}
+	return tagger.Tag(ctx, key, expiration, tags...)
+}
 
-	// Not in cache, fetch data
-	data, err := fetchFn()
+// GetOrSetJSON gets value from cache, or sets it if not found
+// Misses go through the default loader, so concurrent callers share one fetchFn.
+// Tags register the entry for InvalidateTags.
+func GetOrSetJSON(key string, dest interface{}, expiration time.Duration, fetchFn func() (interface{}, error), tags ...string) error {
+	l, err := loader()
 	if err != nil {
-		return fmt.Errorf("failed to fetch data: %w", err)
+		return err
 	}
 
-	// Store in cache
-	if err := SetJSON(key, data, expiration); err != nil {
-		log.Printf("⚠️  Failed to cache data for key %s: %v", key, err)
-		// Don't return error, just log it
-	}
+	return l.Load(ctx, key, expiration, dest, func(context.Context) (interface{}, error) {
+		return fetchFn()
+	}, tags...)
+}
 
-	// Marshal to dest
-	jsonData, err := json.Marshal(data)
+// InvalidateTags deletes every entry cached under tags
+func InvalidateTags(tags ...string) error {
+	l, err := loader()
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrMarshalJSON, err)
+		return err
 	}
-
-	return json.Unmarshal(jsonData, dest)
+	return l.InvalidateTags(ctx, tags...)
 }
 
 // ============================================================================
@@ -255,63 +337,23 @@ func GetOrSetJSON(key string, dest interface{}, expiration time.Duration, fetchF
 // ============================================================================
 
 // DeletePattern deletes all keys matching a pattern
+// It walks the whole keyspace with SCAN; invalidate related entries with
+// InvalidateTags instead.
 func DeletePattern(pattern string) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
-	}
-
-	var cursor uint64
-	var keys []string
-
-	for {
-		var batch []string
-		var err error
-
-		batch, cursor, err = Client.Scan(ctx, cursor, pattern, 100).Result()
-		if err != nil {
-			return fmt.Errorf("failed to scan keys: %w", err)
-		}
-
-		keys = append(keys, batch...)
-
-		if cursor == 0 {
-			break
-		}
-	}
-
-	if len(keys) > 0 {
-		return Client.Del(ctx, keys...).Err()
+	keys, err := GetKeysByPattern(pattern)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return DeleteMultiple(keys...)
 }
 
 // GetKeysByPattern retrieves all keys matching a pattern
 func GetKeysByPattern(pattern string) ([]string, error) {
-	if Client == nil {
-		return nil, fmt.Errorf("Redis client not initialized")
-	}
-
-	var cursor uint64
-	var keys []string
-
-	for {
-		var batch []string
-		var err error
-
-		batch, cursor, err = Client.Scan(ctx, cursor, pattern, 100).Result()
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan keys: %w", err)
-		}
-
-		keys = append(keys, batch...)
-
-		if cursor == 0 {
-			break
-		}
+	c, err := cache()
+	if err != nil {
+		return nil, err
 	}
-
-	return keys, nil
+	return c.Keys(ctx, pattern)
 }
 
 // ============================================================================
@@ -320,42 +362,38 @@ func GetKeysByPattern(pattern string) ([]string, error) {
 
 // HSet sets field in hash
 func HSet(key, field string, value interface{}) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return err
 	}
-
-	return Client.HSet(ctx, key, field, value).Err()
+	return c.HSet(ctx, key, field, value)
 }
 
 // HGet gets field from hash
 func HGet(key, field string) (string, error) {
-	if Client == nil {
-		return "", fmt.Errorf("Redis client not initialized")
-	}
-
-	val, err := Client.HGet(ctx, key, field).Result()
-	if err == redis.Nil {
-		return "", ErrNotFound
+	c, err := cache()
+	if err != nil {
+		return "", err
 	}
-	return val, err
+	return c.HGet(ctx, key, field)
 }
 
 // HGetAll gets all fields from hash
 func HGetAll(key string) (map[string]string, error) {
-	if Client == nil {
-		return nil, fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return nil, err
 	}
-
-	return Client.HGetAll(ctx, key).Result()
+	return c.HGetAll(ctx, key)
 }
 
 // HDel deletes field from hash
 func HDel(key string, fields ...string) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return err
 	}
-
-	return Client.HDel(ctx, key, fields...).Err()
+	return c.HDel(ctx, key, fields...)
 }
 
 // ============================================================================
@@ -364,64 +402,56 @@ func HDel(key string, fields ...string) error {
 
 // LPush pushes value to head of list
 func LPush(key string, values ...interface{}) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return err
 	}
-
-	return Client.LPush(ctx, key, values...).Err()
+	return c.LPush(ctx, key, values...)
 }
 
 // RPush pushes value to tail of list
 func RPush(key string, values ...interface{}) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return err
 	}
-
-	return Client.RPush(ctx, key, values...).Err()
+	return c.RPush(ctx, key, values...)
 }
 
 // LPop pops value from head of list
 func LPop(key string) (string, error) {
-	if Client == nil {
-		return "", fmt.Errorf("Redis client not initialized")
-	}
-
-	val, err := Client.LPop(ctx, key).Result()
-	if err == redis.Nil {
-		return "", ErrNotFound
+	c, err := cache()
+	if err != nil {
+		return "", err
 	}
-	return val, err
+	return c.LPop(ctx, key)
 }
 
 // RPop pops value from tail of list
 func RPop(key string) (string, error) {
-	if Client == nil {
-		return "", fmt.Errorf("Redis client not initialized")
-	}
-
-	val, err := Client.RPop(ctx, key).Result()
-	if err == redis.Nil {
-		return "", ErrNotFound
+	c, err := cache()
+	if err != nil {
+		return "", err
 	}
-	return val, err
+	return c.RPop(ctx, key)
 }
 
 // LRange gets range of values from list
 func LRange(key string, start, stop int64) ([]string, error) {
-	if Client == nil {
-		return nil, fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return nil, err
 	}
-
-	return Client.LRange(ctx, key, start, stop).Result()
+	return c.LRange(ctx, key, start, stop)
 }
 
 // LLen gets length of list
 func LLen(key string) (int64, error) {
-	if Client == nil {
-		return 0, fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return 0, err
 	}
-
-	return Client.LLen(ctx, key).Result()
+	return c.LLen(ctx, key)
 }
 
 // ============================================================================
@@ -430,38 +460,38 @@ func LLen(key string) (int64, error) {
 
 // SAdd adds members to set
 func SAdd(key string, members ...interface{}) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return err
 	}
-
-	return Client.SAdd(ctx, key, members...).Err()
+	return c.SAdd(ctx, key, members...)
 }
 
 // SMembers gets all members of set
 func SMembers(key string) ([]string, error) {
-	if Client == nil {
-		return nil, fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return nil, err
 	}
-
-	return Client.SMembers(ctx, key).Result()
+	return c.SMembers(ctx, key)
 }
 
 // SIsMember checks if member is in set
 func SIsMember(key string, member interface{}) (bool, error) {
-	if Client == nil {
-		return false, fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return false, err
 	}
-
-	return Client.SIsMember(ctx, key, member).Result()
+	return c.SIsMember(ctx, key, member)
 }
 
 // SRem removes members from set
 func SRem(key string, members ...interface{}) error {
-	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return err
 	}
-
-	return Client.SRem(ctx, key, members...).Err()
+	return c.SRem(ctx, key, members...)
 }
 
 // ============================================================================
@@ -470,38 +500,26 @@ func SRem(key string, members ...interface{}) error {
 
 // Incr increments counter
 func Incr(key string) (int64, error) {
-	if Client == nil {
-		return 0, fmt.Errorf("Redis client not initialized")
-	}
-
-	return Client.Incr(ctx, key).Result()
+	return IncrBy(key, 1)
 }
 
 // IncrBy increments counter by value
 func IncrBy(key string, value int64) (int64, error) {
-	if Client == nil {
-		return 0, fmt.Errorf("Redis client not initialized")
+	c, err := cache()
+	if err != nil {
+		return 0, err
 	}
-
-	return Client.IncrBy(ctx, key, value).Result()
+	return c.IncrBy(ctx, key, value)
 }
 
 // Decr decrements counter
 func Decr(key string) (int64, error) {
-	if Client == nil {
-		return 0, fmt.Errorf("Redis client not initialized")
-	}
-
-	return Client.Decr(ctx, key).Result()
+	return IncrBy(key, -1)
 }
 
 // DecrBy decrements counter by value
 func DecrBy(key string, value int64) (int64, error) {
-	if Client == nil {
-		return 0, fmt.Errorf("Redis client not initialized")
-	}
-
-	return Client.DecrBy(ctx, key, value).Result()
+	return IncrBy(key, -value)
 }
 
 // ============================================================================
@@ -511,7 +529,7 @@ func DecrBy(key string, value int64) (int64, error) {
 // FlushDB clears all keys in current database (use with caution!)
 func FlushDB() error {
 	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
+		return ErrNotInitialized
 	}
 
 	return Client.FlushDB(ctx).Err()
@@ -520,7 +538,7 @@ func FlushDB() error {
 // FlushAll clears all keys in all databases (use with extreme caution!)
 func FlushAll() error {
 	if Client == nil {
-		return fmt.Errorf("Redis client not initialized")
+		return ErrNotInitialized
 	}
 
 	return Client.FlushAll(ctx).Err()
@@ -529,7 +547,7 @@ func FlushAll() error {
 // Info returns Redis server information
 func Info(section ...string) (string, error) {
 	if Client == nil {
-		return "", fmt.Errorf("Redis client not initialized")
+		return "", ErrNotInitialized
 	}
 
 	return Client.Info(ctx, section...).Result()
@@ -538,7 +556,7 @@ func Info(section ...string) (string, error) {
 // DBSize returns number of keys in current database
 func DBSize() (int64, error) {
 	if Client == nil {
-		return 0, fmt.Errorf("Redis client not initialized")
+		return 0, ErrNotInitialized
 	}
 
 	return Client.DBSize(ctx).Result()
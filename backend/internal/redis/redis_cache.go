@@ -0,0 +1,217 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// REDIS CACHE
+// ============================================================================
+
+// RedisCache implements Cache with Redis (single node, Sentinel or Cluster)
+type RedisCache struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisCache creates a cache on client with keys under prefix ("" = none)
+func NewRedisCache(client redis.UniversalClient, prefix string) *RedisCache {
+	return &RedisCache{client: client, prefix: joinPrefix("", prefix)}
+}
+
+// WithPrefix returns a view of the cache whose keys live under prefix
+func (c *RedisCache) WithPrefix(prefix string) Cache {
+	return &RedisCache{client: c.client, prefix: joinPrefix(c.prefix, prefix)}
+}
+
+// key returns the full Redis key
+func (c *RedisCache) key(key string) string {
+	return c.prefix + key
+}
+
+// ----------------------------------------------------------------------------
+// Strings
+// ----------------------------------------------------------------------------
+
+func (c *RedisCache) Get(ctx context.Context, key string) (string, error) {
+	val, err := c.client.Get(ctx, c.key(key)).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get key %s: %w", key, err)
+	}
+	return val, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return c.client.Set(ctx, c.key(key), value, ttl).Err()
+}
+
+func (c *RedisCache) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
+	return c.client.IncrBy(ctx, c.key(key), value).Result()
+}
+
+// ----------------------------------------------------------------------------
+// Keys
+// ----------------------------------------------------------------------------
+
+// Delete removes keys, one DEL per key in a pipeline so keys living in
+// different cluster slots never fail with CROSSSLOT
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Del(ctx, c.key(key))
+		}
+		return nil
+	})
+	return err
+}
+
+func (c *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := c.client.Exists(ctx, c.key(key)).Result()
+	return n > 0, err
+}
+
+func (c *RedisCache) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return c.client.Expire(ctx, c.key(key), ttl).Err()
+}
+
+func (c *RedisCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return c.client.TTL(ctx, c.key(key)).Result()
+}
+
+// Keys lists keys matching pattern with SCAN (on every master in a cluster)
+func (c *RedisCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var mu sync.Mutex
+	var keys []string
+
+	scan := func(ctx context.Context, node redis.UniversalClient) error {
+		var cursor uint64
+		for {
+			batch, next, err := node.Scan(ctx, cursor, escapeGlob(c.prefix)+pattern, 100).Result()
+			if err != nil {
+				return fmt.Errorf("failed to scan keys: %w", err)
+			}
+
+			mu.Lock()
+			for _, key := range batch {
+				keys = append(keys, strings.TrimPrefix(key, c.prefix))
+			}
+			mu.Unlock()
+
+			cursor = next
+			if cursor == 0 {
+				return nil
+			}
+		}
+	}
+
+	if cluster, ok := c.client.(*redis.ClusterClient); ok {
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scan(ctx, node)
+		})
+		return keys, err
+	}
+
+	return keys, scan(ctx, c.client)
+}
+
+// DeletePattern deletes all keys matching pattern
+func (c *RedisCache) DeletePattern(ctx context.Context, pattern string) error {
+	keys, err := c.Keys(ctx, pattern)
+	if err != nil {
+		return err
+	}
+	return c.Delete(ctx, keys...)
+}
+
+// ----------------------------------------------------------------------------
+// Hashes
+// ----------------------------------------------------------------------------
+
+func (c *RedisCache) HSet(ctx context.Context, key, field string, value interface{}) error {
+	return c.client.HSet(ctx, c.key(key), field, value).Err()
+}
+
+func (c *RedisCache) HGet(ctx context.Context, key, field string) (string, error) {
+	val, err := c.client.HGet(ctx, c.key(key), field).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+	return val, err
+}
+
+func (c *RedisCache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return c.client.HGetAll(ctx, c.key(key)).Result()
+}
+
+func (c *RedisCache) HDel(ctx context.Context, key string, fields ...string) error {
+	return c.client.HDel(ctx, c.key(key), fields...).Err()
+}
+
+// ----------------------------------------------------------------------------
+// Lists
+// ----------------------------------------------------------------------------
+
+func (c *RedisCache) LPush(ctx context.Context, key string, values ...interface{}) error {
+	return c.client.LPush(ctx, c.key(key), values...).Err()
+}
+
+func (c *RedisCache) RPush(ctx context.Context, key string, values ...interface{}) error {
+	return c.client.RPush(ctx, c.key(key), values...).Err()
+}
+
+func (c *RedisCache) LPop(ctx context.Context, key string) (string, error) {
+	val, err := c.client.LPop(ctx, c.key(key)).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+	return val, err
+}
+
+func (c *RedisCache) RPop(ctx context.Context, key string) (string, error) {
+	val, err := c.client.RPop(ctx, c.key(key)).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+	return val, err
+}
+
+func (c *RedisCache) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return c.client.LRange(ctx, c.key(key), start, stop).Result()
+}
+
+func (c *RedisCache) LLen(ctx context.Context, key string) (int64, error) {
+	return c.client.LLen(ctx, c.key(key)).Result()
+}
+
+// ----------------------------------------------------------------------------
+// Sets
+// ----------------------------------------------------------------------------
+
+func (c *RedisCache) SAdd(ctx context.Context, key string, members ...interface{}) error {
+	return c.client.SAdd(ctx, c.key(key), members...).Err()
+}
+
+func (c *RedisCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	return c.client.SMembers(ctx, c.key(key)).Result()
+}
+
+func (c *RedisCache) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	return c.client.SIsMember(ctx, c.key(key), member).Result()
+}
+
+func (c *RedisCache) SRem(ctx context.Context, key string, members ...interface{}) error {
+	return c.client.SRem(ctx, c.key(key), members...).Err()
+}
@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
@@ -19,9 +20,10 @@ const (
 	PrefixVerification = "verification"
 	PrefixRateLimit    = "ratelimit"
 	PrefixOTP          = "otp"
-	PrefixAppointment  = "appointment"
-	PrefixDoctor       = "doctor"
-	PrefixPatient      = "patient"
+	PrefixKit          = "kit"
+	PrefixGuide        = "guide"
+	PrefixLocation     = "location"
+	PrefixTeam         = "team"
 	PrefixNotification = "notification"
 	PrefixCache        = "cache"
 )
@@ -160,65 +162,84 @@ func TwoFactorCodeKey(userID int) string {
 }
 
 // ============================================================================
-// Appointment Cache Keys
+// Kit Cache Keys
 // ============================================================================
 
-// AppointmentKey returns cache key for appointment
-func AppointmentKey(appointmentID int) string {
-	return fmt.Sprintf("%s:%d", PrefixAppointment, appointmentID)
+// KitKey returns cache key for a survival kit
+func KitKey(kitID int) string {
+	return fmt.Sprintf("%s:%d", PrefixKit, kitID)
 }
 
-// UserAppointmentsKey returns cache key for user's appointments
-func UserAppointmentsKey(userID int) string {
-	return fmt.Sprintf("%s:user:%d", PrefixAppointment, userID)
+// KitItemsKey returns cache key for the items packed in a kit
+func KitItemsKey(kitID int) string {
+	return fmt.Sprintf("%s:items:%d", PrefixKit, kitID)
 }
 
-// DoctorAppointmentsKey returns cache key for doctor's appointments
-func DoctorAppointmentsKey(doctorID int, date string) string {
-	return fmt.Sprintf("%s:doctor:%d:%s", PrefixAppointment, doctorID, date)
+// UserKitsKey returns cache key for the kits a user owns
+func UserKitsKey(userID int) string {
+	return fmt.Sprintf("%s:user:%d", PrefixKit, userID)
 }
 
-// AppointmentsByDateKey returns cache key for appointments by date
-func AppointmentsByDateKey(date string) string {
-	return fmt.Sprintf("%s:date:%s", PrefixAppointment, date)
+// SharedKitsKey returns cache key for the kits shared with a user
+func SharedKitsKey(userID int) string {
+	return fmt.Sprintf("%s:shared:%d", PrefixKit, userID)
 }
 
 // ============================================================================
-// Doctor Cache Keys
+// Guide Cache Keys
 // ============================================================================
 
-// DoctorKey returns cache key for doctor
-func DoctorKey(doctorID int) string {
-	return fmt.Sprintf("%s:%d", PrefixDoctor, doctorID)
+// GuideKey returns cache key for a survival guide
+func GuideKey(guideID int) string {
+	return fmt.Sprintf("%s:%d", PrefixGuide, guideID)
 }
 
-// DoctorScheduleKey returns cache key for doctor schedule
-func DoctorScheduleKey(doctorID int, date string) string {
-	return fmt.Sprintf("%s:schedule:%d:%s", PrefixDoctor, doctorID, date)
+// GuideBySlugKey returns cache key for guide lookup by slug
+func GuideBySlugKey(slug string) string {
+	return fmt.Sprintf("%s:slug:%s", PrefixGuide, slug)
 }
 
-// DoctorAvailabilityKey returns cache key for doctor availability
-func DoctorAvailabilityKey(doctorID int) string {
-	return fmt.Sprintf("%s:availability:%d", PrefixDoctor, doctorID)
+// GuidesByCategoryKey returns cache key for the guides in a category
+func GuidesByCategoryKey(category string) string {
+	return fmt.Sprintf("%s:category:%s", PrefixGuide, category)
 }
 
-// DoctorsBySpecialtyKey returns cache key for doctors by specialty
-func DoctorsBySpecialtyKey(specialty string) string {
-	return fmt.Sprintf("%s:specialty:%s", PrefixDoctor, specialty)
+// ============================================================================
+// Location Cache Keys
+// ============================================================================
+
+// LocationKey returns cache key for a location (shelter, water source, ...)
+func LocationKey(locationID int) string {
+	return fmt.Sprintf("%s:%d", PrefixLocation, locationID)
+}
+
+// LocationsByTypeKey returns cache key for all locations of a type
+func LocationsByTypeKey(locationType string) string {
+	return fmt.Sprintf("%s:type:%s", PrefixLocation, locationType)
+}
+
+// NearbyLocationsKey returns cache key for locations near an area (geohash cell)
+func NearbyLocationsKey(geohash, locationType string) string {
+	return fmt.Sprintf("%s:nearby:%s:%s", PrefixLocation, geohash, locationType)
 }
 
 // ============================================================================
-// Patient Cache Keys
+// Team Cache Keys
 // ============================================================================
 
-// PatientKey returns cache key for patient
-func PatientKey(patientID int) string {
-	return fmt.Sprintf("%s:%d", PrefixPatient, patientID)
+// TeamKey returns cache key for a team
+func TeamKey(teamID int) string {
+	return fmt.Sprintf("%s:%d", PrefixTeam, teamID)
+}
+
+// TeamMembersKey returns cache key for a team's members
+func TeamMembersKey(teamID int) string {
+	return fmt.Sprintf("%s:members:%d", PrefixTeam, teamID)
 }
 
-// PatientMedicalHistoryKey returns cache key for patient medical history
-func PatientMedicalHistoryKey(patientID int) string {
-	return fmt.Sprintf("%s:history:%d", PrefixPatient, patientID)
+// UserTeamsKey returns cache key for the teams a user belongs to
+func UserTeamsKey(userID int) string {
+	return fmt.Sprintf("%s:user:%d", PrefixTeam, userID)
 }
 
 // ============================================================================
@@ -264,37 +285,57 @@ func CountCacheKey(entity string) string {
 }
 
 // ============================================================================
-// Pattern Helpers
+// Cache Tags
 // ============================================================================
+// Tag every cached entry with the entities it was built from, e.g. a user's
+// kit list with UserTag(owner) and KitTag(id) of each kit in it, so changing
+// any of them drops the list. Collection tags cover lists any new row may
+// belong to.
 
-// UserPattern returns pattern for all user keys
-func UserPattern() string {
-	return fmt.Sprintf("%s:*", PrefixUser)
+// UserTag covers entries built from a user
+func UserTag(userID int) string {
+	return fmt.Sprintf("%s:%d", PrefixUser, userID)
 }
 
-// SessionPattern returns pattern for all session keys
-func SessionPattern() string {
-	return fmt.Sprintf("%s:*", PrefixSession)
+// UserSessionsTag covers a user's cached sessions
+func UserSessionsTag(userID int) string {
+	return fmt.Sprintf("%s:user:%d", PrefixSession, userID)
 }
 
-// UserSessionPattern returns pattern for specific user's session keys
-func UserSessionPattern(userID int) string {
-	return fmt.Sprintf("%s:user:%d:*", PrefixSession, userID)
+// KitTag covers entries built from a kit
+func KitTag(kitID int) string {
+	return fmt.Sprintf("%s:%d", PrefixKit, kitID)
 }
 
-// RateLimitPattern returns pattern for all rate limit keys
-func RateLimitPattern() string {
-	return fmt.Sprintf("%s:*", PrefixRateLimit)
+// GuideTag covers entries built from a guide
+func GuideTag(guideID int) string {
+	return fmt.Sprintf("%s:%d", PrefixGuide, guideID)
 }
 
-// OTPPattern returns pattern for all OTP keys
-func OTPPattern() string {
-	return fmt.Sprintf("%s:*", PrefixOTP)
+// GuidesTag covers guide lists (categories)
+func GuidesTag() string {
+	return PrefixGuide + ":all"
+}
+
+// LocationTag covers entries built from a location
+func LocationTag(locationID int) string {
+	return fmt.Sprintf("%s:%d", PrefixLocation, locationID)
+}
+
+// LocationsTag covers location lists (by type, nearby)
+func LocationsTag() string {
+	return PrefixLocation + ":all"
+}
+
+// TeamTag covers entries built from a team
+func TeamTag(teamID int) string {
+	return fmt.Sprintf("%s:%d", PrefixTeam, teamID)
 }
 
 // ============================================================================
-// Cache Invalidation Helpers
+// Cache Invalidation Hooks
 // ============================================================================
+// Call these after committing a change to the entity.
 
 // InvalidateUser invalidates all user-related cache
 func InvalidateUser(userID int) error {
@@ -302,11 +343,38 @@ func InvalidateUser(userID int) error {
 		UserKey(userID),
 		UserProfileKey(userID),
 		UserPreferencesKey(userID),
-		UserAppointmentsKey(userID),
+		UserKitsKey(userID),
+		SharedKitsKey(userID),
+		UserTeamsKey(userID),
 		UserNotificationsKey(userID),
 		UnreadNotificationsCountKey(userID),
 	}
-	return DeleteMultiple(keys...)
+	if err := DeleteMultiple(keys...); err != nil {
+		return err
+	}
+	return InvalidateTags(UserTag(userID))
+}
+
+// UserCache invalidates user caches through the package client (see
+// usecase.UserCache). Without a client there is nothing cached to drop.
+type UserCache struct{}
+
+// InvalidateUser invalidates all user-related cache
+func (UserCache) InvalidateUser(userID int) error {
+	return ignoreNotInitialized(InvalidateUser(userID))
+}
+
+// InvalidateUserSessions invalidates all sessions for a user
+func (UserCache) InvalidateUserSessions(userID int) error {
+	return ignoreNotInitialized(InvalidateUserSessions(userID))
+}
+
+// ignoreNotInitialized drops ErrNotInitialized
+func ignoreNotInitialized(err error) error {
+	if errors.Is(err, ErrNotInitialized) {
+		return nil
+	}
+	return err
 }
 
 // InvalidateSession invalidates session cache
@@ -316,28 +384,42 @@ func InvalidateSession(sessionID string) error {
 
 // InvalidateUserSessions invalidates all sessions for a user
 func InvalidateUserSessions(userID int) error {
-	return DeletePattern(UserSessionPattern(userID))
+	if err := Delete(UserSessionsKey(userID)); err != nil {
+		return err
+	}
+	return InvalidateTags(UserSessionsTag(userID))
 }
 
-// InvalidateDoctor invalidates doctor-related cache
-func InvalidateDoctor(doctorID int) error {
-	// Delete specific keys
-	keys := []string{
-		DoctorKey(doctorID),
-		DoctorAvailabilityKey(doctorID),
+// InvalidateKit invalidates a kit and every list containing it
+func InvalidateKit(kitID int) error {
+	if err := DeleteMultiple(KitKey(kitID), KitItemsKey(kitID)); err != nil {
+		return err
 	}
-	if err := DeleteMultiple(keys...); err != nil {
+	return InvalidateTags(KitTag(kitID))
+}
+
+// InvalidateGuide invalidates a guide and the guide lists
+func InvalidateGuide(guideID int) error {
+	if err := Delete(GuideKey(guideID)); err != nil {
 		return err
 	}
+	return InvalidateTags(GuideTag(guideID), GuidesTag())
+}
 
-	// Delete schedule patterns
-	pattern := fmt.Sprintf("%s:schedule:%d:*", PrefixDoctor, doctorID)
-	return DeletePattern(pattern)
+// InvalidateLocation invalidates a location and the location lists
+func InvalidateLocation(locationID int) error {
+	if err := Delete(LocationKey(locationID)); err != nil {
+		return err
+	}
+	return InvalidateTags(LocationTag(locationID), LocationsTag())
 }
 
-// InvalidateAppointment invalidates appointment-related cache
-func InvalidateAppointment(appointmentID int) error {
-	return Delete(AppointmentKey(appointmentID))
+// InvalidateTeam invalidates a team, its members and every list containing it
+func InvalidateTeam(teamID int) error {
+	if err := DeleteMultiple(TeamKey(teamID), TeamMembersKey(teamID)); err != nil {
+		return err
+	}
+	return InvalidateTags(TeamTag(teamID))
 }
 
 // ============================================================================
@@ -0,0 +1,218 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// TAG-BASED INVALIDATION
+// ============================================================================
+// Every cached entry can be registered under tags naming what it was built
+// from ("user:42", "team:7"). Invalidating a tag removes all of its members,
+// so writers never need to know which keys were derived from an entity and
+// nothing walks the keyspace with SCAN or KEYS.
+//
+// A tag is a set at "tag:{<tag>}" holding the full keys of its members; it
+// lives at least as long as its longest-lived member.
+
+// TTLTag bounds tag sets whose members never expire
+const TTLTag = TTLDayCache
+
+// Tagger groups cache entries under tags
+type Tagger interface {
+	// Tag registers key under tags for at least ttl
+	Tag(ctx context.Context, key string, ttl time.Duration, tags ...string) error
+
+	// InvalidateTags deletes every entry registered under tags and the tags
+	// themselves, returning the deleted keys
+	InvalidateTags(ctx context.Context, tags ...string) ([]string, error)
+}
+
+// tagKey returns the set holding the members of tag (hash-tagged so its
+// cluster slot depends on the tag alone)
+func tagKey(tag string) string {
+	return "tag:{" + tag + "}"
+}
+
+// tagTTL returns how long a tag set must live for a member cached for ttl
+func tagTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return TTLTag
+	}
+	return ttl
+}
+
+// ----------------------------------------------------------------------------
+// Redis
+// ----------------------------------------------------------------------------
+
+// luaTag adds a member and extends (never shortens) the tag's TTL
+// KEYS[1] = tag set, ARGV[1] = member, ARGV[2] = ttl (ms)
+const luaTag = `
+redis.call("SADD", KEYS[1], ARGV[1])
+local ttl = tonumber(ARGV[2])
+if redis.call("PTTL", KEYS[1]) < ttl then
+	redis.call("PEXPIRE", KEYS[1], ttl)
+end
+return 1
+`
+
+// luaInvalidateTags deletes the members of every tag and the tags in one
+// atomic step (single node and Sentinel only: members live in any slot)
+// KEYS = tag sets
+const luaInvalidateTags = `
+local deleted = {}
+for _, tag in ipairs(KEYS) do
+	for _, member in ipairs(redis.call("SMEMBERS", tag)) do
+		if redis.call("DEL", member) == 1 then
+			table.insert(deleted, member)
+		end
+	end
+	redis.call("DEL", tag)
+end
+return deleted
+`
+
+// luaDetachTag atomically empties a tag and returns its former members
+// KEYS[1] = tag set
+const luaDetachTag = `
+local members = redis.call("SMEMBERS", KEYS[1])
+redis.call("DEL", KEYS[1])
+return members
+`
+
+var (
+	tagScript            = redis.NewScript(luaTag)
+	invalidateTagsScript = redis.NewScript(luaInvalidateTags)
+	detachTagScript      = redis.NewScript(luaDetachTag)
+)
+
+// Tag implements Tagger
+func (c *RedisCache) Tag(ctx context.Context, key string, ttl time.Duration, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	// One script per tag: each touches a single key, so it is cluster safe
+	// (Eval, not Run: pipelines cannot fall back from EVALSHA)
+	ms := tagTTL(ttl).Milliseconds()
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, tag := range tags {
+			tagScript.Eval(ctx, pipe, []string{c.key(tagKey(tag))}, c.key(key), ms)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag key %s: %w", key, err)
+	}
+	return nil
+}
+
+// InvalidateTags implements Tagger
+// On a cluster each tag is emptied atomically and its members are deleted
+// right after, since one script cannot touch keys in other slots.
+func (c *RedisCache) InvalidateTags(ctx context.Context, tags ...string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	tagKeys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tagKeys = append(tagKeys, c.key(tagKey(tag)))
+	}
+
+	var members []string
+	if _, ok := c.client.(*redis.ClusterClient); ok {
+		for _, key := range tagKeys {
+			detached, err := detachTagScript.Run(ctx, c.client, []string{key}).StringSlice()
+			if err != nil {
+				return nil, fmt.Errorf("failed to invalidate tag %s: %w", key, err)
+			}
+			members = append(members, detached...)
+		}
+
+		_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, member := range members {
+				pipe.Del(ctx, member)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete tagged keys: %w", err)
+		}
+	} else {
+		deleted, err := invalidateTagsScript.Run(ctx, c.client, tagKeys).StringSlice()
+		if err != nil {
+			return nil, fmt.Errorf("failed to invalidate tags: %w", err)
+		}
+		members = deleted
+	}
+
+	keys := make([]string, 0, len(members))
+	for _, member := range members {
+		keys = append(keys, strings.TrimPrefix(member, c.prefix))
+	}
+	return keys, nil
+}
+
+// ----------------------------------------------------------------------------
+// Memory
+// ----------------------------------------------------------------------------
+
+// Tag implements Tagger
+func (c *MemoryCache) Tag(ctx context.Context, key string, ttl time.Duration, tags ...string) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	expiresAt := c.store.clock.Now().Add(tagTTL(ttl))
+	for _, tag := range tags {
+		e, err := c.entryOf(tagKey(tag), isSet, func() *memoryEntry {
+			return &memoryEntry{set: make(map[string]struct{})}
+		})
+		if err != nil {
+			return err
+		}
+
+		e.set[c.prefix+key] = struct{}{}
+		if e.expiresAt.Before(expiresAt) {
+			e.expiresAt = expiresAt
+		}
+	}
+	return nil
+}
+
+// InvalidateTags implements Tagger (atomic under the store lock)
+func (c *MemoryCache) InvalidateTags(ctx context.Context, tags ...string) ([]string, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	now := c.store.clock.Now()
+	var keys []string
+	for _, tag := range tags {
+		e, err := c.entryOf(tagKey(tag), isSet, nil)
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			continue
+		}
+
+		for member := range e.set {
+			entry, ok := c.store.entries[member]
+			if !ok {
+				continue
+			}
+			delete(c.store.entries, member)
+			if entry.expiresAt.IsZero() || now.Before(entry.expiresAt) {
+				keys = append(keys, strings.TrimPrefix(member, c.prefix))
+			}
+		}
+		delete(c.store.entries, c.prefix+tagKey(tag))
+	}
+	return keys, nil
+}
@@ -3,7 +3,6 @@ package router
 import (
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/handlers"
-	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -12,23 +11,8 @@ func authSetup(app *fiber.App, limiter *ratelimit.RedisLimiter) {
 
 	api := app.Group("/api/v1")
 
-	api.Post("/auth/login",
-		middleware.RedisRateLimitMiddleware(limiter, "login"),
-		handlers.Login,
-	)
-
-	api.Post("/auth/register",
-		middleware.RedisRateLimitMiddleware(limiter, "register"),
-		handlers.Register,
-	)
-
-	api.Post("/auth/forgot-password",
-		middleware.RedisRateLimitMiddleware(limiter, "password_reset"),
-		handlers.ForgotPassword,
-	)
-
-	api.Post("/auth/verify-email",
-		middleware.RedisRateLimitMiddleware(limiter, "email_verify"),
-		handlers.VerifyEmail,
-	)
+	handle(api, limiter, fiber.MethodPost, "/auth/login", handlers.Login)
+	handle(api, limiter, fiber.MethodPost, "/auth/register", handlers.Register)
+	handle(api, limiter, fiber.MethodPost, "/auth/forgot-password", handlers.ForgotPassword)
+	handle(api, limiter, fiber.MethodPost, "/auth/verify-email", handlers.VerifyEmail)
 }
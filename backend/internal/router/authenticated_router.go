@@ -8,31 +8,42 @@ import (
 )
 
 // ================= AUTHENTICATED =================
-func authenticatedSetup(app *fiber.App, limiter *ratelimit.RedisLimiter) {
+func authenticatedSetup(app *fiber.App, limiter *ratelimit.RedisLimiter, concurrency *ratelimit.ConcurrencyLimiter, overrides ratelimit.OverrideStore, ipList *ratelimit.IPList, audit *ratelimit.AuditLog) {
 	api := app.Group("/api/v1")
 
-	auth := api.Group("/",
-		middleware.RedisRateLimitMiddleware(limiter, "api"),
-	)
-
-	users := auth.Group("/users")
-	users.Get("/", handlers.ListUsers)
-	users.Get("/:id", handlers.GetUser)
-	users.Put("/:id", handlers.UpdateUser)
-	users.Delete("/:id", handlers.DeleteUser)
+	users := api.Group("/users")
+	handle(users, limiter, fiber.MethodGet, "/", middleware.RequireLeaderOrAdmin(), handlers.HandleListUsers)
+	handle(users, limiter, fiber.MethodGet, "/:id", middleware.RequireActiveAccount(), handlers.HandleGetUser)
+	handle(users, limiter, fiber.MethodPut, "/:id", middleware.RequireActiveAccount(), handlers.HandleUpdateUser)
+	handle(users, limiter, fiber.MethodDelete, "/:id", middleware.RequireLeaderOrAdmin(), handlers.HandleDeleteUser)
 
-	auth.Post("/upload",
-		middleware.RedisRateLimitMiddleware(limiter, "upload"),
+	handle(api, limiter, fiber.MethodPost, "/upload",
+		middleware.ConcurrencyLimit(concurrency, ratelimit.ActionConcurrentUpload),
 		handlers.Upload,
 	)
 
-	admin := auth.Group("/admin", middleware.RequireAdmin())
+	admin := api.Group("/admin", middleware.RequireAdmin())
+
+	handle(admin, limiter, fiber.MethodGet, "/users/search", handlers.HandleSearchUsers)
+	handle(admin, limiter, fiber.MethodPost, "/users/:id/restore", handlers.HandleRestoreUser)
 
-	newHandler := handlers.NewHandler(limiter)
-	admin.Get("/rate-limits/:identifier/:action", newHandler.GetRateLimitStatus)
-	admin.Delete("/rate-limits/:identifier/:action", newHandler.ResetRateLimit)
-	admin.Post("/rate-limits/:identifier/:action/block", newHandler.BlockUser)
-	admin.Post("/rate-limits/:identifier/:action/unblock", newHandler.UnblockUser)
-	admin.Get("/rate-limits/stats", newHandler.GetRateLimitStats)
-	admin.Get("/rate-limits/rules", newHandler.ListRules)
+	newHandler := handlers.NewHandler(limiter, overrides, ipList, audit)
+	handle(admin, limiter, fiber.MethodGet, "/rate-limits/overrides", newHandler.ListOverrides)
+	handle(admin, limiter, fiber.MethodPut, "/rate-limits/overrides/:type/:identifier/:action", newHandler.SaveOverride)
+	handle(admin, limiter, fiber.MethodDelete, "/rate-limits/overrides/:type/:identifier/:action", newHandler.DeleteOverride)
+	handle(admin, limiter, fiber.MethodGet, "/rate-limits/ip-rules", newHandler.ListIPRules)
+	handle(admin, limiter, fiber.MethodPost, "/rate-limits/ip-rules", newHandler.SaveIPRule)
+	handle(admin, limiter, fiber.MethodDelete, "/rate-limits/ip-rules/:id", newHandler.DeleteIPRule)
+	handle(admin, limiter, fiber.MethodGet, "/rate-limits/blocked", newHandler.ListBlocked)
+	handle(admin, limiter, fiber.MethodGet, "/rate-limits/top/:action", newHandler.TopIdentifiers)
+	handle(admin, limiter, fiber.MethodGet, "/rate-limits/identifiers", newHandler.SearchIdentifiers)
+	handle(admin, limiter, fiber.MethodGet, "/rate-limits/audit", newHandler.ListAudit)
+	handle(admin, limiter, fiber.MethodGet, "/rate-limits/offenders/:identifier", newHandler.GetOffender)
+	handle(admin, limiter, fiber.MethodDelete, "/rate-limits/offenders/:identifier", newHandler.PardonOffender)
+	handle(admin, limiter, fiber.MethodGet, "/rate-limits/:identifier/:action", newHandler.GetRateLimitStatus)
+	handle(admin, limiter, fiber.MethodDelete, "/rate-limits/:identifier/:action", newHandler.ResetRateLimit)
+	handle(admin, limiter, fiber.MethodPost, "/rate-limits/:identifier/:action/block", newHandler.BlockUser)
+	handle(admin, limiter, fiber.MethodPost, "/rate-limits/:identifier/:action/unblock", newHandler.UnblockUser)
+	handle(admin, limiter, fiber.MethodGet, "/rate-limits/stats", newHandler.GetRateLimitStats)
+	handle(admin, limiter, fiber.MethodGet, "/rate-limits/rules", newHandler.ListRules)
 }
@@ -0,0 +1,100 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ================= RATE LIMIT POLICIES =================
+// Route -> policies table. Keys are "METHOD /full/path" exactly as registered.
+// Every route registered through handle() gets the policies listed here.
+// handle() panics for a route without an entry, so a new route or a typo
+// in a key cannot silently drop rate limiting; an explicit nil entry
+// marks a route as deliberately unlimited.
+
+var routePolicies = map[string][]ratelimit.Policy{
+	// Load balancer probes must never be throttled
+	"GET /api/v1/health": nil,
+
+	// Auth
+	"POST /api/v1/auth/login":           {ratelimit.NewPolicy("login")},
+	"POST /api/v1/auth/register":        {ratelimit.NewPolicy("register")},
+	"POST /api/v1/auth/forgot-password": {ratelimit.NewPolicy("password_reset")},
+	"POST /api/v1/auth/verify-email":    {ratelimit.NewPolicy("email_verify")},
+
+	// Users
+	"GET /api/v1/users":        apiPolicies(1),
+	"GET /api/v1/users/:id":    apiPolicies(1),
+	"PUT /api/v1/users/:id":    apiPolicies(2),
+	"DELETE /api/v1/users/:id": apiPolicies(2),
+
+	// Upload: burst + hourly quota, and weighs heavily on the API quota
+	"POST /api/v1/upload": append(apiPolicies(10),
+		ratelimit.NewPolicy(ratelimit.ActionUploadBurst),
+		ratelimit.NewPolicy("upload"),
+	),
+
+	// Admin
+	"GET /api/v1/admin/users/search":                                       apiPolicies(2),
+	"POST /api/v1/admin/users/:id/restore":                                 apiPolicies(2),
+	"GET /api/v1/admin/rate-limits/overrides":                              apiPolicies(1),
+	"PUT /api/v1/admin/rate-limits/overrides/:type/:identifier/:action":    apiPolicies(1),
+	"DELETE /api/v1/admin/rate-limits/overrides/:type/:identifier/:action": apiPolicies(1),
+	"GET /api/v1/admin/rate-limits/ip-rules":                               apiPolicies(1),
+	"POST /api/v1/admin/rate-limits/ip-rules":                              apiPolicies(1),
+	"DELETE /api/v1/admin/rate-limits/ip-rules/:id":                        apiPolicies(1),
+	"GET /api/v1/admin/rate-limits/blocked":                                apiPolicies(1),
+	"GET /api/v1/admin/rate-limits/top/:action":                            apiPolicies(1),
+	"GET /api/v1/admin/rate-limits/identifiers":                            apiPolicies(1),
+	"GET /api/v1/admin/rate-limits/audit":                                  apiPolicies(1),
+	"GET /api/v1/admin/rate-limits/offenders/:identifier":                  apiPolicies(1),
+	"DELETE /api/v1/admin/rate-limits/offenders/:identifier":               apiPolicies(1),
+	"GET /api/v1/admin/rate-limits/:identifier/:action":                    apiPolicies(1),
+	"DELETE /api/v1/admin/rate-limits/:identifier/:action":                 apiPolicies(1),
+	"POST /api/v1/admin/rate-limits/:identifier/:action/block":             apiPolicies(1),
+	"POST /api/v1/admin/rate-limits/:identifier/:action/unblock":           apiPolicies(1),
+	"GET /api/v1/admin/rate-limits/stats":                                  apiPolicies(5),
+	"GET /api/v1/admin/rate-limits/rules":                                  apiPolicies(1),
+}
+
+// apiPolicies returns the default authenticated API policies:
+// a per-second burst limit plus the per-minute API quota charged at cost
+func apiPolicies(cost int) []ratelimit.Policy {
+	return []ratelimit.Policy{
+		ratelimit.NewPolicy(ratelimit.ActionAPIBurst),
+		ratelimit.NewPolicy("api").WithCost(cost),
+	}
+}
+
+// handle registers a route and prepends the rate limit middleware
+// declared for it in routePolicies. It panics when the route has no entry.
+func handle(r fiber.Router, limiter *ratelimit.RedisLimiter, method, path string, handlers ...fiber.Handler) {
+	key := routeKey(r, method, path)
+	policies, ok := routePolicies[key]
+	if !ok {
+		panic("router: no rate limit policies for " + key + " (add it to routePolicies)")
+	}
+	if len(policies) > 0 {
+		handlers = append([]fiber.Handler{middleware.RateLimitPolicies(limiter, policies...)}, handlers...)
+	}
+
+	r.Add(method, path, handlers...)
+}
+
+// routeKey builds the routePolicies key for a route registered on r
+func routeKey(r fiber.Router, method, path string) string {
+	prefix := ""
+	if group, ok := r.(*fiber.Group); ok {
+		prefix = group.Prefix
+	}
+
+	fullPath := strings.TrimRight(prefix, "/") + "/" + strings.TrimLeft(path, "/")
+	if len(fullPath) > 1 {
+		fullPath = strings.TrimRight(fullPath, "/")
+	}
+
+	return method + " " + fullPath
+}
@@ -3,16 +3,21 @@ package router
 import (
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
 	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/handlers"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
-func Setup(app *fiber.App, limiter *ratelimit.RedisLimiter) {
+func Setup(app *fiber.App, limiter *ratelimit.RedisLimiter, concurrency *ratelimit.ConcurrencyLimiter, overrides ratelimit.OverrideStore, ipList *ratelimit.IPList, audit *ratelimit.AuditLog, apiKeys middleware.APIKeyStore, sessions middleware.SessionStore) {
+
+	// API keys and sessions are authenticated before the rate limits charge
+	// their quota, so keys and logged-in users get their own tiers
+	app.Use("/api/v1", middleware.APIKeyAuth(apiKeys), middleware.SessionAuth(sessions))
 
 	// Check Health
-	newHandler := handlers.NewHandler(limiter)
+	newHandler := handlers.NewHandler(limiter, overrides, ipList, audit)
 	api := app.Group("/api/v1")
-	api.Get("/health", newHandler.Check)
+	handle(api, limiter, fiber.MethodGet, "/health", newHandler.Check)
 
 	authSetup(app, limiter)
-	authenticatedSetup(app, limiter)
+	authenticatedSetup(app, limiter, concurrency, overrides, ipList, audit)
 }
@@ -0,0 +1,179 @@
+package router
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/clock"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/persistence/memory"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/infrastructure/ratelimit"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/domain/user"
+	"github.com/LePhuocVuTien/SurvivalPro-Backend/internal/middleware"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// sessionStore maps raw session tokens to their users
+type sessionStore map[string]*user.User
+
+func (s sessionStore) FindUserBySessionToken(ctx context.Context, sessionToken string) (*user.User, error) {
+	if u, ok := s[sessionToken]; ok {
+		return u, nil
+	}
+	return nil, user.ErrSessionNotFound
+}
+
+func TestEveryRouteHasRateLimitPolicies(t *testing.T) {
+	app := fiber.New()
+	Setup(app, nil, nil, nil, nil, nil, memory.NewAPIKeyRepository(nil), sessionStore{})
+
+	registered := make(map[string]bool)
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue // Added by Fiber for every GET route
+		}
+		path := route.Path
+		if len(path) > 1 {
+			path = strings.TrimRight(path, "/")
+		}
+		key := route.Method + " " + path
+		registered[key] = true
+
+		if _, ok := routePolicies[key]; !ok {
+			t.Errorf("%s has no routePolicies entry", key)
+		}
+	}
+
+	// An entry that matches no route is a typo or a removed route
+	for key := range routePolicies {
+		if !registered[key] {
+			t.Errorf("routePolicies entry %s matches no registered route", key)
+		}
+	}
+}
+
+func TestHandlePanicsWithoutPolicies(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("handle did not panic for a route without policies")
+		}
+	}()
+	handle(fiber.New().Group("/api/v1"), nil, fiber.MethodGet, "/unlisted", func(c *fiber.Ctx) error { return nil })
+}
+
+func TestAPIKeyIsChargedItsOwnQuota(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	rules := []*ratelimit.RateLimitRule{
+		ratelimit.NewRule(ratelimit.ActionAPIBurst).MaxAttempts(20).Window(time.Second).BlockFor(time.Second).Build(),
+		ratelimit.NewRule("api").MaxAttempts(100).Window(time.Minute).BlockFor(time.Minute).Build(),
+		ratelimit.NewRule("api:anonymous").MaxAttempts(60).Window(time.Minute).BlockFor(time.Minute).Build(),
+		ratelimit.NewRule("api:api_key").MaxAttempts(600).Window(time.Minute).BlockFor(time.Minute).Build(),
+	}
+	limiter := ratelimit.NewRedisLimiter(client, rules, clk)
+	t.Cleanup(func() { limiter.Close() })
+
+	keys := memory.NewAPIKeyRepository(clk)
+	keys.Put(&user.APIKey{UserID: 1, KeyHash: user.HashAPIKey("sp_live"), KeyPrefix: "sp_live", Name: "ci"})
+	keys.Put(&user.APIKey{UserID: 2, KeyHash: user.HashAPIKey("sp_revoked"), KeyPrefix: "sp_revo", Name: "old"})
+	if _, err := keys.RevokeAllForUser(ctx, 2, 2, "rotated"); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	Setup(app, limiter, nil, nil, nil, nil, keys, sessionStore{})
+
+	get := func(apiKey string) (int, string) {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodGet, "/api/v1/users", nil)
+		if apiKey != "" {
+			req.Header.Set(middleware.APIKeyHeader, apiKey)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, resp.Header.Get(ratelimit.HeaderRateLimitPolicy)
+	}
+
+	// The key authenticates no user, so the route itself still answers 401
+	status, policy := get("sp_live")
+	if status != fiber.StatusUnauthorized || !strings.Contains(policy, `"api:api_key"`) {
+		t.Errorf("valid key = %d with policy %q, want the api:api_key tier", status, policy)
+	}
+	charged, err := limiter.GetStatus(ctx, ratelimit.FormatIdentifier(ratelimit.IdentifierAPIKey, "1"), "api:api_key")
+	if err != nil || charged.Count != 1 {
+		t.Errorf("key quota = %+v, %v; want 1 attempt", charged, err)
+	}
+
+	for _, apiKey := range []string{"sp_unknown", "sp_revoked"} {
+		if status, policy := get(apiKey); status != fiber.StatusUnauthorized || policy != "" {
+			t.Errorf("key %q = %d with policy %q, want 401 before rate limiting", apiKey, status, policy)
+		}
+	}
+
+	if _, policy := get(""); !strings.Contains(policy, `"api:anonymous"`) {
+		t.Errorf("anonymous policy = %q, want the api:anonymous tier", policy)
+	}
+}
+
+func TestLoggedInUserIsChargedTheirTier(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	rules := []*ratelimit.RateLimitRule{
+		ratelimit.NewRule(ratelimit.ActionAPIBurst).MaxAttempts(20).Window(time.Second).BlockFor(time.Second).Build(),
+		ratelimit.NewRule("api").MaxAttempts(100).Window(time.Minute).BlockFor(time.Minute).Build(),
+		ratelimit.NewRule("api:anonymous").MaxAttempts(60).Window(time.Minute).BlockFor(time.Minute).Build(),
+		ratelimit.NewRule("api:user").MaxAttempts(300).Window(time.Minute).BlockFor(time.Minute).Build(),
+		ratelimit.NewRule("api:leader").MaxAttempts(600).Window(time.Minute).BlockFor(time.Minute).Build(),
+	}
+	limiter := ratelimit.NewRedisLimiter(client, rules, clk)
+	t.Cleanup(func() { limiter.Close() })
+
+	sessions := sessionStore{
+		"member-token": {ID: 7, Email: "member@example.com", Role: user.UserRoleUser, AccountStatus: user.AccountActive},
+		"leader-token": {ID: 8, Email: "leader@example.com", Role: user.UserRoleLeader, AccountStatus: user.AccountSuspended},
+	}
+	app := fiber.New()
+	Setup(app, limiter, nil, nil, nil, nil, memory.NewAPIKeyRepository(clk), sessions)
+
+	get := func(path, authorization string) (int, string) {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set(fiber.HeaderAuthorization, authorization)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, resp.Header.Get(ratelimit.HeaderRateLimitPolicy)
+	}
+
+	// Members pass rate limiting on their own quota, then the route's role check
+	status, policy := get("/api/v1/users", "Bearer member-token")
+	if status != fiber.StatusForbidden || !strings.Contains(policy, `"api:user"`) {
+		t.Errorf("member = %d with policy %q, want 403 on the api:user tier", status, policy)
+	}
+	charged, err := limiter.GetStatus(ctx, ratelimit.FormatIdentifier(ratelimit.IdentifierUserID, "7"), "api:user")
+	if err != nil || charged.Count != 1 {
+		t.Errorf("member quota = %+v, %v; want 1 attempt", charged, err)
+	}
+
+	// Suspended accounts are still charged before the route turns them away
+	if status, policy := get("/api/v1/users/8", "Bearer leader-token"); status != fiber.StatusForbidden || !strings.Contains(policy, `"api:leader"`) {
+		t.Errorf("leader = %d with policy %q, want 403 on the api:leader tier", status, policy)
+	}
+
+	for _, authorization := range []string{"Bearer unknown-token", "Basic bWVtYmVyOnNlY3JldA=="} {
+		if status, policy := get("/api/v1/users", authorization); status != fiber.StatusUnauthorized || policy != "" {
+			t.Errorf("%q = %d with policy %q, want 401 before rate limiting", authorization, status, policy)
+		}
+	}
+}